@@ -0,0 +1,278 @@
+package config
+
+import (
+	"challenge_pyegros/app/models"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidRateLimit       = errors.New("Invalid rate limit, the format is {requests}/{window}, e.g. 100/1m")
+	ErrInvalidReasonCatalogue = errors.New("Invalid reason catalogue, only Canceled and Returned reasons with a code are allowed")
+	ErrInvalidBulkConcurrency = errors.New("Invalid bulk concurrency, it must be a positive number")
+	ErrInvalidOrdersStore     = errors.New("Invalid orders store, it must be mongo or memory")
+	ErrInvalidGRPCMaxWatchers = errors.New("Invalid gRPC max watchers, it must be a positive number")
+	ErrInvalidTrustedProxies  = errors.New("Invalid trusted proxies, the format is a comma separated list of IPs or CIDRs, e.g. 10.0.0.0/8")
+)
+
+const (
+	OrdersStoreMongo  = "mongo"
+	OrdersStoreMemory = "memory"
+)
+
+type Config struct {
+	// JWTSecret is the shared secret used to verify HS256 tokens.
+	JWTSecret string
+	// JWKSFile is the path of a JSON Web Key Set with the public keys used to
+	// verify RS256 tokens.
+	JWKSFile    string
+	JWTIssuer   string
+	JWTAudience string
+
+	// RateLimitDefault applies to every route and client without a specific
+	// limit. RateLimitRoutes is keyed by route name and RateLimitClients by
+	// API key subject, JWT subject or IP, the client limit takes precedence.
+	RateLimitDefault RateLimit
+	RateLimitRoutes  map[string]RateLimit
+	RateLimitClients map[string]RateLimit
+	// RateLimitIP applies to every request of an IP before it is
+	// authenticated, so invalid credentials are limited too. RateLimitClients
+	// keyed by IP take precedence.
+	RateLimitIP RateLimit
+
+	// TrustedProxies are the networks of the proxies in front of the service.
+	// The source IP is read from X-Forwarded-For or X-Real-IP only for the
+	// requests coming from them, any client could set those headers.
+	TrustedProxies []*net.IPNet
+
+	// OrderCacheTTL is how long an order read by ID is kept in Redis.
+	OrderCacheTTL time.Duration
+
+	// SalesCacheTTL is how long a sales report is kept in Redis.
+	SalesCacheTTL time.Duration
+
+	// ReasonCatalogue lists the reason codes of Canceled and Returned
+	// events. It is read from the JSON file of REASON_CATALOGUE_FILE, or
+	// models.DefaultReasonCatalogue when it is not set.
+	ReasonCatalogue models.ReasonCatalogue
+
+	// BulkConcurrency is how many items of a bulk import are processed at
+	// the same time.
+	BulkConcurrency int
+
+	// DefaultLocale is the locale of the translations when the request asks
+	// for none of the locales. LocalesDir is a directory of {locale}.json
+	// files replacing the locales shipped with the service.
+	DefaultLocale string
+	LocalesDir    string
+
+	// OrdersStore is where the orders are kept: "mongo", or "memory" to run
+	// the API without the orders collection, losing them on every restart.
+	OrdersStore string
+
+	// RedisAddr is the address of Redis, empty to run without it: the
+	// caches and the idempotency of the orders are skipped and the rate
+	// limits are kept in memory.
+	RedisAddr string
+
+	// GRPCAddr is the address of the gRPC API of the orders, served on its
+	// own port next to the REST API. GRPCMaxWatchers is how many streams of
+	// WatchOrder can be open at the same time.
+	GRPCAddr        string
+	GRPCMaxWatchers int
+}
+
+type RateLimit struct {
+	Requests int
+	Window   time.Duration
+}
+
+func Load() (*Config, error) {
+	rateLimitDefault, err := ParseRateLimit(getEnv("RATE_LIMIT_DEFAULT", "100/1m"))
+	if err != nil {
+		return nil, err
+	}
+	rateLimitRoutes, err := parseRateLimits(os.Getenv("RATE_LIMIT_ROUTES"))
+	if err != nil {
+		return nil, err
+	}
+	rateLimitClients, err := parseRateLimits(os.Getenv("RATE_LIMIT_CLIENTS"))
+	if err != nil {
+		return nil, err
+	}
+	rateLimitIP, err := ParseRateLimit(getEnv("RATE_LIMIT_IP", "300/1m"))
+	if err != nil {
+		return nil, err
+	}
+
+	trustedProxies, err := parseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, err
+	}
+
+	orderCacheTTL, err := time.ParseDuration(getEnv("ORDER_CACHE_TTL", "5m"))
+	if err != nil {
+		return nil, err
+	}
+
+	salesCacheTTL, err := time.ParseDuration(getEnv("SALES_CACHE_TTL", "5m"))
+	if err != nil {
+		return nil, err
+	}
+
+	reasonCatalogue, err := loadReasonCatalogue(os.Getenv("REASON_CATALOGUE_FILE"))
+	if err != nil {
+		return nil, err
+	}
+
+	bulkConcurrency, err := strconv.Atoi(getEnv("BULK_CONCURRENCY", "8"))
+	if err != nil || bulkConcurrency < 1 {
+		return nil, ErrInvalidBulkConcurrency
+	}
+
+	grpcMaxWatchers, err := strconv.Atoi(getEnv("GRPC_MAX_WATCHERS", "100"))
+	if err != nil || grpcMaxWatchers < 1 {
+		return nil, ErrInvalidGRPCMaxWatchers
+	}
+
+	ordersStore := getEnv("ORDERS_STORE", OrdersStoreMongo)
+	if ordersStore != OrdersStoreMongo && ordersStore != OrdersStoreMemory {
+		return nil, ErrInvalidOrdersStore
+	}
+
+	return &Config{
+		JWTSecret:        os.Getenv("JWT_HS256_SECRET"),
+		JWKSFile:         os.Getenv("JWT_JWKS_FILE"),
+		JWTIssuer:        os.Getenv("JWT_ISSUER"),
+		JWTAudience:      os.Getenv("JWT_AUDIENCE"),
+		RateLimitDefault: rateLimitDefault,
+		RateLimitRoutes:  rateLimitRoutes,
+		RateLimitClients: rateLimitClients,
+		RateLimitIP:      rateLimitIP,
+		TrustedProxies:   trustedProxies,
+		OrderCacheTTL:    orderCacheTTL,
+		SalesCacheTTL:    salesCacheTTL,
+		ReasonCatalogue:  reasonCatalogue,
+		BulkConcurrency:  bulkConcurrency,
+		DefaultLocale:    getEnv("DEFAULT_LOCALE", "es-AR"),
+		LocalesDir:       os.Getenv("LOCALES_DIR"),
+		OrdersStore:      ordersStore,
+		RedisAddr:        getEnv("REDIS_ADDR", "redis:6379"),
+		GRPCAddr:         getEnv("GRPC_ADDR", ":9090"),
+		GRPCMaxWatchers:  grpcMaxWatchers,
+	}, nil
+}
+
+// ParseRateLimit parses a limit with the format {requests}/{window}, where the
+// window is a time.Duration, e.g. "100/1m".
+func ParseRateLimit(value string) (RateLimit, error) {
+	requests, window, found := strings.Cut(strings.TrimSpace(value), "/")
+	if !found {
+		return RateLimit{}, ErrInvalidRateLimit
+	}
+
+	requestsInt, err := strconv.Atoi(requests)
+	if err != nil || requestsInt < 1 {
+		return RateLimit{}, ErrInvalidRateLimit
+	}
+	windowDuration, err := time.ParseDuration(window)
+	if err != nil || windowDuration <= 0 {
+		return RateLimit{}, ErrInvalidRateLimit
+	}
+
+	return RateLimit{Requests: requestsInt, Window: windowDuration}, nil
+}
+
+// parseRateLimits parses a comma separated list of {name}={limit}, e.g.
+// "create-order=20/1m,search-orders=50/1m".
+func parseRateLimits(value string) (map[string]RateLimit, error) {
+	limits := map[string]RateLimit{}
+	if strings.TrimSpace(value) == "" {
+		return limits, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		name, limit, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, ErrInvalidRateLimit
+		}
+		rateLimit, err := ParseRateLimit(limit)
+		if err != nil {
+			return nil, err
+		}
+		limits[strings.TrimSpace(name)] = rateLimit
+	}
+
+	return limits, nil
+}
+
+// parseTrustedProxies parses a comma separated list of IPs or CIDRs, e.g.
+// "10.0.0.0/8,192.168.1.10".
+func parseTrustedProxies(value string) ([]*net.IPNet, error) {
+	networks := []*net.IPNet{}
+	if strings.TrimSpace(value) == "" {
+		return networks, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, ErrInvalidTrustedProxies
+			}
+			entry = ip.String() + "/128"
+			if ip.To4() != nil {
+				entry = ip.String() + "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, ErrInvalidTrustedProxies
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+func loadReasonCatalogue(path string) (models.ReasonCatalogue, error) {
+	if path == "" {
+		return models.DefaultReasonCatalogue, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var catalogue models.ReasonCatalogue
+	if err = json.Unmarshal(content, &catalogue); err != nil {
+		return nil, err
+	}
+
+	for eventType, reasons := range catalogue {
+		if eventType != models.EventCanceled && eventType != models.EventReturned {
+			return nil, ErrInvalidReasonCatalogue
+		}
+		for _, reason := range reasons {
+			if reason.Code == "" {
+				return nil, ErrInvalidReasonCatalogue
+			}
+		}
+	}
+
+	return catalogue, nil
+}
+
+func getEnv(key string, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return defaultValue
+}
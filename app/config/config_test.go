@@ -0,0 +1,155 @@
+package config
+
+import (
+	"challenge_pyegros/app/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	limit, err := ParseRateLimit("100/1m")
+	assert.NoError(t, err)
+	assert.Equal(t, RateLimit{Requests: 100, Window: time.Minute}, limit)
+
+	for _, value := range []string{"", "100", "0/1m", "abc/1m", "100/abc", "100/-1s"} {
+		_, err = ParseRateLimit(value)
+		assert.Equal(t, ErrInvalidRateLimit, err, value)
+	}
+}
+
+func TestLoadRateLimits(t *testing.T) {
+	t.Setenv("RATE_LIMIT_DEFAULT", "10/1s")
+	t.Setenv("RATE_LIMIT_ROUTES", "create-order=20/1m, search-orders=5/10s")
+	t.Setenv("RATE_LIMIT_CLIENTS", "10.0.0.1=1/1h")
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, RateLimit{Requests: 10, Window: time.Second}, cfg.RateLimitDefault)
+	assert.Equal(t, map[string]RateLimit{
+		"create-order":  {Requests: 20, Window: time.Minute},
+		"search-orders": {Requests: 5, Window: 10 * time.Second},
+	}, cfg.RateLimitRoutes)
+	assert.Equal(t, map[string]RateLimit{"10.0.0.1": {Requests: 1, Window: time.Hour}}, cfg.RateLimitClients)
+	assert.Equal(t, RateLimit{Requests: 300, Window: time.Minute}, cfg.RateLimitIP)
+
+	t.Setenv("RATE_LIMIT_IP", "50/10s")
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, RateLimit{Requests: 50, Window: 10 * time.Second}, cfg.RateLimitIP)
+
+	t.Setenv("RATE_LIMIT_ROUTES", "create-order")
+	_, err = Load()
+	assert.Equal(t, ErrInvalidRateLimit, err)
+}
+
+func TestLoadReasonCatalogue(t *testing.T) {
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, models.DefaultReasonCatalogue, cfg.ReasonCatalogue)
+
+	path := filepath.Join(t.TempDir(), "reasons.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"Canceled": [{"code": "NO_STOCK", "description": "No stock"}]}`), 0o600))
+	t.Setenv("REASON_CATALOGUE_FILE", path)
+
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReasonCatalogue{"Canceled": {{Code: "NO_STOCK", Description: "No stock"}}}, cfg.ReasonCatalogue)
+
+	assert.NoError(t, os.WriteFile(path, []byte(`{"Invoiced": [{"code": "LATE"}]}`), 0o600))
+	_, err = Load()
+	assert.Equal(t, ErrInvalidReasonCatalogue, err)
+}
+
+func TestLoadBulkConcurrency(t *testing.T) {
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 8, cfg.BulkConcurrency)
+
+	t.Setenv("BULK_CONCURRENCY", "0")
+	_, err = Load()
+	assert.Equal(t, ErrInvalidBulkConcurrency, err)
+}
+
+func TestLoadGRPCMaxWatchers(t *testing.T) {
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, 100, cfg.GRPCMaxWatchers)
+
+	t.Setenv("GRPC_MAX_WATCHERS", "none")
+	_, err = Load()
+	assert.Equal(t, ErrInvalidGRPCMaxWatchers, err)
+}
+
+func TestLoadLocale(t *testing.T) {
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "es-AR", cfg.DefaultLocale)
+	assert.Equal(t, "", cfg.LocalesDir)
+
+	t.Setenv("DEFAULT_LOCALE", "en")
+	t.Setenv("LOCALES_DIR", "/etc/orders/locales")
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "en", cfg.DefaultLocale)
+	assert.Equal(t, "/etc/orders/locales", cfg.LocalesDir)
+}
+
+func TestLoadOrdersStore(t *testing.T) {
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, OrdersStoreMongo, cfg.OrdersStore)
+
+	t.Setenv("ORDERS_STORE", "memory")
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, OrdersStoreMemory, cfg.OrdersStore)
+
+	t.Setenv("ORDERS_STORE", "postgres")
+	_, err = Load()
+	assert.Equal(t, ErrInvalidOrdersStore, err)
+}
+
+func TestLoadTrustedProxies(t *testing.T) {
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Empty(t, cfg.TrustedProxies)
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.10,::1")
+	cfg, err = Load()
+	assert.NoError(t, err)
+	networks := []string{}
+	for _, network := range cfg.TrustedProxies {
+		networks = append(networks, network.String())
+	}
+	assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.10/32", "::1/128"}, networks)
+
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.0/8,proxy")
+	_, err = Load()
+	assert.Equal(t, ErrInvalidTrustedProxies, err)
+}
+
+func TestLoadRedisAddr(t *testing.T) {
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "redis:6379", cfg.RedisAddr)
+
+	t.Setenv("REDIS_ADDR", "")
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, "", cfg.RedisAddr)
+}
+
+func TestLoadGRPCAddr(t *testing.T) {
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.Equal(t, ":9090", cfg.GRPCAddr)
+
+	t.Setenv("GRPC_ADDR", ":50051")
+	cfg, err = Load()
+	assert.NoError(t, err)
+	assert.Equal(t, ":50051", cfg.GRPCAddr)
+}
@@ -0,0 +1,257 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// IndexMissing is an index of the registry that does not exist.
+	IndexMissing = "missing"
+	// IndexDrift is an index with the name of one of the registry but other
+	// keys or options. It is never changed automatically.
+	IndexDrift = "drift"
+	// IndexUnexpected is an index that is not in the registry.
+	IndexUnexpected = "unexpected"
+)
+
+// namespaceNotFound is the code of listIndexes on a collection that does not
+// exist yet.
+const namespaceNotFound = 26
+
+type Index struct {
+	Name   string
+	Keys   bson.D
+	Unique bool
+}
+
+type CollectionIndexes struct {
+	Database   string
+	Collection string
+	Indexes    []Index
+}
+
+type IndexProblem struct {
+	Collection string
+	Index      string
+	Problem    string
+}
+
+func (p IndexProblem) String() string {
+	return fmt.Sprintf("%s.%s: %s", p.Collection, p.Index, p.Problem)
+}
+
+// IndexRegistry declares the indexes of every collection of the service.
+// The _id index is implicit.
+var IndexRegistry = []CollectionIndexes{
+	{
+		Database:   "orders",
+		Collection: "orders",
+		Indexes: []Index{
+			{Name: "id_unique", Keys: bson.D{{Key: "id", Value: 1}}, Unique: true},
+			{Name: "buyer_documentNumber_purchaseDate", Keys: bson.D{{Key: "buyer.documentNumber", Value: 1}, {Key: "purchaseDate", Value: -1}}},
+			{Name: "status_purchaseDate", Keys: bson.D{{Key: "status", Value: 1}, {Key: "purchaseDate", Value: -1}}},
+			{Name: "channel_purchaseDate", Keys: bson.D{{Key: "channel", Value: 1}, {Key: "purchaseDate", Value: -1}}},
+			{Name: "purchaseDate", Keys: bson.D{{Key: "purchaseDate", Value: -1}}},
+		},
+	},
+	{
+		Database:   "orders",
+		Collection: "audit_log",
+		Indexes: []Index{
+			{Name: "timestamp", Keys: bson.D{{Key: "timestamp", Value: 1}}},
+			{Name: "orderID_timestamp", Keys: bson.D{{Key: "orderID", Value: 1}, {Key: "timestamp", Value: 1}}},
+			{Name: "actor_timestamp", Keys: bson.D{{Key: "actor", Value: 1}, {Key: "timestamp", Value: 1}}},
+		},
+	},
+	{
+		Database:   "orders",
+		Collection: "api_keys",
+		Indexes: []Index{
+			{Name: "hash_unique", Keys: bson.D{{Key: "hash", Value: 1}}, Unique: true},
+		},
+	},
+}
+
+// existingIndex is an entry of listIndexes.
+type existingIndex struct {
+	Name   string `bson:"name"`
+	Key    bson.D `bson:"key"`
+	Unique bool   `bson:"unique"`
+}
+
+// CheckIndexes compares the indexes of every collection of the registry with
+// the declared ones, without changing anything.
+func CheckIndexes(client *mongo.Client, registry []CollectionIndexes) ([]IndexProblem, error) {
+	problems := []IndexProblem{}
+	for _, collection := range registry {
+		existing, err := listIndexes(client, collection)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, compareIndexes(collection, existing)...)
+	}
+	return problems, nil
+}
+
+// EnsureIndexes creates the missing indexes of the registry. Indexes with
+// drift or not in the registry are only reported, because dropping or
+// rebuilding an index is left to a person.
+func EnsureIndexes(client *mongo.Client, registry []CollectionIndexes) ([]IndexProblem, error) {
+	problems := []IndexProblem{}
+	for _, collection := range registry {
+		existing, err := listIndexes(client, collection)
+		if err != nil {
+			return nil, err
+		}
+
+		missing := []mongo.IndexModel{}
+		for _, problem := range compareIndexes(collection, existing) {
+			if problem.Problem != IndexMissing {
+				problems = append(problems, problem)
+				continue
+			}
+			for _, index := range collection.Indexes {
+				if index.Name == problem.Index {
+					missing = append(missing, mongo.IndexModel{
+						Keys:    index.Keys,
+						Options: options.Index().SetName(index.Name).SetUnique(index.Unique),
+					})
+				}
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		names, err := client.Database(collection.Database).Collection(collection.Collection).Indexes().CreateMany(context.TODO(), missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			log.Println("Created index " + collection.Collection + "." + name)
+		}
+	}
+	return problems, nil
+}
+
+func listIndexes(client *mongo.Client, collection CollectionIndexes) ([]existingIndex, error) {
+	cursor, err := client.Database(collection.Database).Collection(collection.Collection).Indexes().List(context.TODO())
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && commandErr.Code == namespaceNotFound {
+		return []existingIndex{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	existing := []existingIndex{}
+	if err = cursor.All(context.TODO(), &existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+func compareIndexes(collection CollectionIndexes, existing []existingIndex) []IndexProblem {
+	problems := []IndexProblem{}
+
+	byName := map[string]existingIndex{}
+	for _, index := range existing {
+		byName[index.Name] = index
+	}
+
+	declared := map[string]bool{"_id_": true}
+	for _, index := range collection.Indexes {
+		declared[index.Name] = true
+
+		current, ok := byName[index.Name]
+		if !ok {
+			problems = append(problems, IndexProblem{Collection: collection.Collection, Index: index.Name, Problem: IndexMissing})
+		} else if current.Unique != index.Unique || !sameKeys(current.Key, index.Keys) {
+			problems = append(problems, IndexProblem{Collection: collection.Collection, Index: index.Name, Problem: IndexDrift})
+		}
+	}
+
+	for _, index := range existing {
+		if !declared[index.Name] {
+			problems = append(problems, IndexProblem{Collection: collection.Collection, Index: index.Name, Problem: IndexUnexpected})
+		}
+	}
+
+	return problems
+}
+
+// sameKeys compares the keys of two indexes in order. Mongo returns the
+// directions as int32 or double, so numbers are compared by value.
+func sameKeys(a bson.D, b bson.D) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Key != b[i].Key || fmt.Sprint(keyValue(a[i].Value)) != fmt.Sprint(keyValue(b[i].Value)) {
+			return false
+		}
+	}
+	return true
+}
+
+func keyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	}
+	return value
+}
+
+// ExplainUsesIndex tells whether the winning plan of an explain output reads
+// an index, and no stage of it scans the whole collection.
+func ExplainUsesIndex(explain bson.M) bool {
+	planner, ok := explain["queryPlanner"].(bson.M)
+	if !ok {
+		return false
+	}
+	plan, ok := planner["winningPlan"].(bson.M)
+	if !ok {
+		return false
+	}
+	// Since Mongo 7 the plan of the classic engine is nested in queryPlan.
+	if queryPlan, ok := plan["queryPlan"].(bson.M); ok {
+		plan = queryPlan
+	}
+
+	usesIndex, scansCollection := walkPlan(plan)
+	return usesIndex && !scansCollection
+}
+
+func walkPlan(stage bson.M) (bool, bool) {
+	usesIndex := stage["stage"] == "IXSCAN" || stage["stage"] == "IDHACK" || stage["stage"] == "EXPRESS_IXSCAN"
+	scansCollection := stage["stage"] == "COLLSCAN"
+
+	children := []bson.M{}
+	if input, ok := stage["inputStage"].(bson.M); ok {
+		children = append(children, input)
+	}
+	if inputs, ok := stage["inputStages"].(bson.A); ok {
+		for _, input := range inputs {
+			if child, ok := input.(bson.M); ok {
+				children = append(children, child)
+			}
+		}
+	}
+
+	for _, child := range children {
+		childIndex, childScan := walkPlan(child)
+		usesIndex = usesIndex || childIndex
+		scansCollection = scansCollection || childScan
+	}
+	return usesIndex, scansCollection
+}
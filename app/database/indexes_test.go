@@ -0,0 +1,102 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+var testRegistry = []CollectionIndexes{
+	{
+		Database:   "orders",
+		Collection: "orders",
+		Indexes: []Index{
+			{Name: "id_unique", Keys: bson.D{{Key: "id", Value: 1}}, Unique: true},
+			{Name: "status_purchaseDate", Keys: bson.D{{Key: "status", Value: 1}, {Key: "purchaseDate", Value: -1}}},
+			{Name: "purchaseDate", Keys: bson.D{{Key: "purchaseDate", Value: -1}}},
+		},
+	},
+}
+
+func listIndexesResponse(indexes ...bson.D) bson.D {
+	return mtest.CreateCursorResponse(0, "orders.orders", mtest.FirstBatch, indexes...)
+}
+
+func TestCheckIndexes(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("reports the differences", func(mt *mtest.T) {
+		mt.AddMockResponses(listIndexesResponse(
+			bson.D{{Key: "name", Value: "_id_"}, {Key: "key", Value: bson.D{{Key: "_id", Value: int32(1)}}}},
+			bson.D{{Key: "name", Value: "id_unique"}, {Key: "key", Value: bson.D{{Key: "id", Value: int32(1)}}}, {Key: "unique", Value: true}},
+			bson.D{{Key: "name", Value: "status_purchaseDate"}, {Key: "key", Value: bson.D{{Key: "status", Value: int32(1)}}}},
+			bson.D{{Key: "name", Value: "channel"}, {Key: "key", Value: bson.D{{Key: "channel", Value: 1.0}}}},
+		))
+
+		problems, err := CheckIndexes(mt.Client, testRegistry)
+		assert.Nil(t, err)
+		assert.Equal(t, []IndexProblem{
+			{Collection: "orders", Index: "status_purchaseDate", Problem: IndexDrift},
+			{Collection: "orders", Index: "purchaseDate", Problem: IndexMissing},
+			{Collection: "orders", Index: "channel", Problem: IndexUnexpected},
+		}, problems)
+	})
+
+	mt.Run("missing collection", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: namespaceNotFound, Name: "NamespaceNotFound", Message: "ns does not exist"}))
+
+		problems, err := CheckIndexes(mt.Client, testRegistry)
+		assert.Nil(t, err)
+		assert.Len(t, problems, 3)
+	})
+}
+
+func TestEnsureIndexes(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("creates the missing indexes", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			listIndexesResponse(
+				bson.D{{Key: "name", Value: "_id_"}, {Key: "key", Value: bson.D{{Key: "_id", Value: int32(1)}}}},
+				bson.D{{Key: "name", Value: "id_unique"}, {Key: "key", Value: bson.D{{Key: "id", Value: int32(1)}}}},
+			),
+			mtest.CreateSuccessResponse(),
+		)
+
+		problems, err := EnsureIndexes(mt.Client, testRegistry)
+		assert.Nil(t, err)
+		assert.Equal(t, []IndexProblem{{Collection: "orders", Index: "id_unique", Problem: IndexDrift}}, problems)
+
+		mt.GetStartedEvent()
+		command := mt.GetStartedEvent().Command
+		assert.Equal(t, "orders", command.Lookup("createIndexes").StringValue())
+		indexes, err := command.Lookup("indexes").Array().Values()
+		assert.Nil(t, err)
+		assert.Len(t, indexes, 2)
+	})
+}
+
+func TestExplainUsesIndex(t *testing.T) {
+	explain := func(plan bson.M) bson.M {
+		return bson.M{"queryPlanner": bson.M{"winningPlan": plan}}
+	}
+
+	assert.True(t, ExplainUsesIndex(explain(bson.M{"stage": "FETCH", "inputStage": bson.M{"stage": "IXSCAN"}})))
+	assert.True(t, ExplainUsesIndex(explain(bson.M{"queryPlan": bson.M{"stage": "FETCH", "inputStage": bson.M{"stage": "IXSCAN"}}})))
+	assert.True(t, ExplainUsesIndex(explain(bson.M{"stage": "FETCH", "inputStage": bson.M{"stage": "AND_SORTED", "inputStages": bson.A{
+		bson.M{"stage": "IXSCAN"},
+		bson.M{"stage": "IXSCAN"},
+	}}})))
+	assert.False(t, ExplainUsesIndex(explain(bson.M{"stage": "COLLSCAN"})))
+	assert.False(t, ExplainUsesIndex(explain(bson.M{"stage": "SUBPLAN", "inputStage": bson.M{"stage": "OR", "inputStages": bson.A{
+		bson.M{"stage": "IXSCAN"},
+		bson.M{"stage": "COLLSCAN"},
+	}}})))
+	assert.False(t, ExplainUsesIndex(bson.M{}))
+}
+
+func TestSameKeys(t *testing.T) {
+	assert.True(t, sameKeys(bson.D{{Key: "a", Value: int32(1)}, {Key: "b", Value: -1.0}}, bson.D{{Key: "a", Value: 1}, {Key: "b", Value: -1}}))
+	assert.False(t, sameKeys(bson.D{{Key: "a", Value: 1}}, bson.D{{Key: "a", Value: -1}}))
+	assert.False(t, sameKeys(bson.D{{Key: "a", Value: 1}, {Key: "b", Value: 1}}, bson.D{{Key: "b", Value: 1}, {Key: "a", Value: 1}}))
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	// KeyNamespace prefixes every key of the service, so the Redis can be
+	// shared with other services.
+	KeyNamespace = "orders-api"
+	// KeySchemaVersion must be bumped whenever the shape of a cached value
+	// changes, so values stored with the previous shape are not read back.
+	KeySchemaVersion = "v2"
+)
+
+// Key builds a key as {namespace}:{version}:{parts...}.
+func Key(parts ...string) string {
+	return strings.Join(append([]string{KeyNamespace, KeySchemaVersion}, parts...), ":")
+}
+
+// KeyPattern scopes a SCAN pattern to the namespace of the service. The
+// version is part of the pattern, so keys of previous versions can be matched.
+func KeyPattern(pattern string) string {
+	return KeyNamespace + ":" + pattern
+}
+
+func OrderIdempotencyKey(key string) string {
+	return Key("idempotency", "order", key)
+}
+
+func EventIdempotencyKey(orderID int64, eventID string) string {
+	return Key("idempotency", "order", strconv.FormatInt(orderID, 10), "event", eventID)
+}
+
+func OrderDetailKey(orderID int64) string {
+	return Key("order", strconv.FormatInt(orderID, 10))
+}
+
+func RateLimitKey(route string, client string) string {
+	return Key("ratelimit", route, client)
+}
+
+func SalesReportKey(groupBy string, from string, to string) string {
+	return Key("report", "sales", groupBy, from, to)
+}
@@ -0,0 +1,16 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeys(t *testing.T) {
+	assert.Equal(t, "orders-api:v2:idempotency:order:abc-123-Ecommerce", OrderIdempotencyKey("abc-123-Ecommerce"))
+	assert.Equal(t, "orders-api:v2:idempotency:order:1:event:event-001", EventIdempotencyKey(1, "event-001"))
+	assert.Equal(t, "orders-api:v2:order:1", OrderDetailKey(1))
+	assert.Equal(t, "orders-api:v2:ratelimit:create-order:10.0.0.1", RateLimitKey("create-order", "10.0.0.1"))
+	assert.Equal(t, "orders-api:v2:report:sales:channel:2024-05-01T00:00:00Z:", SalesReportKey("channel", "2024-05-01T00:00:00Z", ""))
+	assert.Equal(t, "orders-api:v2:order:*", KeyPattern("v2:order:*"))
+}
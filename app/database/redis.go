@@ -4,15 +4,27 @@ import (
 	"challenge_pyegros/app/models"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
-func ConnectRedis() *redis.Client {
+// ErrRedisUnavailable is returned by the helpers of this file without a Redis
+// client, the callers handle it as any other failure of Redis.
+var ErrRedisUnavailable = errors.New("Redis is not available")
+
+// ConnectRedis returns nil when the address is empty or Redis does not answer,
+// the service runs without Redis then.
+func ConnectRedis(addr string) *redis.Client {
+	if addr == "" {
+		fmt.Println("Redis is disabled")
+		return nil
+	}
+
 	rdb := redis.NewClient(&redis.Options{
-		Addr:     "redis:6379",
+		Addr:     addr,
 		Password: "",
 		DB:       0,
 	})
@@ -26,18 +38,26 @@ func ConnectRedis() *redis.Client {
 	return rdb
 }
 
-func SetEventDataFromRedis(key string, eventResponse *models.ResponseUpdate, rdb *redis.Client) error {
+func SetEventDataFromRedis(orderID int64, eventID string, eventResponse *models.ResponseUpdate, rdb *redis.Client) error {
+	if rdb == nil {
+		return ErrRedisUnavailable
+	}
+
 	value, err := json.Marshal(eventResponse)
 	if err != nil {
 		return err
 	}
 
-	err = rdb.Set(context.Background(), "event: "+key, value, 24*time.Hour).Err()
+	err = rdb.Set(context.Background(), EventIdempotencyKey(orderID, eventID), value, 24*time.Hour).Err()
 	return err
 }
 
-func GetEventDataFromRedis(key string, rdb *redis.Client) (*models.ResponseUpdate, error) {
-	val, err := rdb.Get(context.Background(), "event: "+key).Bytes()
+func GetEventDataFromRedis(orderID int64, eventID string, rdb *redis.Client) (*models.ResponseUpdate, error) {
+	if rdb == nil {
+		return nil, ErrRedisUnavailable
+	}
+
+	val, err := rdb.Get(context.Background(), EventIdempotencyKey(orderID, eventID)).Bytes()
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +71,11 @@ func GetEventDataFromRedis(key string, rdb *redis.Client) (*models.ResponseUpdat
 }
 
 func GetOrderDataFromRedis(key string, rdb *redis.Client) (*models.ResponseCreate, error) {
-	val, err := rdb.Get(context.Background(), "order: "+key).Bytes()
+	if rdb == nil {
+		return nil, ErrRedisUnavailable
+	}
+
+	val, err := rdb.Get(context.Background(), OrderIdempotencyKey(key)).Bytes()
 	if err != nil {
 		return nil, err
 	}
@@ -64,11 +88,85 @@ func GetOrderDataFromRedis(key string, rdb *redis.Client) (*models.ResponseCreat
 }
 
 func SetOrderDataFromRedis(key string, orderResponse *models.ResponseCreate, rdb *redis.Client) error {
+	if rdb == nil {
+		return ErrRedisUnavailable
+	}
+
 	value, err := json.Marshal(orderResponse)
 	if err != nil {
 		return err
 	}
 
-	err = rdb.Set(context.Background(), "order: "+key, value, 24*time.Hour).Err()
+	err = rdb.Set(context.Background(), OrderIdempotencyKey(key), value, 24*time.Hour).Err()
+	return err
+}
+
+func GetOrderDetailFromRedis(orderID int64, rdb *redis.Client) (*models.ResponseGet, error) {
+	if rdb == nil {
+		return nil, ErrRedisUnavailable
+	}
+
+	val, err := rdb.Get(context.Background(), OrderDetailKey(orderID)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var response models.ResponseGet
+	if err = json.Unmarshal(val, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func SetOrderDetailFromRedis(orderID int64, orderResponse *models.ResponseGet, ttl time.Duration, rdb *redis.Client) error {
+	if rdb == nil {
+		return ErrRedisUnavailable
+	}
+
+	value, err := json.Marshal(orderResponse)
+	if err != nil {
+		return err
+	}
+
+	err = rdb.Set(context.Background(), OrderDetailKey(orderID), value, ttl).Err()
+	return err
+}
+
+func DeleteOrderDetailFromRedis(orderID int64, rdb *redis.Client) error {
+	if rdb == nil {
+		return ErrRedisUnavailable
+	}
+
+	return rdb.Del(context.Background(), OrderDetailKey(orderID)).Err()
+}
+
+func GetSalesReportFromRedis(filters models.SalesFilters, rdb *redis.Client) (*models.ResponseSalesReport, error) {
+	if rdb == nil {
+		return nil, ErrRedisUnavailable
+	}
+
+	val, err := rdb.Get(context.Background(), SalesReportKey(filters.GroupBy, filters.From, filters.To)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var response models.ResponseSalesReport
+	if err = json.Unmarshal(val, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func SetSalesReportFromRedis(filters models.SalesFilters, report *models.ResponseSalesReport, ttl time.Duration, rdb *redis.Client) error {
+	if rdb == nil {
+		return ErrRedisUnavailable
+	}
+
+	value, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	err = rdb.Set(context.Background(), SalesReportKey(filters.GroupBy, filters.From, filters.To), value, ttl).Err()
 	return err
 }
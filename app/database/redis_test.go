@@ -0,0 +1,29 @@
+package database
+
+import (
+	"challenge_pyegros/app/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisHelpersWithoutClient(t *testing.T) {
+	assert.Nil(t, ConnectRedis(""))
+
+	_, err := GetOrderDataFromRedis("abc-123-Ecommerce", nil)
+	assert.Equal(t, ErrRedisUnavailable, err)
+	assert.Equal(t, ErrRedisUnavailable, SetOrderDataFromRedis("abc-123-Ecommerce", &models.ResponseCreate{}, nil))
+
+	_, err = GetEventDataFromRedis(1, "event-001", nil)
+	assert.Equal(t, ErrRedisUnavailable, err)
+	assert.Equal(t, ErrRedisUnavailable, SetEventDataFromRedis(1, "event-001", &models.ResponseUpdate{}, nil))
+
+	_, err = GetOrderDetailFromRedis(1, nil)
+	assert.Equal(t, ErrRedisUnavailable, err)
+	assert.Equal(t, ErrRedisUnavailable, SetOrderDetailFromRedis(1, &models.ResponseGet{}, 0, nil))
+	assert.Equal(t, ErrRedisUnavailable, DeleteOrderDetailFromRedis(1, nil))
+
+	_, err = GetSalesReportFromRedis(models.SalesFilters{}, nil)
+	assert.Equal(t, ErrRedisUnavailable, err)
+	assert.Equal(t, ErrRedisUnavailable, SetSalesReportFromRedis(models.SalesFilters{}, &models.ResponseSalesReport{}, 0, nil))
+}
@@ -0,0 +1,156 @@
+package apikeys
+
+import (
+	"challenge_pyegros/app/models"
+	apiKeysRepository "challenge_pyegros/app/repositories/apikeys"
+	"challenge_pyegros/app/utils"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// IssueAPIKey godoc
+// @Summary Issues an API key
+// @Description Issues an API key bound to a channel with the given scopes. The key is only returned in this response
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param apiKey body models.APIKeyRequest true "api key"
+// @Success 201 {object} models.ResponseAPIKey
+// @Failure 400 {object} models.ResponseError
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+// @Security BearerAuth
+// @Router /admin/api-keys [post]
+func (h *Handler) IssueAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.WriteError(w, `{"error": "Failed to read request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	var request models.APIKeyRequest
+	err = json.Unmarshal(body, &request)
+	if err != nil {
+		utils.WriteError(w, `{"error": "Error unmarshaling JSON"}`, http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.u.IssueAPIKey(request)
+	if err == apiKeysRepository.ErrChannelNotFound || err == apiKeysRepository.ErrInvalidScope || err == apiKeysRepository.ErrMissingScopes {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusBadRequest)
+		return
+	} else if err != nil {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
+		return
+	}
+
+	json, err := json.Marshal(response)
+	if err != nil {
+		utils.WriteError(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
+		return
+	}
+
+	utils.RecordAudit(h.audit, r, models.AuditEntry{
+		Action: models.AuditActionIssueAPIKey,
+		Target: response.ID,
+	})
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write(json)
+}
+
+// RotateAPIKey godoc
+// @Summary Rotates an API key
+// @Description Replaces the key of an API key keeping its channel and scopes. The previous key stops working
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param keyId path string true "api key id"
+// @Success 200 {object} models.ResponseAPIKey
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 404 {object} models.ResponseError
+// @Failure 409 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+// @Security BearerAuth
+// @Router /admin/api-keys/{keyId}/rotate [post]
+func (h *Handler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	keyID := chi.URLParam(r, "keyId")
+
+	response, err := h.u.RotateAPIKey(keyID)
+	if err == mongo.ErrNoDocuments {
+		utils.WriteError(w, `{"error": "The search did not return any results. Incorrect ID."}`, http.StatusNotFound)
+		return
+	} else if err == apiKeysRepository.ErrAPIKeyRevoked {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusConflict)
+		return
+	} else if err != nil {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
+		return
+	}
+
+	json, err := json.Marshal(response)
+	if err != nil {
+		utils.WriteError(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
+		return
+	}
+
+	utils.RecordAudit(h.audit, r, models.AuditEntry{
+		Action: models.AuditActionRotateAPIKey,
+		Target: response.ID,
+	})
+
+	w.Write(json)
+}
+
+// RevokeAPIKey godoc
+// @Summary Revokes an API key
+// @Description Revokes an API key, it can not be used or rotated anymore
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param keyId path string true "api key id"
+// @Success 200 {object} models.APIKey
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 404 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+// @Security BearerAuth
+// @Router /admin/api-keys/{keyId} [delete]
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	keyID := chi.URLParam(r, "keyId")
+
+	response, err := h.u.RevokeAPIKey(keyID)
+	if err == mongo.ErrNoDocuments {
+		utils.WriteError(w, `{"error": "The search did not return any results. Incorrect ID."}`, http.StatusNotFound)
+		return
+	} else if err != nil {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
+		return
+	}
+
+	json, err := json.Marshal(response)
+	if err != nil {
+		utils.WriteError(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
+		return
+	}
+
+	utils.RecordAudit(h.audit, r, models.AuditEntry{
+		Action: models.AuditActionRevokeAPIKey,
+		Target: response.ID,
+	})
+
+	w.Write(json)
+}
@@ -0,0 +1,18 @@
+package apikeys
+
+import (
+	ports "challenge_pyegros/app/ports/apikeys"
+	auditPorts "challenge_pyegros/app/ports/audit"
+)
+
+type Handler struct {
+	u     ports.APIKeysUseCase
+	audit auditPorts.AuditUseCase
+}
+
+func NewHandler(u ports.APIKeysUseCase, audit auditPorts.AuditUseCase) *Handler {
+	return &Handler{
+		u:     u,
+		audit: audit,
+	}
+}
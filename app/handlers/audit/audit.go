@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"challenge_pyegros/app/models"
+	"challenge_pyegros/app/utils"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var csvHeader = []string{"timestamp", "actor", "action", "orderID", "previousStatus", "newStatus", "target", "requestID", "sourceIP"}
+
+// GetAuditEntries godoc
+// @Summary Get the audit trail
+// @Description Gets the audit entries that match certain filters (Actor, Action, OrderId, From, To). Responds with a CSV export of every matching entry when format=csv or the Accept header is text/csv
+// @Tags audit
+// @Accept json
+// @Produce json,text/csv
+// @Param actor query string false "actor"
+// @Param action query string false "action"
+// @Param orderId query string false "order id, a non numeric id is ignored"
+// @Param from query string false "from (RFC3339)"
+// @Param to query string false "to (RFC3339)"
+// @Param page query int false "page number" default(1)
+// @Param pageSize query int false "page size" default(20)
+// @Param format query string false "csv to export the entries"
+// @Success 200 {object} []models.AuditEntry
+// @Failure 400 {object} models.ResponseError
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /audit [get]
+func (h *Handler) GetAuditEntries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	filters := utils.GetAuditFilters(r)
+
+	err := utils.CheckFormatDate(filters.From)
+	if err != nil {
+		utils.WriteError(w, `{"error": "The From date is not in the correct format"}`, http.StatusBadRequest)
+		return
+	}
+	err = utils.CheckFormatDate(filters.To)
+	if err != nil {
+		utils.WriteError(w, `{"error": "The To date is not in the correct format"}`, http.StatusBadRequest)
+		return
+	}
+
+	exportCSV := r.URL.Query().Get("format") == "csv" || strings.Contains(r.Header.Get("Accept"), "text/csv")
+	if exportCSV {
+		filters.Page = 0
+		filters.PageSize = 0
+	}
+
+	entries, err := h.u.GetAuditEntries(filters)
+	if err != nil {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
+		return
+	}
+
+	if exportCSV {
+		writeCSV(w, entries)
+		return
+	}
+
+	json, err := json.Marshal(entries)
+	if err != nil {
+		utils.WriteError(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(json)
+}
+
+func writeCSV(w http.ResponseWriter, entries []models.AuditEntry) {
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="audit_log.csv"`)
+
+	writer := csv.NewWriter(w)
+	writer.Write(csvHeader)
+	for _, entry := range entries {
+		writer.Write([]string{
+			entry.Timestamp,
+			entry.Actor,
+			entry.Action,
+			strconv.FormatInt(entry.OrderID, 10),
+			string(entry.PreviousStatus),
+			string(entry.NewStatus),
+			entry.Target,
+			entry.RequestID,
+			entry.SourceIP,
+		})
+	}
+	writer.Flush()
+}
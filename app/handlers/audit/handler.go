@@ -0,0 +1,15 @@
+package audit
+
+import (
+	ports "challenge_pyegros/app/ports/audit"
+)
+
+type Handler struct {
+	u ports.AuditUseCase
+}
+
+func NewHandler(u ports.AuditUseCase) *Handler {
+	return &Handler{
+		u: u,
+	}
+}
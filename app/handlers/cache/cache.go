@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"challenge_pyegros/app/database"
+	"challenge_pyegros/app/models"
+	cacheRepository "challenge_pyegros/app/repositories/cache"
+	"challenge_pyegros/app/utils"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultKeysLimit = 100
+	maxKeysLimit     = 1000
+)
+
+// GetCacheKeys godoc
+// @Summary Inspect cache keys
+// @Description Lists the Redis keys of the service that match a pattern, with their type and TTL in seconds. The pattern is scoped to the service namespace and starts with the schema version, e.g. v2:order:*
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param pattern query string true "pattern, e.g. v2:idempotency:*"
+// @Param limit query int false "max amount of keys" default(100)
+// @Success 200 {object} models.ResponseCacheKeys
+// @Failure 400 {object} models.ResponseError
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+// @Failure 503 {object} models.ResponseError
+// @Security BearerAuth
+// @Router /admin/cache/keys [get]
+func (h *Handler) GetCacheKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	pattern := r.URL.Query().Get("pattern")
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		limit = defaultKeysLimit
+	}
+	if limit > maxKeysLimit {
+		limit = maxKeysLimit
+	}
+
+	response, err := h.u.GetKeys(pattern, int64(limit))
+	if err == cacheRepository.ErrMissingPattern {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusBadRequest)
+		return
+	} else if err == database.ErrRedisUnavailable {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
+		return
+	}
+
+	json, err := json.Marshal(response)
+	if err != nil {
+		utils.WriteError(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(json)
+}
+
+// PurgeCacheKeys godoc
+// @Summary Purge cache keys
+// @Description Deletes the Redis keys of the service that match a pattern. The pattern is scoped to the service namespace and starts with the schema version, e.g. v2:order:*
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param pattern query string true "pattern, e.g. v2:order:*"
+// @Success 200 {object} models.ResponsePurge
+// @Failure 400 {object} models.ResponseError
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+// @Failure 503 {object} models.ResponseError
+// @Security BearerAuth
+// @Router /admin/cache/keys [delete]
+func (h *Handler) PurgeCacheKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	response, err := h.u.PurgeKeys(r.URL.Query().Get("pattern"))
+	if err == cacheRepository.ErrMissingPattern {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusBadRequest)
+		return
+	} else if err == database.ErrRedisUnavailable {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
+		return
+	}
+
+	json, err := json.Marshal(response)
+	if err != nil {
+		utils.WriteError(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
+		return
+	}
+
+	utils.RecordAudit(h.audit, r, models.AuditEntry{
+		Action: models.AuditActionPurgeCache,
+		Target: response.Pattern,
+	})
+
+	w.Write(json)
+}
@@ -0,0 +1,18 @@
+package cache
+
+import (
+	auditPorts "challenge_pyegros/app/ports/audit"
+	ports "challenge_pyegros/app/ports/cache"
+)
+
+type Handler struct {
+	u     ports.CacheUseCase
+	audit auditPorts.AuditUseCase
+}
+
+func NewHandler(u ports.CacheUseCase, audit auditPorts.AuditUseCase) *Handler {
+	return &Handler{
+		u:     u,
+		audit: audit,
+	}
+}
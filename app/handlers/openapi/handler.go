@@ -0,0 +1,47 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	httpSwagger "github.com/swaggo/http-swagger/v2"
+)
+
+// SpecPath is where the OpenAPI 3 spec is served, UIPath is the Swagger UI
+// reading it.
+const (
+	SpecPath = "/docs/openapi.json"
+	UIPath   = "/docs/index.html"
+)
+
+type Handler struct {
+	spec []byte
+	ui   http.HandlerFunc
+}
+
+func NewHandler(spec *openapi3.T) (*Handler, error) {
+	content, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Handler{
+		spec: content,
+		ui:   httpSwagger.Handler(httpSwagger.URL(SpecPath)),
+	}, nil
+}
+
+func (h *Handler) GetSpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(h.spec)
+}
+
+// GetUI serves Swagger UI and its assets, /docs redirects to its index.
+func (h *Handler) GetUI(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/docs" {
+		http.Redirect(w, r, UIPath, http.StatusMovedPermanently)
+		return
+	}
+	h.ui(w, r)
+}
@@ -0,0 +1,45 @@
+package openapi
+
+import (
+	"challenge_pyegros/app/docs"
+	"context"
+	"encoding/json"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// LoadSpec converts the Swagger 2.0 docs generated by swag from the
+// annotations of the handlers to OpenAPI 3. The server is the base path of
+// the API, so the spec works from any host serving it.
+func LoadSpec() (*openapi3.T, error) {
+	var swagger openapi2.T
+	if err := json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &swagger); err != nil {
+		return nil, err
+	}
+
+	spec, err := openapi2conv.ToV3(&swagger)
+	if err != nil {
+		return nil, err
+	}
+	spec.Servers = openapi3.Servers{{URL: docs.SwaggerInfo.BasePath}}
+
+	// The enums of models accept the empty value for a field that is not
+	// set, and encoding/json marshals nil slices and maps as null.
+	for _, schema := range spec.Components.Schemas {
+		if schema.Value.Type == "string" && len(schema.Value.Enum) > 0 {
+			schema.Value.Enum = append(schema.Value.Enum, "")
+		}
+		for _, property := range schema.Value.Properties {
+			if property.Ref == "" && (property.Value.Type == "array" || property.Value.AdditionalProperties != nil) {
+				property.Value.Nullable = true
+			}
+		}
+	}
+
+	if err = spec.Validate(context.TODO()); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
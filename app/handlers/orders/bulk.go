@@ -0,0 +1,315 @@
+package orders
+
+import (
+	"bufio"
+	"bytes"
+	"challenge_pyegros/app/models"
+	orderUseCase "challenge_pyegros/app/usecases/orders"
+	"challenge_pyegros/app/utils"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// MaxBulkOrders is the largest batch accepted by CreateOrders, and
+// MaxBulkEvents by UpdateEventOrders.
+const (
+	MaxBulkOrders = 1000
+	MaxBulkEvents = 5000
+)
+
+const (
+	CodeInvalidJSON      = "INVALID_JSON"
+	CodeInvalidDate      = "INVALID_DATE"
+	CodeUnknownEventType = "UNKNOWN_EVENT_TYPE"
+	CodeChannelForbidden = orderUseCase.CodeChannelForbidden
+)
+
+var (
+	ErrInvalidBulkBody = errors.New("The body must be a JSON array or NDJSON of orders")
+	ErrTooManyOrders   = errors.New("The batch exceeds " + strconv.Itoa(MaxBulkOrders) + " orders")
+	ErrInvalidEvents   = errors.New("The body must be a JSON array of orderId and event")
+	ErrTooManyEvents   = errors.New("The batch exceeds " + strconv.Itoa(MaxBulkEvents) + " events")
+)
+
+// CreateOrders godoc
+// @Summary Imports a batch of orders
+// @Description Creates the orders of a JSON array, or of NDJSON with the Content-Type application/x-ndjson, with the same validation and idempotency of POST /orders.
+// @Description Every order has its own result: created, duplicate (with the ID of the existing order) or error with a code
+// @Tags orders
+// @Accept json
+// @Accept application/x-ndjson
+// @Produce json
+// @Param orders body []models.Order true "orders"
+// @Success 200 {object} models.ResponseBulkOrders
+// @Failure 400 {object} models.ResponseError
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 413 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /orders/bulk [post]
+func (h *Handler) CreateOrders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	items, err := readBulkItems(r)
+	if err == ErrTooManyOrders {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusRequestEntityTooLarge)
+		return
+	} else if err != nil {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := utils.GetPrincipal(r.Context())
+
+	results := make([]models.BulkOrderResult, len(items))
+	orders := []models.Order{}
+	positions := []int{}
+	for i, item := range items {
+		var order models.Order
+		err = json.Unmarshal(item, &order)
+		if errors.Is(err, models.ErrUnknownChannel) {
+			results[i] = bulkError(i, orderUseCase.CodeChannelNotFound, orderUseCase.ErrChannelNotFound.Error())
+			continue
+		} else if err != nil {
+			results[i] = bulkError(i, CodeInvalidJSON, unmarshalError(err))
+			continue
+		}
+		err = utils.CheckFormatDate(order.PurchaseDate)
+		if err != nil {
+			results[i] = bulkError(i, CodeInvalidDate, err.Error())
+			continue
+		}
+		if principal != nil && principal.Channel != "" && principal.Channel != order.Channel {
+			results[i] = bulkError(i, CodeChannelForbidden, "The channel of the order does not match the channel of the API key")
+			continue
+		}
+
+		orders = append(orders, order)
+		positions = append(positions, i)
+	}
+
+	if len(orders) > 0 {
+		created, err := h.u.CreateOrders(orders)
+		if err != nil {
+			utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		for j, result := range created {
+			result.Index = positions[j]
+			results[positions[j]] = result
+
+			if result.Status == models.BulkStatusCreated {
+				h.recordAudit(r, models.AuditEntry{
+					Action:    models.AuditActionCreateOrder,
+					OrderID:   result.OrderID,
+					NewStatus: models.StatusCreated,
+				})
+			}
+		}
+	}
+
+	response := models.ResponseBulkOrders{Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case models.BulkStatusCreated:
+			response.Created++
+		case models.BulkStatusDuplicate:
+			response.Duplicates++
+		default:
+			response.Errors++
+		}
+	}
+
+	json, err := json.Marshal(response)
+	if err != nil {
+		utils.WriteError(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(json)
+}
+
+// UpdateEventOrders godoc
+// @Summary Applies a batch of events
+// @Description Applies every event to its order with the same rules of POST /orders/{orderId}/events. The events of the same order are applied in the order of the batch.
+// @Description The user of the events is the authenticated subject. Every event has its own result: applied, duplicate or error with a code.
+// @Description The events of an API key to orders of another channel fail with CHANNEL_FORBIDDEN, and the events of an unknown type with UNKNOWN_EVENT_TYPE
+// @Tags orders events
+// @Accept json
+// @Produce json
+// @Param events body []models.BulkEventItem true "events"
+// @Success 200 {object} models.ResponseBulkEvents
+// @Failure 400 {object} models.ResponseError
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 413 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /events/bulk [post]
+func (h *Handler) UpdateEventOrders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.WriteError(w, `{"error": "Failed to read request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	var items []json.RawMessage
+	err = json.Unmarshal(body, &items)
+	if err != nil || len(items) == 0 {
+		utils.WriteError(w, `{"error": "`+error.Error(ErrInvalidEvents)+`"}`, http.StatusBadRequest)
+		return
+	}
+	if len(items) > MaxBulkEvents {
+		utils.WriteError(w, `{"error": "`+error.Error(ErrTooManyEvents)+`"}`, http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	actor := utils.GetActor(r)
+	var channel models.Channel
+	if principal, ok := utils.GetPrincipal(r.Context()); ok {
+		channel = principal.Channel
+	}
+	results := make([]models.BulkEventResult, len(items))
+	valid := []models.BulkEventItem{}
+	positions := []int{}
+	for i, raw := range items {
+		var item models.BulkEventItem
+		err = json.Unmarshal(raw, &item)
+		if errors.Is(err, models.ErrUnknownEventType) {
+			results[i] = bulkEventError(i, raw, CodeUnknownEventType, models.ErrUnknownEventType.Error())
+			continue
+		} else if err != nil {
+			results[i] = bulkEventError(i, raw, CodeInvalidJSON, unmarshalError(err))
+			continue
+		}
+		err = utils.CheckFormatDate(item.Event.Date)
+		if err != nil {
+			results[i] = bulkEventError(i, raw, CodeInvalidDate, err.Error())
+			continue
+		}
+
+		item.Event.User = actor
+		valid = append(valid, item)
+		positions = append(positions, i)
+	}
+
+	if len(valid) > 0 {
+		applied, err := h.u.UpdateEventOrders(valid, channel)
+		if err != nil {
+			utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
+			return
+		}
+
+		for j, result := range applied {
+			result.Index = positions[j]
+			results[positions[j]] = result
+
+			if result.Status == models.BulkStatusApplied {
+				h.recordAudit(r, models.AuditEntry{
+					Action:         models.AuditActionAddEvent,
+					OrderID:        result.OrderID,
+					PreviousStatus: result.PreviousStatus,
+					NewStatus:      result.NewStatus,
+				})
+			}
+		}
+	}
+
+	response := models.ResponseBulkEvents{Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case models.BulkStatusApplied:
+			response.Applied++
+		case models.BulkStatusDuplicate:
+			response.Duplicates++
+		default:
+			response.Errors++
+		}
+	}
+
+	json, err := json.Marshal(response)
+	if err != nil {
+		utils.WriteError(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(json)
+}
+
+// readBulkItems splits the body in the raw orders. NDJSON is read line by
+// line, skipping blank lines, otherwise the body must be a JSON array.
+func readBulkItems(r *http.Request) ([]json.RawMessage, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, ErrInvalidBulkBody
+	}
+
+	items := []json.RawMessage{}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/x-ndjson" || mediaType == "application/ndjson" {
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		scanner.Buffer(make([]byte, 64*1024), len(body)+1)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			items = append(items, json.RawMessage(append([]byte{}, line...)))
+		}
+		if scanner.Err() != nil {
+			return nil, ErrInvalidBulkBody
+		}
+	} else if err := json.Unmarshal(body, &items); err != nil {
+		return nil, ErrInvalidBulkBody
+	}
+
+	if len(items) == 0 {
+		return nil, ErrInvalidBulkBody
+	}
+	if len(items) > MaxBulkOrders {
+		return nil, ErrTooManyOrders
+	}
+	return items, nil
+}
+
+func bulkError(index int, code string, message string) models.BulkOrderResult {
+	return models.BulkOrderResult{
+		Index:  index,
+		Status: models.BulkStatusError,
+		Code:   code,
+		Error:  message,
+	}
+}
+
+// bulkEventError is the result of an event that could not be decoded or
+// validated, with its order and ID when the item has them.
+func bulkEventError(index int, item json.RawMessage, code string, message string) models.BulkEventResult {
+	var ids struct {
+		OrderID int64 `json:"orderId"`
+		Event   struct {
+			Id string `json:"id"`
+		} `json:"event"`
+	}
+	json.Unmarshal(item, &ids)
+
+	return models.BulkEventResult{
+		Index:   index,
+		Status:  models.BulkStatusError,
+		OrderID: ids.OrderID,
+		EventID: ids.Event.Id,
+		Code:    code,
+		Error:   message,
+	}
+}
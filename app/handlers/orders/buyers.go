@@ -0,0 +1,56 @@
+package orders
+
+import (
+	"challenge_pyegros/app/utils"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetBuyerOrders godoc
+// @Summary Get the orders of a buyer
+// @Description Gets the orders of a buyer by document number, the most recent first, with a summary of all of them: total spent, amount of orders by status, first and last purchase dates and favourite channel.
+// @Description The total spent excludes canceled orders and refunded amounts. API keys only see the orders of their channel
+// @Tags buyers
+// @Accept json
+// @Produce json
+// @Param documentNumber path string true "document number of the buyer"
+// @Param page query int false "page number" default(1)
+// @Param pageSize query int false "page size" default(20)
+// @Success 200 {object} models.ResponseBuyerOrders
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 404 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /buyers/{documentNumber}/orders [get]
+func (h *Handler) GetBuyerOrders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	documentNumber := chi.URLParam(r, "documentNumber")
+
+	filters := utils.GetBuyerFilters(r)
+	if principal, ok := utils.GetPrincipal(r.Context()); ok && principal.Channel != "" {
+		filters.Channel = principal.Channel
+	}
+
+	response, err := h.u.GetBuyerOrders(documentNumber, filters)
+	if err == mongo.ErrNoDocuments {
+		utils.WriteError(w, `{"error": "The buyer has no orders"}`, http.StatusNotFound)
+		return
+	} else if err != nil {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
+		return
+	}
+
+	json, err := json.Marshal(response)
+	if err != nil {
+		utils.WriteError(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(json)
+}
@@ -0,0 +1,166 @@
+package orders_test
+
+import (
+	"bytes"
+	"challenge_pyegros/app/handlers/openapi"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const basePath = "/api/v1"
+
+var (
+	loadContract  sync.Once
+	contractSpec  *openapi3.T
+	contractPaths routers.Router
+	contractErr   error
+)
+
+// contract is the OpenAPI 3 spec served in /docs and its router, loaded
+// once for every test.
+func contract(t *testing.T) (*openapi3.T, routers.Router) {
+	loadContract.Do(func() {
+		openapi3filter.RegisterBodyDecoder("application/x-ndjson", decodeNDJSON)
+
+		contractSpec, contractErr = openapi.LoadSpec()
+		if contractErr == nil {
+			contractPaths, contractErr = gorillamux.NewRouter(contractSpec)
+		}
+	})
+	require.NoError(t, contractErr)
+	return contractSpec, contractPaths
+}
+
+// validateContract checks the response against the schema of its route and
+// status in the spec. The request is checked too when it succeeded, since
+// the spec must accept every request the API accepts. A bulk request with
+// items in error was only accepted in part, the spec describes valid items.
+func validateContract(t *testing.T, test handlerTest, req *http.Request, w *httptest.ResponseRecorder) {
+	_, router := contract(t)
+
+	// The body of req was read by the handler, and the handlers do not need
+	// the Content-Type of JSON bodies that the spec asks for.
+	req = req.Clone(context.TODO())
+	req.Body = io.NopCloser(strings.NewReader(test.body))
+	if test.body != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	route, pathParams, err := router.FindRoute(req)
+	require.NoError(t, err, "%s %s is not in the spec", req.Method, req.URL.Path)
+
+	input := &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: pathParams,
+		Route:      route,
+		Options:    &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+	}
+	if w.Code < http.StatusBadRequest && bulkErrors(w) == 0 {
+		assert.NoError(t, openapi3filter.ValidateRequest(context.TODO(), input))
+	}
+
+	// Only JSON bodies can be checked against the schemas, the CSV and
+	// NDJSON exports are streamed rows.
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	assert.NoError(t, openapi3filter.ValidateResponse(context.TODO(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 w.Code,
+		Header:                 w.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+		Options: &openapi3filter.Options{
+			IncludeResponseStatus: true,
+			ExcludeResponseBody:   mediaType != "application/json",
+		},
+	}))
+}
+
+// bulkErrors is the amount of items in error of a bulk response, 0 for any
+// other response.
+func bulkErrors(w *httptest.ResponseRecorder) int {
+	var response struct {
+		Errors int `json:"errors"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return response.Errors
+}
+
+// decodeNDJSON decodes a body of one JSON value per line as an array.
+func decodeNDJSON(body io.Reader, header http.Header, schema *openapi3.SchemaRef, encFn openapi3filter.EncodingFn) (interface{}, error) {
+	values := []interface{}{}
+	decoder := json.NewDecoder(body)
+	for decoder.More() {
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// TestRoutesAreDocumented checks that every route of the API is in the spec
+// and that the spec has no operation without a route.
+func TestRoutesAreDocumented(t *testing.T) {
+	spec, _ := contract(t)
+	r, _ := newTestRouter(t)
+
+	registered := []string{}
+	err := chi.Walk(r, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		if strings.HasPrefix(route, basePath+"/") {
+			registered = append(registered, method+" "+strings.TrimPrefix(route, basePath))
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+
+	documented := []string{}
+	for path, item := range spec.Paths {
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+path)
+		}
+	}
+
+	sort.Strings(registered)
+	sort.Strings(documented)
+	assert.Equal(t, registered, documented)
+}
+
+func TestServeSpec(t *testing.T) {
+	r, _ := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, openapi.SpecPath, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	served, err := openapi3.NewLoader().LoadFromData(w.Body.Bytes())
+	require.NoError(t, err)
+	assert.NoError(t, served.Validate(context.TODO()))
+	assert.Equal(t, "3.0.3", served.OpenAPI)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, openapi.UIPath, w.Header().Get("Location"))
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, openapi.UIPath, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "SwaggerUIBundle")
+}
@@ -0,0 +1,136 @@
+package orders
+
+import (
+	"challenge_pyegros/app/models"
+	"challenge_pyegros/app/utils"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	exportCSV    = "text/csv"
+	exportNDJSON = "application/x-ndjson"
+
+	// exportFlushEvery is how many orders are written between flushes.
+	exportFlushEvery = 100
+)
+
+var exportCSVHeader = []string{
+	"orderID", "externalReferenceID", "channel", "purchaseDate", "status", "totalValue", "refundedAmount",
+	"buyerFirstName", "buyerLastName", "buyerDocumentNumber", "buyerPhone",
+	"sku", "name", "description", "price", "quantity", "returnedQuantity",
+}
+
+// exportFormat returns the streaming format requested in the Accept header,
+// or an empty string for the JSON array.
+func exportFormat(r *http.Request) string {
+	accept := r.Header.Get("Accept")
+	if strings.Contains(accept, exportCSV) {
+		return exportCSV
+	}
+	if strings.Contains(accept, exportNDJSON) || strings.Contains(accept, "application/ndjson") {
+		return exportNDJSON
+	}
+	return ""
+}
+
+// streamOrders writes the orders that match the filters as they are read from
+// the cursor, flushing every exportFlushEvery orders. Once the first order is
+// written the status can no longer change, so later errors end the response.
+func (h *Handler) streamOrders(w http.ResponseWriter, filters models.Filters, format string) {
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	csvWriter := csv.NewWriter(w)
+	encoder := json.NewEncoder(w)
+
+	written := 0
+	start := func() {
+		w.Header().Set("Content-Type", format)
+		if format == exportCSV {
+			w.Header().Set("Content-Disposition", `attachment; filename="orders.csv"`)
+			csvWriter.Write(exportCSVHeader)
+		}
+	}
+
+	err := h.u.StreamOrdersByFilters(filters, func(order models.Order) error {
+		if written == 0 {
+			start()
+		}
+		written++
+
+		if format == exportCSV {
+			for _, row := range orderRows(order) {
+				csvWriter.Write(row)
+			}
+			if written%exportFlushEvery == 0 {
+				csvWriter.Flush()
+				flush()
+			}
+			return csvWriter.Error()
+		}
+
+		err := encoder.Encode(order)
+		if written%exportFlushEvery == 0 {
+			flush()
+		}
+		return err
+	})
+	if err != nil && written == 0 {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
+		return
+	} else if err != nil {
+		fmt.Println("Error streaming orders: " + err.Error())
+		return
+	}
+
+	if written == 0 {
+		start()
+	}
+	csvWriter.Flush()
+	flush()
+}
+
+// orderRows flattens an order to one CSV row per product line. An order
+// without products has a single row with the product columns empty.
+func orderRows(order models.Order) [][]string {
+	columns := []string{
+		strconv.FormatInt(order.OrderID, 10),
+		order.ExternalReferenceID,
+		string(order.Channel),
+		order.PurchaseDate,
+		string(order.Status),
+		strconv.FormatFloat(order.TotalValue, 'f', -1, 64),
+		strconv.FormatFloat(order.RefundedAmount, 'f', -1, 64),
+		order.Buyer.FirstName,
+		order.Buyer.LastName,
+		order.Buyer.DocumentNumber,
+		order.Buyer.Phone,
+	}
+
+	if len(order.Products) == 0 {
+		return [][]string{append(columns, "", "", "", "", "", "")}
+	}
+
+	rows := make([][]string, 0, len(order.Products))
+	for _, product := range order.Products {
+		row := append(append([]string{}, columns...),
+			product.Sku,
+			product.Name,
+			product.Description,
+			strconv.FormatFloat(product.Price, 'f', -1, 64),
+			strconv.FormatInt(product.Quantity, 10),
+			strconv.FormatInt(product.ReturnedQuantity, 10),
+		)
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -1,15 +1,91 @@
 package orders
 
 import (
+	"challenge_pyegros/app/i18n"
+	"challenge_pyegros/app/models"
+	auditPorts "challenge_pyegros/app/ports/audit"
 	ports "challenge_pyegros/app/ports/orders"
+	orderUseCase "challenge_pyegros/app/usecases/orders"
+	"challenge_pyegros/app/utils"
+	"errors"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type Handler struct {
-	u ports.OrdersUseCase
+	u         ports.OrdersUseCase
+	audit     auditPorts.AuditUseCase
+	catalogue *i18n.Catalogue
 }
 
-func NewHandler(u ports.OrdersUseCase) *Handler {
+func NewHandler(u ports.OrdersUseCase, audit auditPorts.AuditUseCase, catalogue *i18n.Catalogue) *Handler {
 	return &Handler{
-		u: u,
+		u:         u,
+		audit:     audit,
+		catalogue: catalogue,
+	}
+}
+
+// translateOrder adds the translations of the channel and status of the order
+// in the locale negotiated with the lang parameter or the Accept-Language
+// header of the request.
+func (h *Handler) translateOrder(w http.ResponseWriter, r *http.Request, order *models.ResponseGet) {
+	locale := h.catalogue.Negotiate(r.URL.Query().Get("lang"), r.Header.Get("Accept-Language"))
+	order.ChannelTranslate = h.catalogue.Translate(locale, i18n.Channel, string(order.Channel))
+	order.StatusTranslate = h.catalogue.Translate(locale, i18n.Status, string(order.Status))
+
+	w.Header().Set("Content-Language", locale)
+	w.Header().Add("Vary", "Accept-Language")
+}
+
+func (h *Handler) recordAudit(r *http.Request, entry models.AuditEntry) {
+	utils.RecordAudit(h.audit, r, entry)
+}
+
+// checkOrderChannel reads the order when the principal is bound to a channel
+// and fails with mongo.ErrNoDocuments when the order is of another channel,
+// so it cannot tell the order exists.
+func (h *Handler) checkOrderChannel(r *http.Request, orderID int64) error {
+	principal, ok := utils.GetPrincipal(r.Context())
+	if !ok || principal.Channel == "" {
+		return nil
+	}
+
+	order, err := h.u.GetOrderByID(orderID)
+	if err != nil {
+		return err
+	}
+	if !utils.OwnsChannel(principal, order.Channel) {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+func isInvalidAmendment(err error) bool {
+	switch err {
+	case orderUseCase.ErrInvalidPatch,
+		orderUseCase.ErrFieldNotAmendable,
+		orderUseCase.ErrInvalidProducts,
+		orderUseCase.ErrTotalMismatch:
+		return true
 	}
+	return false
+}
+
+// unmarshalError is the message of a body that could not be decoded, an
+// unknown status, event type or channel is reported instead of the generic
+// message.
+func unmarshalError(err error) string {
+	for _, enumErr := range []error{models.ErrUnknownOrderStatus, models.ErrUnknownEventType, models.ErrUnknownChannel} {
+		if errors.Is(err, enumErr) {
+			return enumErr.Error()
+		}
+	}
+	return "Error unmarshaling JSON"
+}
+
+// writeRequestError writes the error of a request mapped by the utils package.
+func writeRequestError(w http.ResponseWriter, err *utils.RequestError) {
+	utils.WriteError(w, `{"error": "`+err.Error()+`"}`, err.Status)
 }
@@ -0,0 +1,817 @@
+package orders_test
+
+import (
+	"challenge_pyegros/app/config"
+	apiKeysHandler "challenge_pyegros/app/handlers/apikeys"
+	auditHandler "challenge_pyegros/app/handlers/audit"
+	cacheHandler "challenge_pyegros/app/handlers/cache"
+	"challenge_pyegros/app/handlers/openapi"
+	orderHandler "challenge_pyegros/app/handlers/orders"
+	reportsHandler "challenge_pyegros/app/handlers/reports"
+	"challenge_pyegros/app/i18n"
+	"challenge_pyegros/app/middlewares"
+	"challenge_pyegros/app/models"
+	apiKeysMocks "challenge_pyegros/app/ports/apikeys/mocks"
+	auditMocks "challenge_pyegros/app/ports/audit/mocks"
+	cacheMocks "challenge_pyegros/app/ports/cache/mocks"
+	ports "challenge_pyegros/app/ports/orders"
+	"challenge_pyegros/app/ports/orders/mocks"
+	reportsMocks "challenge_pyegros/app/ports/reports/mocks"
+	"challenge_pyegros/app/routes"
+	orderUseCase "challenge_pyegros/app/usecases/orders"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-chi/chi"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/mock/gomock"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of testdata")
+
+const secret = "test-secret"
+
+// storeAPIKey and storeAuditorAPIKey authenticate as API keys bound to the
+// Store channel.
+const (
+	storeAPIKey        = "key-store"
+	storeAuditorAPIKey = "key-store-auditor"
+)
+
+var (
+	order = models.Order{
+		ExternalReferenceID: "abc-123",
+		Channel:             "Ecommerce",
+		PurchaseDate:        "2024-05-01T14:00:00Z",
+		TotalValue:          2000,
+		Buyer: models.Buyer{
+			FirstName:      "Patricio",
+			LastName:       "Yegros",
+			DocumentNumber: "87654321",
+			Phone:          "+541112345678",
+		},
+		Products: []models.Product{
+			{
+				Sku:         "P001",
+				Name:        "Producto A",
+				Description: "Descripción",
+				Price:       1000,
+				Quantity:    2,
+			},
+		},
+	}
+
+	event = models.Event{
+		Id:   "event-001",
+		Type: "PaymentReceived",
+		Date: "2024-05-01T15:00:00Z",
+	}
+
+	storedOrder = models.Order{
+		OrderID:             1,
+		ExternalReferenceID: order.ExternalReferenceID,
+		Channel:             order.Channel,
+		PurchaseDate:        order.PurchaseDate,
+		TotalValue:          order.TotalValue,
+		Buyer:               order.Buyer,
+		Products:            order.Products,
+		Status:              "PaymentReceived",
+		Events:              []models.Event{event},
+	}
+
+	orderResponse = models.ResponseGet{
+		OrderID:             1,
+		ExternalReferenceID: order.ExternalReferenceID,
+		Channel:             order.Channel,
+		PurchaseDate:        order.PurchaseDate,
+		TotalValue:          order.TotalValue,
+		Buyer:               order.Buyer,
+		Products:            order.Products,
+		Status:              "PaymentReceived",
+		Events:              []models.Event{event},
+	}
+)
+
+type handlerTest struct {
+	name    string
+	method  string
+	path    string
+	body    string
+	headers map[string]string
+	// roles of the JWT of the request, without roles nor X-API-Key the
+	// request has no Authorization header.
+	roles []string
+	// setup sets the expectations of the orders use case, setupMocks the
+	// ones of the use cases of the other handlers.
+	setup       func(u *mocks.MockOrdersUseCase)
+	setupMocks  func(m *routerMocks)
+	status      int
+	contentType string
+}
+
+// routerMocks are the use cases of the handlers of the router.
+type routerMocks struct {
+	orders  *mocks.MockOrdersUseCase
+	audit   *auditMocks.MockAuditUseCase
+	apiKeys *apiKeysMocks.MockAPIKeysUseCase
+	cache   *cacheMocks.MockCacheUseCase
+	reports *reportsMocks.MockReportsUseCase
+}
+
+// newTestRouter builds the router of the service with every use case
+// mocked. The audit records anything and only the API keys of the tests
+// authenticate, the rest of the mocks have no expectations.
+func newTestRouter(t *testing.T) (*chi.Mux, *routerMocks) {
+	ctrl := gomock.NewController(t)
+
+	cfg := &config.Config{
+		JWTSecret:        secret,
+		RateLimitDefault: config.RateLimit{Requests: 1000, Window: time.Minute},
+		RateLimitIP:      config.RateLimit{Requests: 1000, Window: time.Minute},
+	}
+	authenticator, err := middlewares.NewJWTAuthenticator(cfg)
+	assert.NoError(t, err)
+
+	apiKeys := apiKeysMocks.NewMockAPIKeysUseCase(ctrl)
+	apiKeys.EXPECT().Authenticate(storeAPIKey).Return(&models.Principal{
+		Subject: "apikey:store",
+		Roles:   []string{models.RoleChannelClient, models.RoleBackoffice},
+		Channel: "Store",
+	}, nil).AnyTimes()
+	apiKeys.EXPECT().Authenticate(storeAuditorAPIKey).Return(&models.Principal{
+		Subject: "apikey:store-auditor",
+		Roles:   []string{models.RoleAuditor},
+		Channel: "Store",
+	}, nil).AnyTimes()
+	apiKeys.EXPECT().Authenticate(gomock.Any()).Return(nil, errors.New("Unknown API key")).AnyTimes()
+
+	rdb := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	rateLimiter := middlewares.NewRateLimiter(rdb, cfg)
+
+	audit := auditMocks.NewMockAuditUseCase(ctrl)
+	audit.EXPECT().Record(gomock.Any()).Return(nil).AnyTimes()
+
+	catalogue, err := i18n.NewCatalogue(i18n.Locales(), "es-AR")
+	assert.NoError(t, err)
+
+	spec, _ := contract(t)
+	openAPIHandler, err := openapi.NewHandler(spec)
+	assert.NoError(t, err)
+
+	m := &routerMocks{
+		orders:  mocks.NewMockOrdersUseCase(ctrl),
+		audit:   audit,
+		apiKeys: apiKeys,
+		cache:   cacheMocks.NewMockCacheUseCase(ctrl),
+		reports: reportsMocks.NewMockReportsUseCase(ctrl),
+	}
+	r := routes.SetUpRoutes(nil, authenticator, middlewares.NewAPIKeyAuthenticator(apiKeys), rateLimiter, middlewares.NewTrustedProxies(cfg),
+		orderHandler.NewHandler(m.orders, audit, catalogue),
+		auditHandler.NewHandler(audit),
+		apiKeysHandler.NewHandler(apiKeys, audit),
+		cacheHandler.NewHandler(m.cache, audit),
+		reportsHandler.NewHandler(m.reports),
+		openAPIHandler,
+	)
+	return r, m
+}
+
+func signToken(t *testing.T, roles []string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   "user-001",
+		"roles": roles,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(secret))
+	assert.NoError(t, err)
+	return token
+}
+
+func runHandlerTests(t *testing.T, tests []handlerTest) {
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r, m := newTestRouter(t)
+			if test.setup != nil {
+				test.setup(m.orders)
+			}
+			if test.setupMocks != nil {
+				test.setupMocks(m)
+			}
+
+			req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+			if len(test.roles) > 0 {
+				req.Header.Set("Authorization", "Bearer "+signToken(t, test.roles))
+			}
+			for key, value := range test.headers {
+				req.Header.Set(key, value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, test.status, w.Code)
+			if test.contentType != "" {
+				assert.Equal(t, test.contentType, w.Header().Get("Content-Type"))
+			}
+			if w.Code >= http.StatusBadRequest {
+				var body map[string]string
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), w.Body.String())
+				assert.NotEmpty(t, body["error"])
+			}
+			validateContract(t, test, req, w)
+			assertGolden(t, w.Body.Bytes())
+		})
+	}
+}
+
+// assertGolden compares the body with testdata/{test name}.golden, go test
+// -update rewrites the files with the current bodies.
+func assertGolden(t *testing.T, body []byte) {
+	path := filepath.Join("testdata", strings.ReplaceAll(t.Name(), "/", "_")+".golden")
+	if *update {
+		assert.NoError(t, os.MkdirAll("testdata", 0o755))
+		assert.NoError(t, os.WriteFile(path, body, 0o644))
+	}
+
+	expected, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(body))
+}
+
+func toJSON(t *testing.T, value interface{}) string {
+	content, err := json.Marshal(value)
+	assert.NoError(t, err)
+	return string(content)
+}
+
+func TestCreateOrder(t *testing.T) {
+	channelClient := []string{models.RoleChannelClient}
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:   "created",
+			method: http.MethodPost, path: "/api/v1/orders", body: toJSON(t, order), roles: channelClient,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().CreateOrder(order).Return(&models.ResponseCreate{OrderID: 1, Status: "Created", UpdatedOn: order.PurchaseDate}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "invalid json",
+			method: http.MethodPost, path: "/api/v1/orders", body: `{"channel": `, roles: channelClient,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unknown channel",
+			method: http.MethodPost, path: "/api/v1/orders", body: `{"channel": "Marketplace"}`, roles: channelClient,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid date",
+			method: http.MethodPost, path: "/api/v1/orders", body: `{"channel": "Ecommerce", "purchaseDate": "01/05/2024"}`, roles: channelClient,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "total mismatch",
+			method: http.MethodPost, path: "/api/v1/orders", body: toJSON(t, order), roles: channelClient,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().CreateOrder(order).Return(nil, orderUseCase.ErrTotalMismatch)
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "external reference mismatch",
+			method: http.MethodPost, path: "/api/v1/orders", body: toJSON(t, order), roles: channelClient,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().CreateOrder(order).Return(nil, orderUseCase.ErrMismatchExternalReference)
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "without token",
+			method: http.MethodPost, path: "/api/v1/orders", body: toJSON(t, order),
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "without role",
+			method: http.MethodPost, path: "/api/v1/orders", body: toJSON(t, order), roles: []string{models.RoleAuditor},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "api key of another channel",
+			method: http.MethodPost, path: "/api/v1/orders", body: toJSON(t, order), headers: map[string]string{middlewares.APIKeyHeader: storeAPIKey},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "unknown api key",
+			method: http.MethodPost, path: "/api/v1/orders", body: toJSON(t, order), headers: map[string]string{middlewares.APIKeyHeader: "key-unknown"},
+			status: http.StatusUnauthorized,
+		},
+	})
+}
+
+func TestUpdateEventOrder(t *testing.T) {
+	backoffice := []string{models.RoleBackoffice}
+	authored := event
+	authored.User = "user-001"
+	response := &models.ResponseUpdate{OrderID: 1, PreviousStatus: "Created", NewStatus: "PaymentReceived", UpdatedOn: event.Date}
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:   "applied",
+			method: http.MethodPost, path: "/api/v1/orders/1/events", body: toJSON(t, event), roles: backoffice,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().UpdateEventOrder(int64(1), authored).Return(response, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "non numeric order id",
+			method: http.MethodPost, path: "/api/v1/orders/abc/events", body: toJSON(t, event), roles: backoffice,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unknown event type",
+			method: http.MethodPost, path: "/api/v1/orders/1/events", body: `{"id": "event-001", "type": "Shipped", "date": "2024-05-01T15:00:00Z"}`, roles: backoffice,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid date",
+			method: http.MethodPost, path: "/api/v1/orders/1/events", body: `{"id": "event-001", "type": "PaymentReceived", "date": "yesterday"}`, roles: backoffice,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "not found",
+			method: http.MethodPost, path: "/api/v1/orders/2/events", body: toJSON(t, event), roles: backoffice,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().UpdateEventOrder(int64(2), authored).Return(nil, mongo.ErrNoDocuments)
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "invalid reason",
+			method: http.MethodPost, path: "/api/v1/orders/1/events", body: `{"id": "event-002", "type": "Canceled", "date": "2024-05-01T15:00:00Z"}`, roles: backoffice,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().UpdateEventOrder(int64(1), gomock.Any()).Return(nil, orderUseCase.ErrMissingReason)
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid state transition",
+			method: http.MethodPost, path: "/api/v1/orders/1/events", body: toJSON(t, event), roles: backoffice,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().UpdateEventOrder(int64(1), authored).Return(nil, orderUseCase.ErrInvalidStateTransition)
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name:   "another event with same id",
+			method: http.MethodPost, path: "/api/v1/orders/1/events", body: toJSON(t, event), roles: backoffice,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().UpdateEventOrder(int64(1), authored).Return(nil, orderUseCase.ErrAnotherEventWithSameID)
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name:   "order changed",
+			method: http.MethodPost, path: "/api/v1/orders/1/events", body: toJSON(t, event), roles: backoffice,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().UpdateEventOrder(int64(1), authored).Return(nil, ports.ErrOrderChanged)
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name:   "channel client",
+			method: http.MethodPost, path: "/api/v1/orders/1/events", body: toJSON(t, event), roles: []string{models.RoleChannelClient},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "api key of another channel",
+			method: http.MethodPost, path: "/api/v1/orders/1/events", body: toJSON(t, event), headers: map[string]string{middlewares.APIKeyHeader: storeAPIKey},
+			setup: func(u *mocks.MockOrdersUseCase) {
+				response := orderResponse
+				u.EXPECT().GetOrderByID(int64(1)).Return(&response, nil)
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "api key of the channel",
+			method: http.MethodPost, path: "/api/v1/orders/1/events", body: toJSON(t, event), headers: map[string]string{middlewares.APIKeyHeader: storeAPIKey},
+			setup: func(u *mocks.MockOrdersUseCase) {
+				stored := orderResponse
+				stored.Channel = models.ChannelStore
+				u.EXPECT().GetOrderByID(int64(1)).Return(&stored, nil)
+				byKey := event
+				byKey.User = "apikey:store"
+				u.EXPECT().UpdateEventOrder(int64(1), byKey).Return(response, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+	})
+}
+
+func TestGetOrderByID(t *testing.T) {
+	readers := []string{models.RoleAuditor}
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:   "found",
+			method: http.MethodGet, path: "/api/v1/orders/1", roles: readers,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				response := orderResponse
+				u.EXPECT().GetOrderByID(int64(1)).Return(&response, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "translated",
+			method: http.MethodGet, path: "/api/v1/orders/1?lang=en", headers: map[string]string{"Accept-Language": "pt-BR"}, roles: readers,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				response := orderResponse
+				u.EXPECT().GetOrderByID(int64(1)).Return(&response, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "non numeric order id",
+			method: http.MethodGet, path: "/api/v1/orders/abc", roles: readers,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "not found",
+			method: http.MethodGet, path: "/api/v1/orders/2", roles: readers,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().GetOrderByID(int64(2)).Return(nil, mongo.ErrNoDocuments)
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "api key of another channel",
+			method: http.MethodGet, path: "/api/v1/orders/1", headers: map[string]string{middlewares.APIKeyHeader: storeAPIKey},
+			setup: func(u *mocks.MockOrdersUseCase) {
+				response := orderResponse
+				u.EXPECT().GetOrderByID(int64(1)).Return(&response, nil)
+			},
+			status: http.StatusNotFound,
+		},
+	})
+}
+
+func TestGetOrderByFilters(t *testing.T) {
+	readers := []string{models.RoleBackoffice}
+	search := []models.Order{storedOrder}
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:   "every filter",
+			method: http.MethodGet, path: "/api/v1/orders/search?orderId=1&documentNumber=87654321&status=PaymentReceived&channel=Ecommerce&createdOnFrom=2024-05-01T00:00:00Z&createdOnTo=2024-05-02T00:00:00Z", roles: readers,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().GetOrderByFilters(models.Filters{
+					OrderId:        1,
+					DocumentNumber: "87654321",
+					Status:         "PaymentReceived",
+					Channel:        "Ecommerce",
+					CreatedOnFrom:  "2024-05-01T00:00:00Z",
+					CreatedOnTo:    "2024-05-02T00:00:00Z",
+				}).Return(search, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "no results",
+			method: http.MethodGet, path: "/api/v1/orders/search", roles: readers,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().GetOrderByFilters(models.Filters{}).Return([]models.Order{}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "non numeric order id is ignored",
+			method: http.MethodGet, path: "/api/v1/orders/search?orderId=abc&status=Created", roles: readers,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().GetOrderByFilters(models.Filters{Status: "Created"}).Return([]models.Order{}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "api key channel",
+			method: http.MethodGet, path: "/api/v1/orders/search?channel=Ecommerce", headers: map[string]string{middlewares.APIKeyHeader: storeAPIKey},
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().GetOrderByFilters(models.Filters{Channel: "Store"}).Return([]models.Order{}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "unknown status",
+			method: http.MethodGet, path: "/api/v1/orders/search?status=Shipped", roles: readers,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unknown channel",
+			method: http.MethodGet, path: "/api/v1/orders/search?channel=Marketplace", roles: readers,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid from date",
+			method: http.MethodGet, path: "/api/v1/orders/search?createdOnFrom=2024-05-01", roles: readers,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "search fails",
+			method: http.MethodGet, path: "/api/v1/orders/search", roles: readers,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().GetOrderByFilters(models.Filters{}).Return(nil, errors.New("connection refused"))
+			},
+			status: http.StatusInternalServerError,
+		},
+		{
+			name:   "csv",
+			method: http.MethodGet, path: "/api/v1/orders/search?status=PaymentReceived", headers: map[string]string{"Accept": "text/csv"}, roles: readers,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().StreamOrdersByFilters(models.Filters{Status: "PaymentReceived"}, gomock.Any()).DoAndReturn(streamOrders(search))
+			},
+			status: http.StatusOK, contentType: "text/csv",
+		},
+		{
+			name:   "ndjson",
+			method: http.MethodGet, path: "/api/v1/orders/search", headers: map[string]string{"Accept": "application/x-ndjson"}, roles: readers,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().StreamOrdersByFilters(models.Filters{}, gomock.Any()).DoAndReturn(streamOrders(search))
+			},
+			status: http.StatusOK, contentType: "application/x-ndjson",
+		},
+	})
+}
+
+func streamOrders(orders []models.Order) func(models.Filters, func(models.Order) error) error {
+	return func(filters models.Filters, fn func(models.Order) error) error {
+		for _, order := range orders {
+			if err := fn(order); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestGetOrderEvents(t *testing.T) {
+	readers := []string{models.RoleAuditor}
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:   "page",
+			method: http.MethodGet, path: "/api/v1/orders/1/events?type=PaymentReceived&page=2&pageSize=500", roles: readers,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().GetOrderEvents(int64(1), models.EventFilters{Type: "PaymentReceived", Page: 2, PageSize: 100}).Return(&models.ResponseEvents{
+					OrderID:  1,
+					Page:     2,
+					PageSize: 100,
+					Total:    1,
+					Events:   []models.TimelineEvent{},
+				}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "non numeric order id",
+			method: http.MethodGet, path: "/api/v1/orders/abc/events", roles: readers,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid to date",
+			method: http.MethodGet, path: "/api/v1/orders/1/events?dateTo=tomorrow", roles: readers,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unknown type",
+			method: http.MethodGet, path: "/api/v1/orders/1/events?type=Shipped", roles: readers,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "not found",
+			method: http.MethodGet, path: "/api/v1/orders/2/events", roles: readers,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().GetOrderEvents(int64(2), models.EventFilters{Page: 1, PageSize: 20}).Return(nil, mongo.ErrNoDocuments)
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "api key of another channel",
+			method: http.MethodGet, path: "/api/v1/orders/1/events", headers: map[string]string{middlewares.APIKeyHeader: storeAPIKey},
+			setup: func(u *mocks.MockOrdersUseCase) {
+				response := orderResponse
+				u.EXPECT().GetOrderByID(int64(1)).Return(&response, nil)
+			},
+			status: http.StatusNotFound,
+		},
+	})
+}
+
+func TestAmendOrder(t *testing.T) {
+	amenders := []string{models.RoleBackoffice}
+	patch := `{"buyer": {"phone": "+541187654321"}}`
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:   "amended",
+			method: http.MethodPatch, path: "/api/v1/orders/1", body: patch, roles: amenders,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().AmendOrder(int64(1), []byte(patch), "user-001").Return(&models.ResponseAmend{
+					OrderID: 1,
+					Status:  "Created",
+					Changes: []models.FieldChange{{Field: "buyer.phone", From: "+541112345678", To: "+541187654321"}},
+				}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "not amendable",
+			method: http.MethodPatch, path: "/api/v1/orders/1", body: patch, roles: amenders,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().AmendOrder(int64(1), []byte(patch), "user-001").Return(nil, orderUseCase.ErrOrderNotAmendable)
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name:   "order changed",
+			method: http.MethodPatch, path: "/api/v1/orders/1", body: patch, roles: amenders,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().AmendOrder(int64(1), []byte(patch), "user-001").Return(nil, ports.ErrOrderChanged)
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name:   "field not amendable",
+			method: http.MethodPatch, path: "/api/v1/orders/1", body: `{"channel": "Store"}`, roles: amenders,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().AmendOrder(int64(1), gomock.Any(), "user-001").Return(nil, orderUseCase.ErrFieldNotAmendable)
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "non numeric order id",
+			method: http.MethodPatch, path: "/api/v1/orders/abc", body: patch, roles: amenders,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "api key of another channel",
+			method: http.MethodPatch, path: "/api/v1/orders/1", body: patch, headers: map[string]string{middlewares.APIKeyHeader: storeAPIKey},
+			setup: func(u *mocks.MockOrdersUseCase) {
+				response := orderResponse
+				u.EXPECT().GetOrderByID(int64(1)).Return(&response, nil)
+			},
+			status: http.StatusNotFound,
+		},
+	})
+}
+
+func TestGetBuyerOrders(t *testing.T) {
+	readers := []string{models.RoleAuditor}
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:   "found",
+			method: http.MethodGet, path: "/api/v1/buyers/87654321/orders?page=1&pageSize=10", roles: readers,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().GetBuyerOrders("87654321", models.BuyerFilters{Page: 1, PageSize: 10}).Return(&models.ResponseBuyerOrders{
+					DocumentNumber: "87654321",
+					Page:           1,
+					PageSize:       10,
+					Summary: models.BuyerSummary{
+						TotalSpent:        2000,
+						OrderCount:        1,
+						OrdersByStatus:    map[string]int64{"PaymentReceived": 1},
+						FirstPurchaseDate: order.PurchaseDate,
+						LastPurchaseDate:  order.PurchaseDate,
+						FavouriteChannel:  "Ecommerce",
+					},
+					Orders: []models.Order{storedOrder},
+				}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "without orders",
+			method: http.MethodGet, path: "/api/v1/buyers/00000000/orders", roles: readers,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().GetBuyerOrders("00000000", models.BuyerFilters{Page: 1, PageSize: 20}).Return(nil, mongo.ErrNoDocuments)
+			},
+			status: http.StatusNotFound,
+		},
+	})
+}
+
+func TestBulk(t *testing.T) {
+	ndjson := toJSON(t, order) + "\n" + `{"channel": ` + "\n"
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:   "orders ndjson",
+			method: http.MethodPost, path: "/api/v1/orders/bulk", body: ndjson, roles: []string{models.RoleChannelClient},
+			headers: map[string]string{"Content-Type": "application/x-ndjson"},
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().CreateOrders([]models.Order{order}).Return([]models.BulkOrderResult{
+					{Index: 0, Status: models.BulkStatusCreated, OrderID: 1},
+				}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "orders ndjson with blank lines",
+			method: http.MethodPost, path: "/api/v1/orders/bulk", body: toJSON(t, order) + "\n\n" + toJSON(t, order) + "\n", roles: []string{models.RoleChannelClient},
+			headers: map[string]string{"Content-Type": "application/x-ndjson"},
+			setup: func(u *mocks.MockOrdersUseCase) {
+				u.EXPECT().CreateOrders([]models.Order{order, order}).Return([]models.BulkOrderResult{
+					{Index: 0, Status: models.BulkStatusCreated, OrderID: 1},
+					{Index: 1, Status: models.BulkStatusDuplicate, OrderID: 1},
+				}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "orders ndjson without orders",
+			method: http.MethodPost, path: "/api/v1/orders/bulk", body: "\n\n", roles: []string{models.RoleChannelClient},
+			headers: map[string]string{"Content-Type": "application/x-ndjson"},
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:   "orders empty body",
+			method: http.MethodPost, path: "/api/v1/orders/bulk", body: `{}`, roles: []string{models.RoleChannelClient},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "events",
+			method: http.MethodPost, path: "/api/v1/events/bulk", body: `[{"orderId": 1, "event": ` + toJSON(t, event) + `}]`, roles: []string{models.RoleBackoffice},
+			setup: func(u *mocks.MockOrdersUseCase) {
+				authored := event
+				authored.User = "user-001"
+				u.EXPECT().UpdateEventOrders([]models.BulkEventItem{{OrderID: 1, Event: authored}}, models.Channel("")).Return([]models.BulkEventResult{
+					{Index: 0, Status: models.BulkStatusApplied, OrderID: 1, EventID: event.Id, PreviousStatus: "Created", NewStatus: "PaymentReceived"},
+				}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "events of unknown type",
+			method: http.MethodPost, path: "/api/v1/events/bulk", roles: []string{models.RoleBackoffice},
+			body: `[{"orderId": 1, "event": ` + toJSON(t, event) + `}, {"orderId": 2, "event": {"id": "event-002", "type": "Shipped", "date": "2024-05-01T15:00:00Z"}}, {"orderId": 3, "event": 1}]`,
+			setup: func(u *mocks.MockOrdersUseCase) {
+				authored := event
+				authored.User = "user-001"
+				u.EXPECT().UpdateEventOrders([]models.BulkEventItem{{OrderID: 1, Event: authored}}, models.Channel("")).Return([]models.BulkEventResult{
+					{Index: 0, Status: models.BulkStatusApplied, OrderID: 1, EventID: event.Id, PreviousStatus: "Created", NewStatus: "PaymentReceived"},
+				}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "events invalid body",
+			method: http.MethodPost, path: "/api/v1/events/bulk", body: `{"orderId": 1}`, roles: []string{models.RoleBackoffice},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "events api key",
+			method: http.MethodPost, path: "/api/v1/events/bulk", body: `[{"orderId": 1, "event": ` + toJSON(t, event) + `}]`, headers: map[string]string{middlewares.APIKeyHeader: storeAPIKey},
+			setup: func(u *mocks.MockOrdersUseCase) {
+				authored := event
+				authored.User = "apikey:store"
+				u.EXPECT().UpdateEventOrders([]models.BulkEventItem{{OrderID: 1, Event: authored}}, models.ChannelStore).Return([]models.BulkEventResult{
+					{Index: 0, Status: models.BulkStatusError, OrderID: 1, EventID: event.Id, Code: orderUseCase.CodeChannelForbidden, Error: orderUseCase.ErrChannelForbidden.Error()},
+				}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+	})
+}
+
+// TestRoutesOfEveryChannel checks that the API keys, bound to a channel, can
+// not read the audit trail nor the reports of every channel.
+func TestRoutesOfEveryChannel(t *testing.T) {
+	runHandlerTests(t, []handlerTest{
+		{
+			name:   "audit",
+			method: http.MethodGet, path: "/api/v1/audit", headers: map[string]string{middlewares.APIKeyHeader: storeAuditorAPIKey},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "reasons report",
+			method: http.MethodGet, path: "/api/v1/reports/reasons", headers: map[string]string{middlewares.APIKeyHeader: storeAuditorAPIKey},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "sales report",
+			method: http.MethodGet, path: "/api/v1/reports/sales", headers: map[string]string{middlewares.APIKeyHeader: storeAPIKey},
+			status: http.StatusForbidden,
+		},
+	})
+}
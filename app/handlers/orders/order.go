@@ -2,6 +2,8 @@ package orders
 
 import (
 	"challenge_pyegros/app/models"
+	ports "challenge_pyegros/app/ports/orders"
+	orderUseCase "challenge_pyegros/app/usecases/orders"
 	"challenge_pyegros/app/utils"
 	"encoding/json"
 	"io"
@@ -28,41 +30,54 @@ func NewOrderHandler(client *mongo.Client) *OrderHandler {
 // @Tags orders
 // @Accept json
 // @Produce json
-// @Param models.Order body models.Order true "order"
+// @Param order body models.Order true "order"
 // @Success 200 {object} models.ResponseCreate
-// @Failure 400 {object} nil
-// @Failure 500 {object} nil
+// @Failure 400 {object} models.ResponseError
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /orders [post]
 func (h *Handler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, `{"error": "Failed to read request body"}`, http.StatusBadRequest)
+		utils.WriteError(w, `{"error": "Failed to read request body"}`, http.StatusBadRequest)
 		return
 	}
 
 	var order models.Order
 	err = json.Unmarshal(body, &order)
 	if err != nil {
-		http.Error(w, `{"error": "Error unmarshaling JSON"}`, http.StatusBadRequest)
-		return
-	}
-	err = utils.CheckFormatDate(order.PurchaseDate)
-	if err != nil {
-		http.Error(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
+		utils.WriteError(w, `{"error": "`+unmarshalError(err)+`"}`, http.StatusBadRequest)
 		return
 	}
+	principal, _ := utils.GetPrincipal(r.Context())
+	err = utils.ValidateNewOrder(principal, order)
 	var response *models.ResponseCreate
-	response, err = h.u.CreateOrder(order)
+	if err == nil {
+		response, err = h.u.CreateOrder(order)
+	}
 	if err != nil {
-		http.Error(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
+		writeRequestError(w, utils.CreateOrderError(err))
 		return
 	}
 
+	// A replay of an order already created changed nothing to audit.
+	if !response.Duplicate {
+		h.recordAudit(r, models.AuditEntry{
+			Action:    models.AuditActionCreateOrder,
+			OrderID:   response.OrderID,
+			NewStatus: response.Status,
+		})
+	}
+
 	json, err := json.Marshal(response)
 	if err != nil {
-		http.Error(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
+		utils.WriteError(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
 		return
 	}
 
@@ -72,92 +87,194 @@ func (h *Handler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 
 // UpdateEventOrder godoc
 // @Summary Updates the status of an order
-// @Description Updates the state of an order by processing a specific event
+// @Description Updates the state of an order by processing a specific event. The user of the event is the authenticated subject.
+// @Description Canceled and Returned events require a reason with a code of the catalogue, and Returned events the returned products. A Returned event that leaves units to return moves the order to PartiallyReturned
+// @Description An order changed by another request while the event was applied answers 409, the event can be sent again
 // @Tags orders events
 // @Accept json
 // @Produce json
-// @Param models.Event body models.Event true "event"
-// @Param orderId query string true "order id" string
+// @Param orderId path int64 true "order id"
+// @Param event body models.Event true "event"
 // @Success 200 {object} models.ResponseUpdate
-// @Failure 400 {object} nil
-// @Failure 500 {object} nil
+// @Failure 400 {object} models.ResponseError
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 404 {object} models.ResponseError
+// @Failure 409 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /orders/{orderId}/events [post]
 func (h *Handler) UpdateEventOrder(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		http.Error(w, `{"error": "Failed to read request body"}`, http.StatusBadRequest)
+		utils.WriteError(w, `{"error": "Failed to read request body"}`, http.StatusBadRequest)
 		return
 	}
 
 	var event models.Event
 	orderID := chi.URLParam(r, "orderId")
 	orderIDInt, err := strconv.Atoi(orderID)
+	if err != nil {
+		utils.WriteError(w, `{"error": "ID must be a number"}`, http.StatusBadRequest)
+		return
+	}
 	err = json.Unmarshal(body, &event)
 	if err != nil {
-		http.Error(w, `{"error": "Error unmarshaling JSON"}`, http.StatusBadRequest)
+		utils.WriteError(w, `{"error": "`+unmarshalError(err)+`"}`, http.StatusBadRequest)
 		return
 	}
+	event.User = utils.GetActor(r)
 
 	err = utils.CheckFormatDate(event.Date)
+	if err == nil {
+		err = h.checkOrderChannel(r, int64(orderIDInt))
+	}
+	var response *models.ResponseUpdate
+	if err == nil {
+		response, err = h.u.UpdateEventOrder(int64(orderIDInt), event)
+	}
 	if err != nil {
-		http.Error(w, `{"error": "`+error.Error(err)+`"}`, http.StatusBadRequest)
+		writeRequestError(w, utils.AddEventError(err))
 		return
 	}
 
-	var response *models.ResponseUpdate
-	response, err = h.u.UpdateEventOrder(int64(orderIDInt), event)
+	if !response.Duplicate {
+		h.recordAudit(r, models.AuditEntry{
+			Action:         models.AuditActionAddEvent,
+			OrderID:        response.OrderID,
+			PreviousStatus: response.PreviousStatus,
+			NewStatus:      response.NewStatus,
+		})
+	}
+
+	json, err := json.Marshal(response)
+	if err != nil {
+		utils.WriteError(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
+		return
+	}
+
+	// Logic to parse request body and insert a new order into MongoDB
+	w.Write(json)
+}
+
+// AmendOrder godoc
+// @Summary Amends an order
+// @Description Applies a JSON Merge Patch to an order in Created status. Only the buyer phone, the products and the total value can change, and the total value must match the products.
+// @Description The changes are recorded in an Amended event
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param orderId path int64 true "order id"
+// @Param patch body models.Order true "merge patch with buyer.phone, products and totalValue"
+// @Success 200 {object} models.ResponseAmend
+// @Failure 400 {object} models.ResponseError
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 404 {object} models.ResponseError
+// @Failure 409 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /orders/{orderId} [patch]
+func (h *Handler) AmendOrder(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	orderID := chi.URLParam(r, "orderId")
+	orderIDInt, err := strconv.Atoi(orderID)
+	if err != nil {
+		utils.WriteError(w, `{"error": "ID must be a number"}`, http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.WriteError(w, `{"error": "Failed to read request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	err = h.checkOrderChannel(r, int64(orderIDInt))
+	var response *models.ResponseAmend
+	if err == nil {
+		response, err = h.u.AmendOrder(int64(orderIDInt), body, utils.GetActor(r))
+	}
 	if err == mongo.ErrNoDocuments {
-		http.Error(w, `{"error": "The search did not return any results. Incorrect ID."}`, http.StatusNotFound)
+		utils.WriteError(w, `{"error": "The search did not return any results. Incorrect ID."}`, http.StatusNotFound)
+		return
+	} else if err == orderUseCase.ErrOrderNotAmendable || err == ports.ErrOrderChanged {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusConflict)
+		return
+	} else if isInvalidAmendment(err) {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusBadRequest)
 		return
 	} else if err != nil {
-		http.Error(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
 		return
 	}
 
+	if len(response.Changes) > 0 {
+		h.recordAudit(r, models.AuditEntry{
+			Action:         models.AuditActionAmendOrder,
+			OrderID:        response.OrderID,
+			PreviousStatus: response.Status,
+			NewStatus:      response.Status,
+		})
+	}
+
 	json, err := json.Marshal(response)
 	if err != nil {
-		http.Error(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
+		utils.WriteError(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
 		return
 	}
 
-	// Logic to parse request body and insert a new order into MongoDB
 	w.Write(json)
 }
 
 // GetOrderByID godoc
 // @Summary Get Order by ID
-// @Description Gets an order by its id and adds the translations to spanish of channel and status
+// @Description Gets an order by its id and adds the translations of channel and status, in the locale of the lang parameter or the Accept-Language header (es-AR, en or pt-BR, es-AR by default)
 // @Tags orders
 // @Accept json
 // @Produce json
-// @Param orderId query int64 true "order id" int64
+// @Param orderId path int64 true "order id"
+// @Param lang query string false "locale of the translations, it takes precedence over Accept-Language"
+// @Param Accept-Language header string false "locales of the translations by preference"
 // @Success 200 {object} models.ResponseGet
-// @Failure 400 {object} nil
-// @Failure 500 {object} nil
+// @Failure 400 {object} models.ResponseError
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 404 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /orders/{orderId} [get]
 func (h *Handler) GetOrderByID(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	orderID := chi.URLParam(r, "orderId")
 	orderIDInt, err := strconv.Atoi(orderID)
 	if err != nil {
-		http.Error(w, `{"error": "ID must be a number"}`, http.StatusInternalServerError)
+		utils.WriteError(w, `{"error": "ID must be a number"}`, http.StatusBadRequest)
 		return
 	}
 
 	response, err := h.u.GetOrderByID(int64(orderIDInt))
-	if err == mongo.ErrNoDocuments {
-		http.Error(w, `{"error": "The search did not return any results. Incorrect ID."}`, http.StatusNotFound)
-		return
-	} else if err != nil {
-		http.Error(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
+	if principal, _ := utils.GetPrincipal(r.Context()); err == nil && !utils.OwnsChannel(principal, response.Channel) {
+		err = mongo.ErrNoDocuments
+	}
+	if err != nil {
+		writeRequestError(w, utils.GetOrderError(err))
 		return
 	}
 
+	h.translateOrder(w, r, response)
+
 	json, err := json.Marshal(response)
 	if err != nil {
-		http.Error(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
+		utils.WriteError(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
 		return
 	}
 
@@ -168,43 +285,123 @@ func (h *Handler) GetOrderByID(w http.ResponseWriter, r *http.Request) {
 // GetOrderByFilters godoc
 // @Summary Get Order by filters
 // @Description Gets Order that matches certain filters (OrderId, DocumentNumber, Status, CreatedOnFrom, CreatedOnTo)
+// @Description With the Accept header text/csv (one row per product) or application/x-ndjson (one order per line) the orders are streamed from the database
 // @Tags orders
 // @Accept json
-// @Produce json
-// @Param orderId query int64 true "order id" int64
-// @Param documentNumber query string true "document Number" string
-// @Param status query string true "status" string
-// @Param createdOnFrom query string true "created On From" string
-// @Param createdOnTo query string ture "created On To" string
+// @Produce json,text/csv,application/x-ndjson
+// @Param orderId query string false "order id, a non numeric id is ignored"
+// @Param documentNumber query string false "document number of the buyer"
+// @Param status query string false "status" Enums(Created, PaymentReceived, Canceled, Invoiced, Returned, PartiallyReturned)
+// @Param channel query string false "channel, API keys can only search their own channel" Enums(Ecommerce, CallCenter, Store, Affiliate)
+// @Param createdOnFrom query string false "created on from (RFC3339)"
+// @Param createdOnTo query string false "created on to (RFC3339)"
 // @Success 200 {object} []models.Order
-// @Failure 400 {object} nil
-// @Failure 500 {object} nil
+// @Failure 400 {object} models.ResponseError
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /orders/search [get]
 func (h *Handler) GetOrderByFilters(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	filters := utils.GetFilters(r)
+	err := utils.ValidateFilters(filters)
+	if err != nil {
+		writeRequestError(w, utils.SearchOrdersError(err))
+		return
+	}
+	if principal, ok := utils.GetPrincipal(r.Context()); ok && principal.Channel != "" {
+		filters.Channel = principal.Channel
+	}
 
-	err := utils.CheckFormatDate(filters.CreatedOnFrom)
+	if format := exportFormat(r); format != "" {
+		h.streamOrders(w, filters, format)
+		return
+	}
+
+	response, err := h.u.GetOrderByFilters(filters)
 	if err != nil {
-		http.Error(w, `{"error": "The From date is not in the correct format"}`, http.StatusInternalServerError)
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
 		return
 	}
-	err = utils.CheckFormatDate(filters.CreatedOnTo)
+
+	json, err := json.Marshal(response)
 	if err != nil {
-		http.Error(w, `{"error": "The To date is not in the correct format"}`, http.StatusInternalServerError)
+		utils.WriteError(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
 		return
 	}
 
-	response, err := h.u.GetOrderByFilters(filters)
+	w.Write(json)
+}
+
+// GetOrderEvents godoc
+// @Summary Get the event history of an order
+// @Description Gets the events of an order sorted by date, with the resulting status after each event. Supports pagination and filters by type, user and date range
+// @Tags orders events
+// @Accept json
+// @Produce json
+// @Param orderId path int64 true "order id"
+// @Param type query string false "event type" Enums(PaymentReceived, Canceled, Invoiced, Returned, Amended)
+// @Param user query string false "user"
+// @Param dateFrom query string false "date from (RFC3339)"
+// @Param dateTo query string false "date to (RFC3339)"
+// @Param page query int false "page number" default(1)
+// @Param pageSize query int false "page size" default(20)
+// @Success 200 {object} models.ResponseEvents
+// @Failure 400 {object} models.ResponseError
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 404 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /orders/{orderId}/events [get]
+func (h *Handler) GetOrderEvents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	orderID := chi.URLParam(r, "orderId")
+	orderIDInt, err := strconv.Atoi(orderID)
+	if err != nil {
+		utils.WriteError(w, `{"error": "ID must be a number"}`, http.StatusBadRequest)
+		return
+	}
+
+	filters := utils.GetEventFilters(r)
+
+	err = utils.CheckFormatDate(filters.DateFrom)
+	if err != nil {
+		utils.WriteError(w, `{"error": "The From date is not in the correct format"}`, http.StatusBadRequest)
+		return
+	}
+	err = utils.CheckFormatDate(filters.DateTo)
 	if err != nil {
-		http.Error(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
+		utils.WriteError(w, `{"error": "The To date is not in the correct format"}`, http.StatusBadRequest)
+		return
+	}
+	if filters.Type != "" && !filters.Type.IsValid() {
+		utils.WriteError(w, `{"error": "`+error.Error(utils.ErrEventTypeFilter)+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	err = h.checkOrderChannel(r, int64(orderIDInt))
+	var response *models.ResponseEvents
+	if err == nil {
+		response, err = h.u.GetOrderEvents(int64(orderIDInt), filters)
+	}
+	if err == mongo.ErrNoDocuments {
+		utils.WriteError(w, `{"error": "The search did not return any results. Incorrect ID."}`, http.StatusNotFound)
+		return
+	} else if err != nil {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
 		return
 	}
 
 	json, err := json.Marshal(response)
 	if err != nil {
-		http.Error(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
+		utils.WriteError(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
 		return
 	}
 
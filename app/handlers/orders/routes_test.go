@@ -0,0 +1,272 @@
+package orders_test
+
+import (
+	"challenge_pyegros/app/database"
+	"challenge_pyegros/app/models"
+	apiKeysRepository "challenge_pyegros/app/repositories/apikeys"
+	cacheRepository "challenge_pyegros/app/repositories/cache"
+	reportsRepository "challenge_pyegros/app/repositories/reports"
+	"errors"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// The audit, reports and admin handlers are tested through the router of
+// the orders handlers, so their responses are checked against the spec too.
+
+var (
+	auditEntry = models.AuditEntry{
+		Actor:          "user-001",
+		Action:         models.AuditActionAddEvent,
+		OrderID:        1,
+		PreviousStatus: models.StatusCreated,
+		NewStatus:      models.StatusPaymentReceived,
+		RequestID:      "request-001",
+		SourceIP:       "10.0.0.1",
+		Timestamp:      "2024-05-01T15:00:00Z",
+	}
+
+	apiKey = models.APIKey{
+		ID:        "key-001",
+		Prefix:    "ok_live_abc",
+		Channel:   models.ChannelEcommerce,
+		Scopes:    []string{models.RoleChannelClient},
+		CreatedOn: "2024-05-01T14:00:00Z",
+	}
+)
+
+func TestGetAuditEntries(t *testing.T) {
+	auditors := []string{models.RoleAuditor}
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:   "entries",
+			method: http.MethodGet, path: "/api/v1/audit?action=AddEvent&orderId=1&from=2024-05-01T12:00:00-03:00", roles: auditors,
+			setupMocks: func(m *routerMocks) {
+				m.audit.EXPECT().GetAuditEntries(models.AuditFilters{
+					Action:   models.AuditActionAddEvent,
+					OrderId:  1,
+					From:     "2024-05-01T15:00:00Z",
+					Page:     1,
+					PageSize: 20,
+				}).Return([]models.AuditEntry{auditEntry}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "csv",
+			method: http.MethodGet, path: "/api/v1/audit?format=csv", roles: auditors,
+			setupMocks: func(m *routerMocks) {
+				m.audit.EXPECT().GetAuditEntries(models.AuditFilters{}).Return([]models.AuditEntry{auditEntry}, nil)
+			},
+			status: http.StatusOK, contentType: "text/csv",
+		},
+		{
+			name:   "invalid from date",
+			method: http.MethodGet, path: "/api/v1/audit?from=2024-05-01", roles: auditors,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "not an auditor",
+			method: http.MethodGet, path: "/api/v1/audit", roles: []string{models.RoleBackoffice},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "failed",
+			method: http.MethodGet, path: "/api/v1/audit", roles: auditors,
+			setupMocks: func(m *routerMocks) {
+				m.audit.EXPECT().GetAuditEntries(models.AuditFilters{Page: 1, PageSize: 20}).Return(nil, errors.New("Failed to read the audit"))
+			},
+			status: http.StatusInternalServerError,
+		},
+	})
+}
+
+func TestReports(t *testing.T) {
+	analysts := []string{models.RoleBackoffice}
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:   "reasons",
+			method: http.MethodGet, path: "/api/v1/reports/reasons?type=Canceled&from=2024-05-01T00:00:00Z", roles: analysts,
+			setupMocks: func(m *routerMocks) {
+				m.reports.EXPECT().GetReasonsReport(models.ReportFilters{Type: models.EventCanceled, From: "2024-05-01T00:00:00Z"}).Return([]models.ReasonReport{
+					{Type: "Canceled", Code: "OUT_OF_STOCK", Count: 2},
+				}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "reasons of an unknown type",
+			method: http.MethodGet, path: "/api/v1/reports/reasons?type=Invoiced", roles: analysts,
+			setupMocks: func(m *routerMocks) {
+				m.reports.EXPECT().GetReasonsReport(models.ReportFilters{Type: models.EventInvoiced}).Return(nil, reportsRepository.ErrInvalidReasonType)
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "reasons in memory",
+			method: http.MethodGet, path: "/api/v1/reports/reasons", roles: analysts,
+			setupMocks: func(m *routerMocks) {
+				m.reports.EXPECT().GetReasonsReport(models.ReportFilters{}).Return(nil, reportsRepository.ErrReportsUnavailable)
+			},
+			status: http.StatusServiceUnavailable,
+		},
+		{
+			name:   "sales",
+			method: http.MethodGet, path: "/api/v1/reports/sales?groupBy=day&from=2024-05-01T00:00:00Z&to=2024-05-02T00:00:00Z", roles: analysts,
+			setupMocks: func(m *routerMocks) {
+				m.reports.EXPECT().GetSalesReport(models.SalesFilters{GroupBy: models.SalesGroupDay, From: "2024-05-01T00:00:00Z", To: "2024-05-02T00:00:00Z"}).Return(&models.ResponseSalesReport{
+					GroupBy: models.SalesGroupDay,
+					From:    "2024-05-01T00:00:00Z",
+					To:      "2024-05-02T00:00:00Z",
+					Groups:  []models.SalesReport{{Group: "2024-05-01", OrderCount: 1, Units: 2, TotalValue: 2000}},
+				}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "sales invalid to date",
+			method: http.MethodGet, path: "/api/v1/reports/sales?to=2024-05-02", roles: analysts,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "sales invalid group",
+			method: http.MethodGet, path: "/api/v1/reports/sales?groupBy=year", roles: analysts,
+			setupMocks: func(m *routerMocks) {
+				m.reports.EXPECT().GetSalesReport(models.SalesFilters{GroupBy: "year"}).Return(nil, reportsRepository.ErrInvalidGroupBy)
+			},
+			status: http.StatusBadRequest,
+		},
+	})
+}
+
+func TestAPIKeys(t *testing.T) {
+	admins := []string{models.RoleAdmin}
+	request := models.APIKeyRequest{Channel: models.ChannelEcommerce, Scopes: []string{models.RoleChannelClient}}
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:   "issue",
+			method: http.MethodPost, path: "/api/v1/admin/api-keys", body: toJSON(t, request), roles: admins,
+			setupMocks: func(m *routerMocks) {
+				m.apiKeys.EXPECT().IssueAPIKey(request).Return(&models.ResponseAPIKey{APIKey: apiKey, Key: "ok_live_abc123"}, nil)
+			},
+			status: http.StatusCreated, contentType: "application/json",
+		},
+		{
+			name:   "issue invalid scope",
+			method: http.MethodPost, path: "/api/v1/admin/api-keys", body: `{"channel": "Ecommerce", "scopes": ["root"]}`, roles: admins,
+			setupMocks: func(m *routerMocks) {
+				m.apiKeys.EXPECT().IssueAPIKey(models.APIKeyRequest{Channel: models.ChannelEcommerce, Scopes: []string{"root"}}).Return(nil, apiKeysRepository.ErrInvalidScope)
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "issue invalid json",
+			method: http.MethodPost, path: "/api/v1/admin/api-keys", body: `{"channel": `, roles: admins,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "issue not an admin",
+			method: http.MethodPost, path: "/api/v1/admin/api-keys", body: toJSON(t, request), roles: []string{models.RoleBackoffice},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "rotate",
+			method: http.MethodPost, path: "/api/v1/admin/api-keys/key-001/rotate", roles: admins,
+			setupMocks: func(m *routerMocks) {
+				rotated := apiKey
+				rotated.RotatedOn = "2024-05-02T14:00:00Z"
+				m.apiKeys.EXPECT().RotateAPIKey("key-001").Return(&models.ResponseAPIKey{APIKey: rotated, Key: "ok_live_def456"}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "rotate revoked",
+			method: http.MethodPost, path: "/api/v1/admin/api-keys/key-001/rotate", roles: admins,
+			setupMocks: func(m *routerMocks) {
+				m.apiKeys.EXPECT().RotateAPIKey("key-001").Return(nil, apiKeysRepository.ErrAPIKeyRevoked)
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name:   "rotate not found",
+			method: http.MethodPost, path: "/api/v1/admin/api-keys/key-002/rotate", roles: admins,
+			setupMocks: func(m *routerMocks) {
+				m.apiKeys.EXPECT().RotateAPIKey("key-002").Return(nil, mongo.ErrNoDocuments)
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "revoke",
+			method: http.MethodDelete, path: "/api/v1/admin/api-keys/key-001", roles: admins,
+			setupMocks: func(m *routerMocks) {
+				revoked := apiKey
+				revoked.RevokedOn = "2024-05-02T14:00:00Z"
+				m.apiKeys.EXPECT().RevokeAPIKey("key-001").Return(&revoked, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "revoke not found",
+			method: http.MethodDelete, path: "/api/v1/admin/api-keys/key-002", roles: admins,
+			setupMocks: func(m *routerMocks) {
+				m.apiKeys.EXPECT().RevokeAPIKey("key-002").Return(nil, mongo.ErrNoDocuments)
+			},
+			status: http.StatusNotFound,
+		},
+	})
+}
+
+func TestCacheKeys(t *testing.T) {
+	admins := []string{models.RoleAdmin}
+
+	runHandlerTests(t, []handlerTest{
+		{
+			name:   "keys",
+			method: http.MethodGet, path: "/api/v1/admin/cache/keys?pattern=v2:order:*&limit=5000", roles: admins,
+			setupMocks: func(m *routerMocks) {
+				m.cache.EXPECT().GetKeys("v2:order:*", int64(1000)).Return(&models.ResponseCacheKeys{
+					Pattern: "orders-api:v2:order:*",
+					Keys:    []models.CacheKey{{Key: "orders-api:v2:order:1", Type: "string", TTL: 60}},
+				}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "keys without pattern",
+			method: http.MethodGet, path: "/api/v1/admin/cache/keys", roles: admins,
+			setupMocks: func(m *routerMocks) {
+				m.cache.EXPECT().GetKeys("", int64(100)).Return(nil, cacheRepository.ErrMissingPattern)
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "keys without redis",
+			method: http.MethodGet, path: "/api/v1/admin/cache/keys?pattern=v2:order:*", roles: admins,
+			setupMocks: func(m *routerMocks) {
+				m.cache.EXPECT().GetKeys("v2:order:*", int64(100)).Return(nil, database.ErrRedisUnavailable)
+			},
+			status: http.StatusServiceUnavailable,
+		},
+		{
+			name:   "purge",
+			method: http.MethodDelete, path: "/api/v1/admin/cache/keys?pattern=v2:idempotency:*", roles: admins,
+			setupMocks: func(m *routerMocks) {
+				m.cache.EXPECT().PurgeKeys("v2:idempotency:*").Return(&models.ResponsePurge{Pattern: "orders-api:v2:idempotency:*", Deleted: 3}, nil)
+			},
+			status: http.StatusOK, contentType: "application/json",
+		},
+		{
+			name:   "purge without redis",
+			method: http.MethodDelete, path: "/api/v1/admin/cache/keys?pattern=v2:idempotency:*", roles: admins,
+			setupMocks: func(m *routerMocks) {
+				m.cache.EXPECT().PurgeKeys("v2:idempotency:*").Return(nil, database.ErrRedisUnavailable)
+			},
+			status: http.StatusServiceUnavailable,
+		},
+	})
+}
@@ -0,0 +1,15 @@
+package reports
+
+import (
+	ports "challenge_pyegros/app/ports/reports"
+)
+
+type Handler struct {
+	u ports.ReportsUseCase
+}
+
+func NewHandler(u ports.ReportsUseCase) *Handler {
+	return &Handler{
+		u: u,
+	}
+}
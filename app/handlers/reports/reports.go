@@ -0,0 +1,121 @@
+package reports
+
+import (
+	reportsRepository "challenge_pyegros/app/repositories/reports"
+	"challenge_pyegros/app/utils"
+	"encoding/json"
+	"net/http"
+)
+
+// GetReasonsReport godoc
+// @Summary Report of cancellation and return reasons
+// @Description Counts the Canceled and Returned events per reason code, with the amount of returned products, for the events dated between from and to
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param type query string false "Canceled or Returned, both when empty" Enums(Canceled, Returned)
+// @Param from query string false "from (RFC3339)"
+// @Param to query string false "to (RFC3339)"
+// @Success 200 {object} []models.ReasonReport
+// @Failure 400 {object} models.ResponseError
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+// @Failure 503 {object} models.ResponseError
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /reports/reasons [get]
+func (h *Handler) GetReasonsReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	filters := utils.GetReportFilters(r)
+
+	err := utils.CheckFormatDate(filters.From)
+	if err != nil {
+		utils.WriteError(w, `{"error": "The From date is not in the correct format"}`, http.StatusBadRequest)
+		return
+	}
+	err = utils.CheckFormatDate(filters.To)
+	if err != nil {
+		utils.WriteError(w, `{"error": "The To date is not in the correct format"}`, http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.u.GetReasonsReport(filters)
+	if err == reportsRepository.ErrInvalidReasonType {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusBadRequest)
+		return
+	} else if err == reportsRepository.ErrReportsUnavailable {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
+		return
+	}
+
+	json, err := json.Marshal(response)
+	if err != nil {
+		utils.WriteError(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(json)
+}
+
+// GetSalesReport godoc
+// @Summary Sales report
+// @Description Sums the orders purchased between from and to grouped by channel, status, day, week, month or sku: amount of orders, units and total value.
+// @Description When grouping by sku the total value is the price by quantity of the product lines. The reports are cached
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param groupBy query string false "channel (default), status, day, week, month or sku" Enums(channel, status, day, week, month, sku)
+// @Param from query string false "from (RFC3339)"
+// @Param to query string false "to (RFC3339)"
+// @Success 200 {object} models.ResponseSalesReport
+// @Failure 400 {object} models.ResponseError
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Failure 500 {object} models.ResponseError
+// @Failure 503 {object} models.ResponseError
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /reports/sales [get]
+func (h *Handler) GetSalesReport(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	filters := utils.GetSalesFilters(r)
+
+	err := utils.CheckFormatDate(filters.From)
+	if err != nil {
+		utils.WriteError(w, `{"error": "The From date is not in the correct format"}`, http.StatusBadRequest)
+		return
+	}
+	err = utils.CheckFormatDate(filters.To)
+	if err != nil {
+		utils.WriteError(w, `{"error": "The To date is not in the correct format"}`, http.StatusBadRequest)
+		return
+	}
+
+	response, err := h.u.GetSalesReport(filters)
+	if err == reportsRepository.ErrInvalidGroupBy {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusBadRequest)
+		return
+	} else if err == reportsRepository.ErrReportsUnavailable {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
+		utils.WriteError(w, `{"error": "`+error.Error(err)+`"}`, http.StatusInternalServerError)
+		return
+	}
+
+	json, err := json.Marshal(response)
+	if err != nil {
+		utils.WriteError(w, `{"error": "Failed to marshal response"}`, http.StatusInternalServerError)
+		return
+	}
+
+	w.Write(json)
+}
@@ -0,0 +1,258 @@
+package rpc
+
+import (
+	"challenge_pyegros/app/models"
+	ordersv1 "challenge_pyegros/app/proto/orders/v1"
+)
+
+var orderStatuses = map[ordersv1.OrderStatus]models.OrderStatus{
+	ordersv1.OrderStatus_ORDER_STATUS_UNSPECIFIED:        "",
+	ordersv1.OrderStatus_ORDER_STATUS_CREATED:            models.StatusCreated,
+	ordersv1.OrderStatus_ORDER_STATUS_PAYMENT_RECEIVED:   models.StatusPaymentReceived,
+	ordersv1.OrderStatus_ORDER_STATUS_CANCELED:           models.StatusCanceled,
+	ordersv1.OrderStatus_ORDER_STATUS_INVOICED:           models.StatusInvoiced,
+	ordersv1.OrderStatus_ORDER_STATUS_RETURNED:           models.StatusReturned,
+	ordersv1.OrderStatus_ORDER_STATUS_PARTIALLY_RETURNED: models.StatusPartiallyReturned,
+}
+
+var eventTypes = map[ordersv1.EventType]models.EventType{
+	ordersv1.EventType_EVENT_TYPE_UNSPECIFIED:      "",
+	ordersv1.EventType_EVENT_TYPE_PAYMENT_RECEIVED: models.EventPaymentReceived,
+	ordersv1.EventType_EVENT_TYPE_CANCELED:         models.EventCanceled,
+	ordersv1.EventType_EVENT_TYPE_INVOICED:         models.EventInvoiced,
+	ordersv1.EventType_EVENT_TYPE_RETURNED:         models.EventReturned,
+	ordersv1.EventType_EVENT_TYPE_AMENDED:          models.EventAmended,
+}
+
+var channels = map[ordersv1.Channel]models.Channel{
+	ordersv1.Channel_CHANNEL_UNSPECIFIED: "",
+	ordersv1.Channel_CHANNEL_ECOMMERCE:   models.ChannelEcommerce,
+	ordersv1.Channel_CHANNEL_CALL_CENTER: models.ChannelCallCenter,
+	ordersv1.Channel_CHANNEL_STORE:       models.ChannelStore,
+	ordersv1.Channel_CHANNEL_AFFILIATE:   models.ChannelAffiliate,
+}
+
+// toOrderStatus, toEventType and toChannel convert the enums of a request, a
+// value unknown to this version of the service fails as an unknown value of
+// the JSON body does.
+func toOrderStatus(value ordersv1.OrderStatus) (models.OrderStatus, error) {
+	status, ok := orderStatuses[value]
+	if !ok {
+		return "", models.ErrUnknownOrderStatus
+	}
+	return status, nil
+}
+
+func toEventType(value ordersv1.EventType) (models.EventType, error) {
+	eventType, ok := eventTypes[value]
+	if !ok {
+		return "", models.ErrUnknownEventType
+	}
+	return eventType, nil
+}
+
+func toChannel(value ordersv1.Channel) (models.Channel, error) {
+	channel, ok := channels[value]
+	if !ok {
+		return "", models.ErrUnknownChannel
+	}
+	return channel, nil
+}
+
+func fromOrderStatus(status models.OrderStatus) ordersv1.OrderStatus {
+	for value, candidate := range orderStatuses {
+		if candidate == status {
+			return value
+		}
+	}
+	return ordersv1.OrderStatus_ORDER_STATUS_UNSPECIFIED
+}
+
+func fromEventType(eventType models.EventType) ordersv1.EventType {
+	for value, candidate := range eventTypes {
+		if candidate == eventType {
+			return value
+		}
+	}
+	return ordersv1.EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+func fromChannel(channel models.Channel) ordersv1.Channel {
+	for value, candidate := range channels {
+		if candidate == channel {
+			return value
+		}
+	}
+	return ordersv1.Channel_CHANNEL_UNSPECIFIED
+}
+
+// toOrder converts the order of a CreateOrderRequest, its ID, status and
+// events are set by the use case.
+func toOrder(order *ordersv1.Order) (models.Order, error) {
+	channel, err := toChannel(order.GetChannel())
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	products := make([]models.Product, len(order.GetProducts()))
+	for i, product := range order.GetProducts() {
+		products[i] = models.Product{
+			Sku:         product.GetSku(),
+			Name:        product.GetName(),
+			Description: product.GetDescription(),
+			Price:       product.GetPrice(),
+			Quantity:    product.GetQuantity(),
+		}
+	}
+
+	buyer := order.GetBuyer()
+	return models.Order{
+		ExternalReferenceID: order.GetExternalReferenceId(),
+		Channel:             channel,
+		PurchaseDate:        order.GetPurchaseDate(),
+		TotalValue:          order.GetTotalValue(),
+		Buyer: models.Buyer{
+			FirstName:      buyer.GetFirstName(),
+			LastName:       buyer.GetLastName(),
+			DocumentNumber: buyer.GetDocumentNumber(),
+			Phone:          buyer.GetPhone(),
+		},
+		Products: products,
+	}, nil
+}
+
+func toEvent(event *ordersv1.Event) (models.Event, error) {
+	eventType, err := toEventType(event.GetType())
+	if err != nil {
+		return models.Event{}, err
+	}
+
+	var reason *models.EventReason
+	if event.GetReason() != nil {
+		reason = &models.EventReason{
+			Code:    event.GetReason().GetCode(),
+			Comment: event.GetReason().GetComment(),
+		}
+		for _, item := range event.GetReason().GetItems() {
+			reason.Items = append(reason.Items, models.ReturnedItem{
+				Sku:      item.GetSku(),
+				Quantity: item.GetQuantity(),
+			})
+		}
+	}
+
+	return models.Event{
+		Id:     event.GetId(),
+		Type:   eventType,
+		Date:   event.GetDate(),
+		Reason: reason,
+	}, nil
+}
+
+func toFilters(req *ordersv1.SearchOrdersRequest) (models.Filters, error) {
+	status, err := toOrderStatus(req.GetStatus())
+	if err != nil {
+		return models.Filters{}, err
+	}
+	channel, err := toChannel(req.GetChannel())
+	if err != nil {
+		return models.Filters{}, err
+	}
+
+	return models.Filters{
+		OrderId:        req.GetOrderId(),
+		DocumentNumber: req.GetDocumentNumber(),
+		Status:         status,
+		Channel:        channel,
+		CreatedOnFrom:  req.GetCreatedOnFrom(),
+		CreatedOnTo:    req.GetCreatedOnTo(),
+	}, nil
+}
+
+func fromOrder(order models.Order) *ordersv1.Order {
+	products := make([]*ordersv1.Product, len(order.Products))
+	for i, product := range order.Products {
+		products[i] = &ordersv1.Product{
+			Sku:              product.Sku,
+			Name:             product.Name,
+			Description:      product.Description,
+			Price:            product.Price,
+			Quantity:         product.Quantity,
+			ReturnedQuantity: product.ReturnedQuantity,
+		}
+	}
+
+	events := make([]*ordersv1.Event, len(order.Events))
+	for i, event := range order.Events {
+		events[i] = fromEvent(event)
+	}
+
+	return &ordersv1.Order{
+		OrderId:             order.OrderID,
+		ExternalReferenceId: order.ExternalReferenceID,
+		Channel:             fromChannel(order.Channel),
+		PurchaseDate:        order.PurchaseDate,
+		TotalValue:          order.TotalValue,
+		RefundedAmount:      order.RefundedAmount,
+		Buyer: &ordersv1.Buyer{
+			FirstName:      order.Buyer.FirstName,
+			LastName:       order.Buyer.LastName,
+			DocumentNumber: order.Buyer.DocumentNumber,
+			Phone:          order.Buyer.Phone,
+		},
+		Products: products,
+		Status:   fromOrderStatus(order.Status),
+		Events:   events,
+	}
+}
+
+func fromEvent(event models.Event) *ordersv1.Event {
+	var reason *ordersv1.EventReason
+	if event.Reason != nil {
+		reason = &ordersv1.EventReason{
+			Code:    event.Reason.Code,
+			Comment: event.Reason.Comment,
+		}
+		for _, item := range event.Reason.Items {
+			reason.Items = append(reason.Items, &ordersv1.ReturnedItem{
+				Sku:      item.Sku,
+				Quantity: item.Quantity,
+			})
+		}
+	}
+
+	changes := make([]*ordersv1.FieldChange, len(event.Changes))
+	for i, change := range event.Changes {
+		changes[i] = &ordersv1.FieldChange{
+			Field: change.Field,
+			From:  change.From,
+			To:    change.To,
+		}
+	}
+
+	return &ordersv1.Event{
+		Id:      event.Id,
+		Type:    fromEventType(event.Type),
+		Date:    event.Date,
+		User:    event.User,
+		Reason:  reason,
+		Changes: changes,
+	}
+}
+
+// fromResponseGet converts the order read by ID, the translations are left
+// to the clients of the gRPC API.
+func fromResponseGet(response models.ResponseGet) *ordersv1.Order {
+	return fromOrder(models.Order{
+		OrderID:             response.OrderID,
+		ExternalReferenceID: response.ExternalReferenceID,
+		Channel:             response.Channel,
+		PurchaseDate:        response.PurchaseDate,
+		TotalValue:          response.TotalValue,
+		RefundedAmount:      response.RefundedAmount,
+		Buyer:               response.Buyer,
+		Products:            response.Products,
+		Status:              response.Status,
+		Events:              response.Events,
+	})
+}
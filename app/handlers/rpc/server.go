@@ -0,0 +1,294 @@
+package rpc
+
+import (
+	"challenge_pyegros/app/models"
+	auditPorts "challenge_pyegros/app/ports/audit"
+	ports "challenge_pyegros/app/ports/orders"
+	ordersv1 "challenge_pyegros/app/proto/orders/v1"
+	orderUseCase "challenge_pyegros/app/usecases/orders"
+	"challenge_pyegros/app/utils"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	DefaultWatchInterval = time.Second
+	// DefaultMaxWatchers bounds the streams of WatchOrder open at the same
+	// time, every one of them reads its order on each interval.
+	DefaultMaxWatchers = 100
+
+	// RequestIDMetadata is the metadata key of the request ID recorded in
+	// the audit trail, as the X-Request-Id header of the REST API.
+	RequestIDMetadata = "x-request-id"
+)
+
+// Roles are the roles allowed per method, the same of the REST routes.
+var Roles = map[string][]string{
+	ordersv1.OrdersService_CreateOrder_FullMethodName:  {models.RoleChannelClient},
+	ordersv1.OrdersService_AddEvent_FullMethodName:     {models.RoleBackoffice},
+	ordersv1.OrdersService_GetOrder_FullMethodName:     {models.RoleChannelClient, models.RoleBackoffice, models.RoleAuditor},
+	ordersv1.OrdersService_SearchOrders_FullMethodName: {models.RoleChannelClient, models.RoleBackoffice, models.RoleAuditor},
+	ordersv1.OrdersService_WatchOrder_FullMethodName:   {models.RoleChannelClient, models.RoleBackoffice, models.RoleAuditor},
+}
+
+// RateLimitRoutes are the REST routes whose rate limit applies to each
+// method, a client has a single window for the same operation in both APIs.
+// WatchOrder has a route of its own.
+var RateLimitRoutes = map[string]string{
+	ordersv1.OrdersService_CreateOrder_FullMethodName:  "create-order",
+	ordersv1.OrdersService_AddEvent_FullMethodName:     "add-event",
+	ordersv1.OrdersService_GetOrder_FullMethodName:     "get-order",
+	ordersv1.OrdersService_SearchOrders_FullMethodName: "search-orders",
+	ordersv1.OrdersService_WatchOrder_FullMethodName:   "watch-order",
+}
+
+// ErrTooManyWatchers is answered with ResourceExhausted when every stream of
+// WatchOrder is taken.
+var ErrTooManyWatchers = status.Error(codes.ResourceExhausted, "Too many orders watched, try again later")
+
+// Server is the gRPC API of the orders. It runs the use case of the REST
+// handlers with the same validation and errors, translated to gRPC codes.
+type Server struct {
+	ordersv1.UnimplementedOrdersServiceServer
+	u             ports.OrdersUseCase
+	audit         auditPorts.AuditUseCase
+	watchInterval time.Duration
+	watchers      chan struct{}
+}
+
+type Option func(*Server)
+
+// WithWatchInterval sets how often WatchOrder reads the order. The orders
+// have no change feed, so the watch polls the order cache.
+func WithWatchInterval(interval time.Duration) Option {
+	return func(s *Server) {
+		s.watchInterval = interval
+	}
+}
+
+// WithMaxWatchers sets how many streams of WatchOrder can be open at the same
+// time, the next ones are rejected until one of them ends.
+func WithMaxWatchers(watchers int) Option {
+	return func(s *Server) {
+		s.watchers = make(chan struct{}, watchers)
+	}
+}
+
+func NewServer(u ports.OrdersUseCase, audit auditPorts.AuditUseCase, options ...Option) *Server {
+	server := &Server{
+		u:             u,
+		audit:         audit,
+		watchInterval: DefaultWatchInterval,
+		watchers:      make(chan struct{}, DefaultMaxWatchers),
+	}
+	for _, option := range options {
+		option(server)
+	}
+	return server
+}
+
+func (s *Server) CreateOrder(ctx context.Context, req *ordersv1.CreateOrderRequest) (*ordersv1.CreateOrderResponse, error) {
+	order, err := toOrder(req.GetOrder())
+	if err == nil {
+		principal, _ := utils.GetPrincipal(ctx)
+		err = utils.ValidateNewOrder(principal, order)
+	}
+	var response *models.ResponseCreate
+	if err == nil {
+		response, err = s.u.CreateOrder(order)
+	}
+	if err != nil {
+		return nil, toStatus(utils.CreateOrderError(err))
+	}
+
+	// A replay of an order already created changed nothing to audit.
+	if !response.Duplicate {
+		s.recordAudit(ctx, models.AuditEntry{
+			Action:    models.AuditActionCreateOrder,
+			OrderID:   response.OrderID,
+			NewStatus: response.Status,
+		})
+	}
+
+	return &ordersv1.CreateOrderResponse{
+		OrderId:   response.OrderID,
+		Status:    fromOrderStatus(response.Status),
+		UpdatedOn: response.UpdatedOn,
+	}, nil
+}
+
+func (s *Server) AddEvent(ctx context.Context, req *ordersv1.AddEventRequest) (*ordersv1.AddEventResponse, error) {
+	event, err := toEvent(req.GetEvent())
+	if err == nil {
+		event.User = utils.GetContextActor(ctx)
+		err = utils.CheckFormatDate(event.Date)
+	}
+	if err != nil {
+		return nil, toStatus(utils.AddEventError(err))
+	}
+	if principal, ok := utils.GetPrincipal(ctx); ok && principal.Channel != "" {
+		if _, err = s.getOrder(ctx, req.GetOrderId()); err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := s.u.UpdateEventOrder(req.GetOrderId(), event)
+	if err != nil {
+		return nil, toStatus(utils.AddEventError(err))
+	}
+
+	if !response.Duplicate {
+		s.recordAudit(ctx, models.AuditEntry{
+			Action:         models.AuditActionAddEvent,
+			OrderID:        response.OrderID,
+			PreviousStatus: response.PreviousStatus,
+			NewStatus:      response.NewStatus,
+		})
+	}
+
+	return &ordersv1.AddEventResponse{
+		OrderId:        response.OrderID,
+		PreviousStatus: fromOrderStatus(response.PreviousStatus),
+		NewStatus:      fromOrderStatus(response.NewStatus),
+		UpdatedOn:      response.UpdatedOn,
+	}, nil
+}
+
+func (s *Server) GetOrder(ctx context.Context, req *ordersv1.GetOrderRequest) (*ordersv1.Order, error) {
+	response, err := s.getOrder(ctx, req.GetOrderId())
+	if err != nil {
+		return nil, err
+	}
+	return fromResponseGet(*response), nil
+}
+
+func (s *Server) SearchOrders(req *ordersv1.SearchOrdersRequest, stream ordersv1.OrdersService_SearchOrdersServer) error {
+	filters, err := toFilters(req)
+	if err == nil {
+		err = utils.ValidateFilters(filters)
+	}
+	if err != nil {
+		return toStatus(utils.SearchOrdersError(err))
+	}
+	if principal, ok := utils.GetPrincipal(stream.Context()); ok && principal.Channel != "" {
+		filters.Channel = principal.Channel
+	}
+
+	var sendErr error
+	err = s.u.StreamOrdersByFilters(filters, func(order models.Order) error {
+		sendErr = stream.Send(fromOrder(order))
+		return sendErr
+	})
+	if sendErr != nil {
+		return sendErr
+	}
+	if err != nil {
+		return toStatus(utils.SearchOrdersError(err))
+	}
+	return nil
+}
+
+// WatchOrder sends the order and then every change read from it, until the
+// order is Canceled or Returned or the client goes away. The stream is
+// rejected when every watcher is taken.
+func (s *Server) WatchOrder(req *ordersv1.WatchOrderRequest, stream ordersv1.OrdersService_WatchOrderServer) error {
+	select {
+	case s.watchers <- struct{}{}:
+		defer func() { <-s.watchers }()
+	default:
+		return ErrTooManyWatchers
+	}
+
+	ctx := stream.Context()
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	var last *ordersv1.Order
+	for {
+		response, err := s.getOrder(ctx, req.GetOrderId())
+		if err != nil {
+			return err
+		}
+
+		order := fromResponseGet(*response)
+		if last == nil || !proto.Equal(last, order) {
+			if err = stream.Send(order); err != nil {
+				return err
+			}
+			last = order
+		}
+		if response.Status == models.StatusCanceled || response.Status == models.StatusReturned {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// getOrder reads the order as GetOrderByID of the REST API, the orders of
+// another channel than the principal's are not found.
+func (s *Server) getOrder(ctx context.Context, orderID int64) (*models.ResponseGet, error) {
+	response, err := s.u.GetOrderByID(orderID)
+	if principal, _ := utils.GetPrincipal(ctx); err == nil && !utils.OwnsChannel(principal, response.Channel) {
+		err = mongo.ErrNoDocuments
+	}
+	if err != nil {
+		return nil, toStatus(utils.GetOrderError(err))
+	}
+	return response, nil
+}
+
+// recordAudit completes the entry with the call metadata and appends it to
+// the audit trail. A failure is logged but does not fail the call, because
+// the change has already been applied.
+func (s *Server) recordAudit(ctx context.Context, entry models.AuditEntry) {
+	entry.Actor = utils.GetContextActor(ctx)
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get(RequestIDMetadata)) > 0 {
+		entry.RequestID = md.Get(RequestIDMetadata)[0]
+	}
+	entry.SourceIP = utils.GetPeerIP(ctx)
+	entry.Timestamp = time.Now().UTC().Format(time.RFC3339)
+
+	err := s.audit.Record(entry)
+	if err != nil {
+		fmt.Println("Error recording audit entry: " + err.Error())
+	}
+}
+
+// toStatus translates the HTTP status of an error of the REST API to its
+// gRPC code. The conflicts are told apart: an event ID already used exists,
+// a transition the status of the order does not allow is a failed
+// precondition, and an order changed by another request can be retried.
+func toStatus(err *utils.RequestError) error {
+	code := codes.Internal
+	switch err.Status {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		switch err.Err {
+		case orderUseCase.ErrAnotherEventWithSameID:
+			code = codes.AlreadyExists
+		case orderUseCase.ErrInvalidStateTransition:
+			code = codes.FailedPrecondition
+		default:
+			code = codes.Aborted
+		}
+	}
+	return status.Error(code, err.Error())
+}
@@ -0,0 +1,562 @@
+package rpc
+
+import (
+	"challenge_pyegros/app/config"
+	"challenge_pyegros/app/middlewares"
+	"challenge_pyegros/app/models"
+	apiKeysMocks "challenge_pyegros/app/ports/apikeys/mocks"
+	auditMocks "challenge_pyegros/app/ports/audit/mocks"
+	ports "challenge_pyegros/app/ports/orders"
+	"challenge_pyegros/app/ports/orders/mocks"
+	ordersv1 "challenge_pyegros/app/proto/orders/v1"
+	orderUseCase "challenge_pyegros/app/usecases/orders"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+const secret = "test-secret"
+
+// storeKey authenticates a channel client and backoffice of the Store channel.
+const storeKey = "fk_store"
+
+type testServer struct {
+	client ordersv1.OrdersServiceClient
+	u      *mocks.MockOrdersUseCase
+	audit  *auditMocks.MockAuditUseCase
+}
+
+func newTestServer(t *testing.T, options ...Option) *testServer {
+	ctrl := gomock.NewController(t)
+	u := mocks.NewMockOrdersUseCase(ctrl)
+	audit := auditMocks.NewMockAuditUseCase(ctrl)
+
+	apiKeys := apiKeysMocks.NewMockAPIKeysUseCase(ctrl)
+	apiKeys.EXPECT().Authenticate(storeKey).Return(&models.Principal{
+		Subject: "apikey:1",
+		Roles:   []string{models.RoleChannelClient, models.RoleBackoffice},
+		Channel: models.ChannelStore,
+	}, nil).AnyTimes()
+	apiKeys.EXPECT().Authenticate(gomock.Any()).Return(nil, errors.New("Unknown API key")).AnyTimes()
+
+	jwtAuthenticator, err := middlewares.NewJWTAuthenticator(&config.Config{JWTSecret: secret})
+	assert.NoError(t, err)
+	authenticator := middlewares.NewGRPCAuthenticator(jwtAuthenticator, apiKeys, Roles)
+
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(authenticator.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(authenticator.StreamInterceptor()),
+	)
+	ordersv1.RegisterOrdersServiceServer(server, NewServer(u, audit, append([]Option{WithWatchInterval(time.Millisecond)}, options...)...))
+
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return &testServer{client: ordersv1.NewOrdersServiceClient(conn), u: u, audit: audit}
+}
+
+// withToken authenticates the call with a JWT of the subject and roles.
+func withToken(t *testing.T, subject string, roles ...string) context.Context {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   subject,
+		"roles": roles,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(secret))
+	assert.NoError(t, err)
+	return metadata.AppendToOutgoingContext(context.Background(), middlewares.AuthorizationMetadata, "Bearer "+token)
+}
+
+func withAPIKey(key string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), middlewares.APIKeyMetadata, key)
+}
+
+func newOrder(channel ordersv1.Channel) *ordersv1.Order {
+	return &ordersv1.Order{
+		Channel:      channel,
+		PurchaseDate: "2024-01-01T10:00:00Z",
+		TotalValue:   100,
+		Buyer:        &ordersv1.Buyer{FirstName: "Juan", LastName: "Perez", DocumentNumber: "12345678"},
+		Products:     []*ordersv1.Product{{Sku: "P001", Name: "Phone", Price: 100, Quantity: 1}},
+	}
+}
+
+func storedOrder(status models.OrderStatus, channel models.Channel) *models.ResponseGet {
+	return &models.ResponseGet{
+		OrderID:      1,
+		Channel:      channel,
+		PurchaseDate: "2024-01-01T10:00:00Z",
+		TotalValue:   100,
+		Products:     []models.Product{{Sku: "P001", Price: 100, Quantity: 1}},
+		Status:       status,
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		code codes.Code
+	}{
+		{name: "without credentials", ctx: context.Background(), code: codes.Unauthenticated},
+		{name: "unknown API key", ctx: withAPIKey("fk_unknown"), code: codes.Unauthenticated},
+		{name: "invalid token", ctx: metadata.AppendToOutgoingContext(context.Background(), middlewares.AuthorizationMetadata, "Bearer invalid"), code: codes.Unauthenticated},
+		{name: "role not allowed", ctx: withToken(t, "auditor", models.RoleAuditor), code: codes.PermissionDenied},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := server.client.CreateOrder(test.ctx, &ordersv1.CreateOrderRequest{Order: newOrder(ordersv1.Channel_CHANNEL_STORE)})
+			assert.Equal(t, test.code, status.Code(err))
+		})
+	}
+}
+
+func TestCreateOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		order    *ordersv1.Order
+		setup    func(s *testServer)
+		code     codes.Code
+		response *ordersv1.CreateOrderResponse
+	}{
+		{
+			name:  "created",
+			ctx:   withAPIKey(storeKey),
+			order: newOrder(ordersv1.Channel_CHANNEL_STORE),
+			setup: func(s *testServer) {
+				s.u.EXPECT().CreateOrder(gomock.Any()).DoAndReturn(func(order models.Order) (*models.ResponseCreate, error) {
+					assert.Equal(t, models.ChannelStore, order.Channel)
+					assert.Equal(t, "12345678", order.Buyer.DocumentNumber)
+					return &models.ResponseCreate{OrderID: 1, Status: models.StatusCreated, UpdatedOn: order.PurchaseDate}, nil
+				})
+				s.audit.EXPECT().Record(gomock.Any()).DoAndReturn(func(entry models.AuditEntry) error {
+					assert.Equal(t, "apikey:1", entry.Actor)
+					assert.Equal(t, models.AuditActionCreateOrder, entry.Action)
+					assert.Equal(t, models.StatusCreated, entry.NewStatus)
+					return nil
+				})
+			},
+			code: codes.OK,
+			response: &ordersv1.CreateOrderResponse{
+				OrderId:   1,
+				Status:    ordersv1.OrderStatus_ORDER_STATUS_CREATED,
+				UpdatedOn: "2024-01-01T10:00:00Z",
+			},
+		},
+		{
+			name:  "duplicate is not audited",
+			ctx:   withAPIKey(storeKey),
+			order: newOrder(ordersv1.Channel_CHANNEL_STORE),
+			setup: func(s *testServer) {
+				s.u.EXPECT().CreateOrder(gomock.Any()).Return(&models.ResponseCreate{OrderID: 1, Status: models.StatusCreated, UpdatedOn: "2024-01-01T10:00:00Z", Duplicate: true}, nil)
+			},
+			code: codes.OK,
+			response: &ordersv1.CreateOrderResponse{
+				OrderId:   1,
+				Status:    ordersv1.OrderStatus_ORDER_STATUS_CREATED,
+				UpdatedOn: "2024-01-01T10:00:00Z",
+			},
+		},
+		{
+			name:  "channel of another API key",
+			ctx:   withAPIKey(storeKey),
+			order: newOrder(ordersv1.Channel_CHANNEL_ECOMMERCE),
+			code:  codes.PermissionDenied,
+		},
+		{
+			name:  "unknown channel",
+			ctx:   withAPIKey(storeKey),
+			order: newOrder(ordersv1.Channel(99)),
+			code:  codes.InvalidArgument,
+		},
+		{
+			name: "invalid purchase date",
+			ctx:  withAPIKey(storeKey),
+			order: func() *ordersv1.Order {
+				order := newOrder(ordersv1.Channel_CHANNEL_STORE)
+				order.PurchaseDate = "yesterday"
+				return order
+			}(),
+			code: codes.InvalidArgument,
+		},
+		{
+			name:  "total mismatch",
+			ctx:   withAPIKey(storeKey),
+			order: newOrder(ordersv1.Channel_CHANNEL_STORE),
+			setup: func(s *testServer) {
+				s.u.EXPECT().CreateOrder(gomock.Any()).Return(nil, orderUseCase.ErrTotalMismatch)
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name:  "external reference mismatch",
+			ctx:   withAPIKey(storeKey),
+			order: newOrder(ordersv1.Channel_CHANNEL_STORE),
+			setup: func(s *testServer) {
+				s.u.EXPECT().CreateOrder(gomock.Any()).Return(nil, orderUseCase.ErrMismatchExternalReference)
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name:  "channel not found",
+			ctx:   withAPIKey(storeKey),
+			order: newOrder(ordersv1.Channel_CHANNEL_STORE),
+			setup: func(s *testServer) {
+				s.u.EXPECT().CreateOrder(gomock.Any()).Return(nil, orderUseCase.ErrChannelNotFound)
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name:  "use case error",
+			ctx:   withAPIKey(storeKey),
+			order: newOrder(ordersv1.Channel_CHANNEL_STORE),
+			setup: func(s *testServer) {
+				s.u.EXPECT().CreateOrder(gomock.Any()).Return(nil, errors.New("Mongo is down"))
+			},
+			code: codes.Internal,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newTestServer(t)
+			if test.setup != nil {
+				test.setup(server)
+			}
+
+			response, err := server.client.CreateOrder(test.ctx, &ordersv1.CreateOrderRequest{Order: test.order})
+			assert.Equal(t, test.code, status.Code(err))
+			if test.response != nil {
+				assert.True(t, proto.Equal(test.response, response), response.String())
+			}
+		})
+	}
+}
+
+func TestAddEvent(t *testing.T) {
+	event := &ordersv1.Event{
+		Id:   "e1",
+		Type: ordersv1.EventType_EVENT_TYPE_CANCELED,
+		Date: "2024-01-02T10:00:00Z",
+		User: "spoofed",
+		Reason: &ordersv1.EventReason{
+			Code: "CUSTOMER_REQUEST",
+		},
+	}
+
+	tests := []struct {
+		name  string
+		event *ordersv1.Event
+		setup func(s *testServer)
+		code  codes.Code
+	}{
+		{
+			name:  "applied",
+			event: event,
+			setup: func(s *testServer) {
+				s.u.EXPECT().UpdateEventOrder(int64(1), gomock.Any()).DoAndReturn(func(orderID int64, event models.Event) (*models.ResponseUpdate, error) {
+					assert.Equal(t, "operator", event.User)
+					assert.Equal(t, models.EventCanceled, event.Type)
+					assert.Equal(t, "CUSTOMER_REQUEST", event.Reason.Code)
+					return &models.ResponseUpdate{OrderID: 1, PreviousStatus: models.StatusCreated, NewStatus: models.StatusCanceled, UpdatedOn: event.Date}, nil
+				})
+				s.audit.EXPECT().Record(gomock.Any()).Return(nil)
+			},
+			code: codes.OK,
+		},
+		{
+			name:  "duplicate is not audited",
+			event: event,
+			setup: func(s *testServer) {
+				s.u.EXPECT().UpdateEventOrder(int64(1), gomock.Any()).Return(&models.ResponseUpdate{OrderID: 1, PreviousStatus: models.StatusCreated, NewStatus: models.StatusCanceled, UpdatedOn: event.Date, Duplicate: true}, nil)
+			},
+			code: codes.OK,
+		},
+		{
+			name:  "order not found",
+			event: event,
+			setup: func(s *testServer) {
+				s.u.EXPECT().UpdateEventOrder(int64(1), gomock.Any()).Return(nil, mongo.ErrNoDocuments)
+			},
+			code: codes.NotFound,
+		},
+		{
+			name:  "invalid reason",
+			event: event,
+			setup: func(s *testServer) {
+				s.u.EXPECT().UpdateEventOrder(int64(1), gomock.Any()).Return(nil, orderUseCase.ErrMissingReason)
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name:  "order changed",
+			event: event,
+			setup: func(s *testServer) {
+				s.u.EXPECT().UpdateEventOrder(int64(1), gomock.Any()).Return(nil, ports.ErrOrderChanged)
+			},
+			code: codes.Aborted,
+		},
+		{
+			name:  "invalid state transition",
+			event: event,
+			setup: func(s *testServer) {
+				s.u.EXPECT().UpdateEventOrder(int64(1), gomock.Any()).Return(nil, orderUseCase.ErrInvalidStateTransition)
+			},
+			code: codes.FailedPrecondition,
+		},
+		{
+			name:  "another event with same id",
+			event: event,
+			setup: func(s *testServer) {
+				s.u.EXPECT().UpdateEventOrder(int64(1), gomock.Any()).Return(nil, orderUseCase.ErrAnotherEventWithSameID)
+			},
+			code: codes.AlreadyExists,
+		},
+		{
+			name:  "invalid date",
+			event: &ordersv1.Event{Id: "e1", Type: ordersv1.EventType_EVENT_TYPE_INVOICED, Date: "yesterday"},
+			code:  codes.InvalidArgument,
+		},
+		{
+			name:  "unknown event type",
+			event: &ordersv1.Event{Id: "e1", Type: ordersv1.EventType(99), Date: "2024-01-02T10:00:00Z"},
+			code:  codes.InvalidArgument,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newTestServer(t)
+			if test.setup != nil {
+				test.setup(server)
+			}
+
+			response, err := server.client.AddEvent(withToken(t, "operator", models.RoleBackoffice), &ordersv1.AddEventRequest{OrderId: 1, Event: test.event})
+			assert.Equal(t, test.code, status.Code(err))
+			if test.code == codes.OK {
+				assert.Equal(t, ordersv1.OrderStatus_ORDER_STATUS_CANCELED, response.GetNewStatus())
+			}
+		})
+	}
+}
+
+func TestAddEventOfAnotherChannel(t *testing.T) {
+	event := &ordersv1.Event{Id: "e1", Type: ordersv1.EventType_EVENT_TYPE_PAYMENT_RECEIVED, Date: "2024-01-02T10:00:00Z"}
+
+	server := newTestServer(t)
+	server.u.EXPECT().GetOrderByID(int64(1)).Return(storedOrder(models.StatusCreated, models.ChannelEcommerce), nil)
+
+	_, err := server.client.AddEvent(withAPIKey(storeKey), &ordersv1.AddEventRequest{OrderId: 1, Event: event})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	server.u.EXPECT().GetOrderByID(int64(2)).Return(storedOrder(models.StatusCreated, models.ChannelStore), nil)
+	server.u.EXPECT().UpdateEventOrder(int64(2), gomock.Any()).Return(&models.ResponseUpdate{OrderID: 2, PreviousStatus: models.StatusCreated, NewStatus: models.StatusPaymentReceived}, nil)
+	server.audit.EXPECT().Record(gomock.Any()).Return(nil)
+
+	response, err := server.client.AddEvent(withAPIKey(storeKey), &ordersv1.AddEventRequest{OrderId: 2, Event: event})
+	assert.NoError(t, err)
+	assert.Equal(t, ordersv1.OrderStatus_ORDER_STATUS_PAYMENT_RECEIVED, response.GetNewStatus())
+}
+
+func TestGetOrder(t *testing.T) {
+	server := newTestServer(t)
+	server.u.EXPECT().GetOrderByID(int64(1)).Return(storedOrder(models.StatusInvoiced, models.ChannelStore), nil).Times(2)
+	server.u.EXPECT().GetOrderByID(int64(2)).Return(nil, mongo.ErrNoDocuments)
+
+	order, err := server.client.GetOrder(withAPIKey(storeKey), &ordersv1.GetOrderRequest{OrderId: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, ordersv1.OrderStatus_ORDER_STATUS_INVOICED, order.GetStatus())
+	assert.Equal(t, ordersv1.Channel_CHANNEL_STORE, order.GetChannel())
+
+	_, err = server.client.GetOrder(withToken(t, "auditor", models.RoleAuditor), &ordersv1.GetOrderRequest{OrderId: 1})
+	assert.NoError(t, err)
+
+	_, err = server.client.GetOrder(withAPIKey(storeKey), &ordersv1.GetOrderRequest{OrderId: 2})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGetOrderOfAnotherChannel(t *testing.T) {
+	server := newTestServer(t)
+	server.u.EXPECT().GetOrderByID(int64(1)).Return(storedOrder(models.StatusInvoiced, models.ChannelEcommerce), nil)
+
+	_, err := server.client.GetOrder(withAPIKey(storeKey), &ordersv1.GetOrderRequest{OrderId: 1})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// receive reads the messages of a stream until it ends and returns them with
+// the error that ended it, nil at the end of the stream.
+func receive[T any](stream interface{ Recv() (T, error) }) ([]T, error) {
+	var messages []T
+	for {
+		message, err := stream.Recv()
+		if err == io.EOF {
+			return messages, nil
+		}
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, message)
+	}
+}
+
+func TestSearchOrders(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		request *ordersv1.SearchOrdersRequest
+		setup   func(s *testServer)
+		code    codes.Code
+		orders  int
+	}{
+		{
+			name:    "streams the orders of the channel of the API key",
+			ctx:     withAPIKey(storeKey),
+			request: &ordersv1.SearchOrdersRequest{Channel: ordersv1.Channel_CHANNEL_ECOMMERCE},
+			setup: func(s *testServer) {
+				s.u.EXPECT().StreamOrdersByFilters(models.Filters{Channel: models.ChannelStore}, gomock.Any()).DoAndReturn(func(filters models.Filters, fn func(order models.Order) error) error {
+					for _, id := range []int64{1, 2} {
+						if err := fn(models.Order{OrderID: id, Channel: models.ChannelStore, Status: models.StatusCreated}); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+			},
+			code:   codes.OK,
+			orders: 2,
+		},
+		{
+			name:    "unknown status",
+			ctx:     withToken(t, "operator", models.RoleBackoffice),
+			request: &ordersv1.SearchOrdersRequest{Status: ordersv1.OrderStatus(99)},
+			code:    codes.InvalidArgument,
+		},
+		{
+			name:    "invalid from date",
+			ctx:     withToken(t, "operator", models.RoleBackoffice),
+			request: &ordersv1.SearchOrdersRequest{CreatedOnFrom: "yesterday"},
+			code:    codes.InvalidArgument,
+		},
+		{
+			name:    "invalid to date",
+			ctx:     withToken(t, "operator", models.RoleBackoffice),
+			request: &ordersv1.SearchOrdersRequest{CreatedOnTo: "tomorrow"},
+			code:    codes.InvalidArgument,
+		},
+		{
+			name:    "use case error",
+			ctx:     withToken(t, "operator", models.RoleBackoffice),
+			request: &ordersv1.SearchOrdersRequest{},
+			setup: func(s *testServer) {
+				s.u.EXPECT().StreamOrdersByFilters(gomock.Any(), gomock.Any()).Return(errors.New("Mongo is down"))
+			},
+			code: codes.Internal,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newTestServer(t)
+			if test.setup != nil {
+				test.setup(server)
+			}
+
+			stream, err := server.client.SearchOrders(test.ctx, test.request)
+			assert.NoError(t, err)
+			orders, err := receive[*ordersv1.Order](stream)
+			assert.Equal(t, test.code, status.Code(err))
+			assert.Len(t, orders, test.orders)
+		})
+	}
+}
+
+func TestWatchOrder(t *testing.T) {
+	server := newTestServer(t)
+	gomock.InOrder(
+		server.u.EXPECT().GetOrderByID(int64(1)).Return(storedOrder(models.StatusCreated, models.ChannelStore), nil).Times(2),
+		server.u.EXPECT().GetOrderByID(int64(1)).Return(storedOrder(models.StatusPaymentReceived, models.ChannelStore), nil),
+		server.u.EXPECT().GetOrderByID(int64(1)).Return(storedOrder(models.StatusCanceled, models.ChannelStore), nil),
+	)
+
+	stream, err := server.client.WatchOrder(withAPIKey(storeKey), &ordersv1.WatchOrderRequest{OrderId: 1})
+	assert.NoError(t, err)
+	orders, err := receive[*ordersv1.Order](stream)
+	assert.NoError(t, err)
+
+	var statuses []ordersv1.OrderStatus
+	for _, order := range orders {
+		statuses = append(statuses, order.GetStatus())
+	}
+	assert.Equal(t, []ordersv1.OrderStatus{
+		ordersv1.OrderStatus_ORDER_STATUS_CREATED,
+		ordersv1.OrderStatus_ORDER_STATUS_PAYMENT_RECEIVED,
+		ordersv1.OrderStatus_ORDER_STATUS_CANCELED,
+	}, statuses)
+}
+
+func TestWatchOrderNotFound(t *testing.T) {
+	server := newTestServer(t)
+	server.u.EXPECT().GetOrderByID(int64(1)).Return(nil, mongo.ErrNoDocuments)
+
+	stream, err := server.client.WatchOrder(withToken(t, "auditor", models.RoleAuditor), &ordersv1.WatchOrderRequest{OrderId: 1})
+	assert.NoError(t, err)
+	_, err = receive[*ordersv1.Order](stream)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestWatchOrderMaxWatchers(t *testing.T) {
+	server := newTestServer(t, WithMaxWatchers(1))
+	server.u.EXPECT().GetOrderByID(int64(1)).Return(storedOrder(models.StatusCreated, models.ChannelStore), nil).AnyTimes()
+
+	watch := func() (ordersv1.OrdersService_WatchOrderClient, context.CancelFunc, error) {
+		ctx, cancel := context.WithCancel(withAPIKey(storeKey))
+		stream, err := server.client.WatchOrder(ctx, &ordersv1.WatchOrderRequest{OrderId: 1})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		return stream, cancel, err
+	}
+
+	_, cancel, err := watch()
+	assert.NoError(t, err)
+
+	_, cancelRejected, err := watch()
+	defer cancelRejected()
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// The watcher is released when its client goes away.
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, cancel, err := watch()
+		cancel()
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+}
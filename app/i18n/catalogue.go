@@ -0,0 +1,169 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultLocale is the locale of the responses when the request asks for
+	// none of the locales of the catalogue.
+	DefaultLocale = "es-AR"
+
+	// Groups of messages of the locale files.
+	Channel = "channel"
+	Status  = "status"
+)
+
+var (
+	ErrInvalidLocaleFile     = errors.New("Invalid locale file, it must map every group to its messages")
+	ErrDefaultLocaleNotFound = errors.New("The default locale has no locale file")
+)
+
+//go:embed locales/*.json
+var locales embed.FS
+
+// Locales returns the locale files shipped with the service.
+func Locales() fs.FS {
+	sub, _ := fs.Sub(locales, "locales")
+	return sub
+}
+
+// Catalogue holds the messages of every locale, read from one {locale}.json
+// file per locale with the messages by group and code, e.g.
+// {"status": {"Created": "Creado"}}.
+type Catalogue struct {
+	messages      map[string]map[string]map[string]string
+	locales       []string
+	defaultLocale string
+}
+
+func NewCatalogue(fsys fs.FS, defaultLocale string) (*Catalogue, error) {
+	files, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	catalogue := &Catalogue{
+		messages:      map[string]map[string]map[string]string{},
+		locales:       []string{},
+		defaultLocale: defaultLocale,
+	}
+	for _, file := range files {
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		var messages map[string]map[string]string
+		if err = json.Unmarshal(content, &messages); err != nil {
+			return nil, ErrInvalidLocaleFile
+		}
+
+		locale := strings.TrimSuffix(file, ".json")
+		catalogue.messages[locale] = messages
+		catalogue.locales = append(catalogue.locales, locale)
+	}
+	sort.Strings(catalogue.locales)
+
+	if _, ok := catalogue.messages[defaultLocale]; !ok {
+		return nil, ErrDefaultLocaleNotFound
+	}
+	return catalogue, nil
+}
+
+func (c *Catalogue) Locales() []string {
+	return c.locales
+}
+
+// Negotiate chooses the locale of a request: the lang parameter when the
+// catalogue has it, otherwise the first language of the Accept-Language
+// header, by quality, that it has, otherwise the default locale. A language
+// without region, or with a region the catalogue has not, falls back to a
+// locale of the same language, e.g. es-MX to es-AR.
+func (c *Catalogue) Negotiate(lang string, acceptLanguage string) string {
+	if locale, ok := c.match(lang); ok {
+		return locale
+	}
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if locale, ok := c.match(tag); ok {
+			return locale
+		}
+	}
+	return c.defaultLocale
+}
+
+// Translate returns the message of a code in the locale, or in the default
+// locale when the locale does not have it. An unknown code is returned as is.
+func (c *Catalogue) Translate(locale string, group string, code string) string {
+	if message, ok := c.messages[locale][group][code]; ok {
+		return message
+	}
+	if message, ok := c.messages[c.defaultLocale][group][code]; ok {
+		return message
+	}
+	return code
+}
+
+func (c *Catalogue) match(tag string) (string, bool) {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	if tag == "" || tag == "*" {
+		return "", false
+	}
+
+	for _, locale := range c.locales {
+		if strings.EqualFold(locale, tag) {
+			return locale, true
+		}
+	}
+
+	// The default locale is preferred among the locales of the language.
+	language, _, _ := strings.Cut(tag, "-")
+	candidates := append([]string{c.defaultLocale}, c.locales...)
+	for _, locale := range candidates {
+		base, _, _ := strings.Cut(locale, "-")
+		if strings.EqualFold(base, language) {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// parseAcceptLanguage returns the languages of the header by quality, without
+// the ones with quality 0.
+func parseAcceptLanguage(header string) []string {
+	type language struct {
+		tag     string
+		quality float64
+	}
+
+	languages := []language{}
+	for _, entry := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(entry, ";")
+		quality := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if strings.TrimSpace(tag) == "" || quality <= 0 {
+			continue
+		}
+		languages = append(languages, language{tag: strings.TrimSpace(tag), quality: quality})
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool { return languages[i].quality > languages[j].quality })
+
+	tags := []string{}
+	for _, language := range languages {
+		tags = append(tags, language.tag)
+	}
+	return tags
+}
@@ -0,0 +1,91 @@
+package i18n
+
+import (
+	"challenge_pyegros/app/models"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestCatalogue(t *testing.T) *Catalogue {
+	catalogue, err := NewCatalogue(Locales(), DefaultLocale)
+	assert.Nil(t, err)
+	return catalogue
+}
+
+func TestNewCatalogue(t *testing.T) {
+	catalogue := newTestCatalogue(t)
+	assert.Equal(t, []string{"en", "es-AR", "pt-BR"}, catalogue.Locales())
+
+	_, err := NewCatalogue(Locales(), "fr")
+	assert.Equal(t, ErrDefaultLocaleNotFound, err)
+
+	_, err = NewCatalogue(fstest.MapFS{"es-AR.json": {Data: []byte(`{"status": "Creado"}`)}}, DefaultLocale)
+	assert.Equal(t, ErrInvalidLocaleFile, err)
+}
+
+func TestLocaleFilesAreComplete(t *testing.T) {
+	catalogue := newTestCatalogue(t)
+	for _, locale := range catalogue.Locales() {
+		for _, channel := range models.Channels {
+			_, ok := catalogue.messages[locale][Channel][string(channel)]
+			assert.True(t, ok, "%s has no channel %s", locale, channel)
+		}
+		for _, status := range models.OrderStatuses {
+			_, ok := catalogue.messages[locale][Status][string(status)]
+			assert.True(t, ok, "%s has no status %s", locale, status)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	catalogue := newTestCatalogue(t)
+
+	tests := []struct {
+		name           string
+		lang           string
+		acceptLanguage string
+		locale         string
+	}{
+		{name: "nothing", locale: "es-AR"},
+		{name: "lang", lang: "en", acceptLanguage: "pt-BR", locale: "en"},
+		{name: "lang is case insensitive", lang: "PT_br", locale: "pt-BR"},
+		{name: "unknown lang", lang: "fr", acceptLanguage: "pt-BR", locale: "pt-BR"},
+		{name: "accept language", acceptLanguage: "en-US", locale: "en"},
+		{name: "by quality", acceptLanguage: "en;q=0.5, pt-BR;q=0.9, fr", locale: "pt-BR"},
+		{name: "quality 0", acceptLanguage: "pt-BR;q=0, en;q=0.1", locale: "en"},
+		{name: "same language", acceptLanguage: "es-MX", locale: "es-AR"},
+		{name: "language without region", acceptLanguage: "pt", locale: "pt-BR"},
+		{name: "unknown languages", acceptLanguage: "fr, de;q=0.8, *;q=0.1", locale: "es-AR"},
+		{name: "malformed quality", acceptLanguage: "en;q=abc, pt", locale: "pt-BR"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.locale, catalogue.Negotiate(test.lang, test.acceptLanguage))
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	catalogue := newTestCatalogue(t)
+
+	assert.Equal(t, "Centro de Llamadas", catalogue.Translate("es-AR", Channel, "CallCenter"))
+	assert.Equal(t, "Pago Recibido", catalogue.Translate("es-AR", Status, "PaymentReceived"))
+	assert.Equal(t, "Call Center", catalogue.Translate("en", Channel, "CallCenter"))
+	assert.Equal(t, "Parcialmente Devolvido", catalogue.Translate("pt-BR", Status, "PartiallyReturned"))
+	assert.Equal(t, "Invalid", catalogue.Translate("en", Status, "Invalid"))
+	assert.Equal(t, "Tienda", catalogue.Translate("fr", Channel, "Store"))
+}
+
+func TestTranslateFallsBackToDefaultLocale(t *testing.T) {
+	catalogue, err := NewCatalogue(fstest.MapFS{
+		"es-AR.json": {Data: []byte(`{"status": {"Created": "Creado", "Invoiced": "Facturado"}}`)},
+		"en.json":    {Data: []byte(`{"status": {"Created": "Created"}}`)},
+	}, DefaultLocale)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "Created", catalogue.Translate("en", Status, "Created"))
+	assert.Equal(t, "Facturado", catalogue.Translate("en", Status, "Invoiced"))
+}
@@ -0,0 +1,412 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"challenge_pyegros/app/config"
+	"challenge_pyegros/app/database"
+	apiKeysHandler "challenge_pyegros/app/handlers/apikeys"
+	auditHandler "challenge_pyegros/app/handlers/audit"
+	cacheHandler "challenge_pyegros/app/handlers/cache"
+	"challenge_pyegros/app/handlers/openapi"
+	orderHandler "challenge_pyegros/app/handlers/orders"
+	reportsHandler "challenge_pyegros/app/handlers/reports"
+	"challenge_pyegros/app/i18n"
+	"challenge_pyegros/app/middlewares"
+	"challenge_pyegros/app/models"
+	apiKeysRepository "challenge_pyegros/app/repositories/apikeys"
+	auditRepository "challenge_pyegros/app/repositories/audit"
+	cacheRepository "challenge_pyegros/app/repositories/cache"
+	orderRepository "challenge_pyegros/app/repositories/orders"
+	reportsRepository "challenge_pyegros/app/repositories/reports"
+	"challenge_pyegros/app/routes"
+	apiKeysUseCase "challenge_pyegros/app/usecases/apikeys"
+	auditUseCase "challenge_pyegros/app/usecases/audit"
+	cacheUseCase "challenge_pyegros/app/usecases/cache"
+	orderUseCase "challenge_pyegros/app/usecases/orders"
+	reportsUseCase "challenge_pyegros/app/usecases/reports"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	secret = "integration-secret"
+
+	// startTimeout is how long mongod and redis-server have to accept
+	// connections.
+	startTimeout = 30 * time.Second
+)
+
+var allRoles = []string{models.RoleChannelClient, models.RoleBackoffice, models.RoleAuditor}
+
+// freePort returns a TCP port of the loopback interface that was free when it
+// was checked.
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// startProcess runs the binary until the end of the test. The test is skipped
+// when the binary is not on the PATH.
+func startProcess(t *testing.T, name string, args ...string) *bytes.Buffer {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		t.Skip(name + " is not on the PATH")
+	}
+
+	var output bytes.Buffer
+	cmd := exec.Command(path, args...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	require.NoError(t, cmd.Start())
+
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	})
+	return &output
+}
+
+// waitFor calls ready until it succeeds or startTimeout passes, the output of
+// the process is logged when it never gets ready.
+func waitFor(t *testing.T, name string, output *bytes.Buffer, ready func(ctx context.Context) error) {
+	deadline := time.Now().Add(startTimeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err := ready(ctx)
+		cancel()
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s is not ready: %v\n%s", name, err, output.String())
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func startMongo(t *testing.T) *mongo.Client {
+	dir := t.TempDir()
+	port := freePort(t)
+	output := startProcess(t, "mongod", "--dbpath", dir, "--port", fmt.Sprint(port), "--bind_ip", "127.0.0.1", "--nounixsocket", "--quiet")
+
+	uri := fmt.Sprintf("mongodb://127.0.0.1:%d/?directConnection=true", port)
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(uri).SetServerSelectionTimeout(time.Second))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		client.Disconnect(context.Background())
+	})
+
+	waitFor(t, "mongod", output, func(ctx context.Context) error {
+		return client.Ping(ctx, nil)
+	})
+	return client
+}
+
+func startRedis(t *testing.T) *redis.Client {
+	dir := t.TempDir()
+	port := freePort(t)
+	output := startProcess(t, "redis-server", "--port", fmt.Sprint(port), "--bind", "127.0.0.1", "--dir", dir, "--save", "", "--appendonly", "no")
+
+	rdb := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("127.0.0.1:%d", port)})
+	t.Cleanup(func() {
+		rdb.Close()
+	})
+
+	waitFor(t, "redis-server", output, func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	})
+	return rdb
+}
+
+// newAPI starts mongod and redis-server and serves the API wired as in
+// src/main.go.
+func newAPI(t *testing.T) *httptest.Server {
+	client := startMongo(t)
+	rdb := startRedis(t)
+
+	_, err := database.EnsureIndexes(client, database.IndexRegistry)
+	require.NoError(t, err)
+
+	cfg := &config.Config{
+		JWTSecret:        secret,
+		RateLimitDefault: config.RateLimit{Requests: 1000, Window: time.Minute},
+		RateLimitIP:      config.RateLimit{Requests: 1000, Window: time.Minute},
+		OrderCacheTTL:    orderUseCase.DefaultOrderCacheTTL,
+		ReasonCatalogue:  models.DefaultReasonCatalogue,
+		BulkConcurrency:  orderUseCase.DefaultBulkConcurrency,
+	}
+	authenticator, err := middlewares.NewJWTAuthenticator(cfg)
+	require.NoError(t, err)
+
+	catalogue, err := i18n.NewCatalogue(i18n.Locales(), "es-AR")
+	require.NoError(t, err)
+	spec, err := openapi.LoadSpec()
+	require.NoError(t, err)
+	openAPIHandler, err := openapi.NewHandler(spec)
+	require.NoError(t, err)
+
+	useCaseAPIKeys := apiKeysUseCase.NewUseCase(apiKeysRepository.NewRepository(client))
+	useCaseAudit := auditUseCase.NewUseCase(auditRepository.NewRepository(client))
+	useCaseOrders := orderUseCase.NewUseCase(orderRepository.NewRepository(client), rdb,
+		orderUseCase.WithOrderCacheTTL(cfg.OrderCacheTTL),
+		orderUseCase.WithReasonCatalogue(cfg.ReasonCatalogue),
+		orderUseCase.WithBulkConcurrency(cfg.BulkConcurrency),
+	)
+
+	r := routes.SetUpRoutes(client, authenticator,
+		middlewares.NewAPIKeyAuthenticator(useCaseAPIKeys),
+		middlewares.NewRateLimiter(rdb, cfg),
+		middlewares.NewTrustedProxies(cfg),
+		orderHandler.NewHandler(useCaseOrders, useCaseAudit, catalogue),
+		auditHandler.NewHandler(useCaseAudit),
+		apiKeysHandler.NewHandler(useCaseAPIKeys, useCaseAudit),
+		cacheHandler.NewHandler(cacheUseCase.NewUseCase(cacheRepository.NewRepository(rdb)), useCaseAudit),
+		reportsHandler.NewHandler(reportsUseCase.NewUseCase(reportsRepository.NewRepository(client, rdb))),
+		openAPIHandler,
+	)
+
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server
+}
+
+type apiClient struct {
+	t      *testing.T
+	server *httptest.Server
+	token  string
+}
+
+func newAPIClient(t *testing.T, server *httptest.Server) *apiClient {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   "integration-user",
+		"roles": allRoles,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(secret))
+	require.NoError(t, err)
+
+	return &apiClient{t: t, server: server, token: token}
+}
+
+// do sends the request and decodes a JSON response into out when it is not
+// nil. It returns the status and the raw body.
+func (c *apiClient) do(method string, path string, body interface{}, out interface{}, headers ...string) (int, []byte) {
+	var reader io.Reader
+	if body != nil {
+		content, err := json.Marshal(body)
+		require.NoError(c.t, err)
+		reader = bytes.NewReader(content)
+	}
+
+	req, err := http.NewRequest(method, c.server.URL+"/api/v1"+path, reader)
+	require.NoError(c.t, err)
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(c.t, err)
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(resp.Body)
+	require.NoError(c.t, err)
+	if out != nil && resp.StatusCode == http.StatusOK {
+		require.NoError(c.t, json.Unmarshal(content, out), string(content))
+	}
+	return resp.StatusCode, content
+}
+
+func newOrder(channel models.Channel, purchaseDate string, documentNumber string) models.Order {
+	return models.Order{
+		ExternalReferenceID: models.ExternalReferenceIDs[channel],
+		Channel:             channel,
+		PurchaseDate:        purchaseDate,
+		TotalValue:          2500,
+		Buyer: models.Buyer{
+			FirstName:      "Patricio",
+			LastName:       "Yegros",
+			DocumentNumber: documentNumber,
+			Phone:          "+541112345678",
+		},
+		Products: []models.Product{
+			{Sku: "P001", Name: "Producto A", Price: 1000, Quantity: 2},
+			{Sku: "P002", Name: "Producto B", Price: 500, Quantity: 1},
+		},
+	}
+}
+
+func newEvent(id string, eventType models.EventType, date string) models.Event {
+	return models.Event{Id: id, Type: eventType, Date: date}
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	api := newAPIClient(t, newAPI(t))
+	order := newOrder("Ecommerce", "2024-05-01T14:00:00Z", "87654321")
+
+	var created models.ResponseCreate
+	status, body := api.do(http.MethodPost, "/orders", order, &created)
+	require.Equal(t, http.StatusOK, status, string(body))
+	assert.Equal(t, models.ResponseCreate{OrderID: 1, Status: "Created", UpdatedOn: order.PurchaseDate}, created)
+
+	// The same order again is answered from the idempotency keys of Redis.
+	var repeated models.ResponseCreate
+	status, _ = api.do(http.MethodPost, "/orders", order, &repeated)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, created, repeated)
+
+	// The first read fills the order cache, that the events must invalidate.
+	status, _ = api.do(http.MethodGet, "/orders/1", nil, nil)
+	assert.Equal(t, http.StatusOK, status)
+
+	events := []struct {
+		event     models.Event
+		newStatus models.OrderStatus
+	}{
+		{event: newEvent("event-001", "PaymentReceived", "2024-05-01T15:00:00Z"), newStatus: "PaymentReceived"},
+		{event: newEvent("event-002", "Invoiced", "2024-05-02T10:00:00Z"), newStatus: "Invoiced"},
+		{event: models.Event{
+			Id:     "event-003",
+			Type:   "Returned",
+			Date:   "2024-05-10T10:00:00Z",
+			Reason: &models.EventReason{Code: "DEFECTIVE", Items: []models.ReturnedItem{{Sku: "P001", Quantity: 1}}},
+		}, newStatus: "PartiallyReturned"},
+	}
+	for _, step := range events {
+		var updated models.ResponseUpdate
+		status, body = api.do(http.MethodPost, "/orders/1/events", step.event, &updated)
+		require.Equal(t, http.StatusOK, status, string(body))
+		assert.Equal(t, step.newStatus, updated.NewStatus)
+	}
+
+	var got models.ResponseGet
+	status, _ = api.do(http.MethodGet, "/orders/1?lang=en", nil, &got)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "PartiallyReturned", got.Status)
+	assert.Equal(t, "Partially Returned", got.StatusTranslate)
+	assert.Equal(t, 1000.0, got.RefundedAmount)
+	assert.Equal(t, int64(1), got.Products[0].ReturnedQuantity)
+	assert.Len(t, got.Events, 3)
+	assert.Equal(t, "integration-user", got.Events[0].User)
+
+	var timeline models.ResponseEvents
+	status, _ = api.do(http.MethodGet, "/orders/1/events?pageSize=2", nil, &timeline)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, int64(3), timeline.Total)
+	assert.Len(t, timeline.Events, 2)
+
+	// A repeated event is idempotent, another one with the same ID conflicts.
+	status, _ = api.do(http.MethodPost, "/orders/1/events", events[0].event, nil)
+	assert.Equal(t, http.StatusOK, status)
+	status, body = api.do(http.MethodPost, "/orders/1/events", newEvent("event-004", "Invoiced", "2024-05-11T10:00:00Z"), nil)
+	assert.Equal(t, http.StatusConflict, status)
+	assert.Contains(t, string(body), orderUseCase.ErrInvalidStateTransition.Error())
+
+	status, _ = api.do(http.MethodGet, "/orders/2", nil, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+	status, _ = api.do(http.MethodPost, "/orders/2/events", events[0].event, nil)
+	assert.Equal(t, http.StatusNotFound, status)
+}
+
+func TestSearchOrders(t *testing.T) {
+	api := newAPIClient(t, newAPI(t))
+
+	// Orders 1 to 4, one per channel since the external reference of the
+	// channel makes them idempotent.
+	orders := []models.Order{
+		newOrder("Ecommerce", "2024-05-01T14:00:00Z", "11111111"),
+		newOrder("CallCenter", "2024-05-10T14:00:00Z", "11111111"),
+		newOrder("Store", "2024-06-01T14:00:00Z", "22222222"),
+		newOrder("Affiliate", "2024-06-15T14:00:00Z", "22222222"),
+	}
+	for _, order := range orders {
+		status, body := api.do(http.MethodPost, "/orders", order, nil)
+		require.Equal(t, http.StatusOK, status, string(body))
+	}
+	for _, event := range []models.Event{
+		newEvent("event-001", "PaymentReceived", "2024-05-01T15:00:00Z"),
+		newEvent("event-002", "Invoiced", "2024-05-02T15:00:00Z"),
+	} {
+		status, body := api.do(http.MethodPost, "/orders/1/events", event, nil)
+		require.Equal(t, http.StatusOK, status, string(body))
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		ids   []int64
+	}{
+		{name: "every order", query: "", ids: []int64{1, 2, 3, 4}},
+		{name: "order id", query: "orderId=3", ids: []int64{3}},
+		{name: "status", query: "status=Invoiced", ids: []int64{1}},
+		{name: "document number and channel", query: "documentNumber=11111111&channel=CallCenter", ids: []int64{2}},
+		{name: "document number and status", query: "documentNumber=22222222&status=Invoiced", ids: []int64{}},
+		{name: "date range", query: "createdOnFrom=2024-05-05T00:00:00Z&createdOnTo=2024-06-10T00:00:00Z", ids: []int64{2, 3}},
+		{name: "date range bounds", query: "createdOnFrom=2024-05-10T14:00:00Z&createdOnTo=2024-06-01T14:00:00Z", ids: []int64{2, 3}},
+		{name: "date range without end", query: "createdOnFrom=2024-05-05T00:00:00Z", ids: []int64{1, 2, 3, 4}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var found []models.Order
+			status, body := api.do(http.MethodGet, "/orders/search?"+test.query, nil, &found)
+			require.Equal(t, http.StatusOK, status, string(body))
+
+			ids := []int64{}
+			for _, order := range found {
+				ids = append(ids, order.OrderID)
+			}
+			assert.ElementsMatch(t, test.ids, ids)
+		})
+	}
+
+	t.Run("only the last event", func(t *testing.T) {
+		var found []models.Order
+		status, _ := api.do(http.MethodGet, "/orders/search?orderId=1", nil, &found)
+		require.Equal(t, http.StatusOK, status)
+		require.Len(t, found, 1)
+		assert.Equal(t, []models.Event{{Id: "event-002", Type: "Invoiced", Date: "2024-05-02T15:00:00Z", User: "integration-user"}}, found[0].Events)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		status, body := api.do(http.MethodGet, "/orders/search?documentNumber=22222222", nil, nil, "Accept", "text/csv")
+		require.Equal(t, http.StatusOK, status)
+
+		rows, err := csv.NewReader(strings.NewReader(string(body))).ReadAll()
+		assert.NoError(t, err)
+		// The header and a row per product of the two orders.
+		assert.Len(t, rows, 5)
+	})
+
+	t.Run("buyer orders", func(t *testing.T) {
+		var buyer models.ResponseBuyerOrders
+		status, _ := api.do(http.MethodGet, "/buyers/11111111/orders", nil, &buyer)
+		require.Equal(t, http.StatusOK, status)
+		assert.Equal(t, int64(2), buyer.Summary.OrderCount)
+		assert.Equal(t, 5000.0, buyer.Summary.TotalSpent)
+		assert.Equal(t, "2024-05-01T14:00:00Z", buyer.Summary.FirstPurchaseDate)
+		assert.Equal(t, "2024-05-10T14:00:00Z", buyer.Summary.LastPurchaseDate)
+		require.Len(t, buyer.Orders, 2)
+		assert.Equal(t, int64(2), buyer.Orders[0].OrderID)
+	})
+}
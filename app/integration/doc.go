@@ -0,0 +1,7 @@
+// Package integration runs the HTTP API against real mongod and redis-server
+// processes. The tests are behind the integration build tag:
+//
+//	go test -tags integration ./integration/
+//
+// Both binaries must be on the PATH, otherwise the tests are skipped.
+package integration
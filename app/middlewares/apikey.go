@@ -0,0 +1,57 @@
+package middlewares
+
+import (
+	ports "challenge_pyegros/app/ports/apikeys"
+	"challenge_pyegros/app/utils"
+	"net/http"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+type APIKeyAuthenticator struct {
+	u ports.APIKeysUseCase
+}
+
+func NewAPIKeyAuthenticator(u ports.APIKeysUseCase) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{
+		u: u,
+	}
+}
+
+// Authenticate validates the X-API-Key header and injects the principal of the
+// key into the request context. Requests without the header are passed on
+// untouched so they can be authenticated with a JWT.
+func (a *APIKeyAuthenticator) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(APIKeyHeader)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, err := a.u.Authenticate(key)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			utils.WriteError(w, `{"error": "Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(utils.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// RequireAllChannels rejects the principals bound to a channel, the API keys,
+// on the routes whose data spans every channel as the audit trail and the
+// reports.
+func RequireAllChannels(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := utils.GetPrincipal(r.Context())
+		if ok && principal.Channel != "" {
+			w.Header().Set("Content-Type", "application/json")
+			utils.WriteError(w, `{"error": "The route is not available to API keys bound to a channel"}`, http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
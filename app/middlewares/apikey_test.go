@@ -0,0 +1,82 @@
+package middlewares
+
+import (
+	"challenge_pyegros/app/config"
+	"challenge_pyegros/app/models"
+	"challenge_pyegros/app/ports/apikeys/mocks"
+	apiKeysRepository "challenge_pyegros/app/repositories/apikeys"
+	"challenge_pyegros/app/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	useCase := mocks.NewMockAPIKeysUseCase(ctrl)
+
+	storeClient := &models.Principal{Subject: "apikey:1", Roles: []string{models.RoleChannelClient}, Channel: "Store"}
+	useCase.EXPECT().Authenticate("fk_valid").Return(storeClient, nil)
+	useCase.EXPECT().Authenticate("fk_revoked").Return(nil, apiKeysRepository.ErrAPIKeyRevoked)
+
+	jwtAuthenticator, err := NewJWTAuthenticator(&config.Config{JWTSecret: secret})
+	assert.NoError(t, err)
+
+	var principal *models.Principal
+	handler := NewAPIKeyAuthenticator(useCase).Authenticate(jwtAuthenticator.Authenticate(RequireRoles(models.RoleChannelClient)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = utils.GetPrincipal(r.Context())
+	}))))
+
+	tests := []struct {
+		name      string
+		key       string
+		code      int
+		principal *models.Principal
+	}{
+		{name: "valid key", key: "fk_valid", code: http.StatusOK, principal: storeClient},
+		{name: "revoked key", key: "fk_revoked", code: http.StatusUnauthorized},
+		{name: "without key nor token", code: http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			principal = nil
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", nil)
+			if test.key != "" {
+				req.Header.Set(APIKeyHeader, test.key)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.code, rec.Code)
+			assert.Equal(t, test.principal, principal)
+		})
+	}
+}
+
+func TestRequireAllChannels(t *testing.T) {
+	handler := RequireAllChannels(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name      string
+		principal *models.Principal
+		code      int
+	}{
+		{name: "token", principal: &models.Principal{Subject: "user-001", Roles: []string{models.RoleAuditor}}, code: http.StatusOK},
+		{name: "api key", principal: &models.Principal{Subject: "apikey:1", Roles: []string{models.RoleAuditor}, Channel: "Store"}, code: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/audit", nil)
+			req = req.WithContext(utils.WithPrincipal(req.Context(), test.principal))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, test.code, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package middlewares
+
+import (
+	"challenge_pyegros/app/config"
+	"challenge_pyegros/app/database"
+	"challenge_pyegros/app/models"
+	ports "challenge_pyegros/app/ports/apikeys"
+	"challenge_pyegros/app/utils"
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// The metadata keys of the credentials of a gRPC call, gRPC lowercases them.
+const (
+	AuthorizationMetadata = "authorization"
+	APIKeyMetadata        = "x-api-key"
+)
+
+// GRPCAuthenticator authenticates the gRPC calls as the REST middlewares do,
+// with an API key or else with a JWT, and checks the roles of the method.
+type GRPCAuthenticator struct {
+	jwt   *JWTAuthenticator
+	keys  ports.APIKeysUseCase
+	roles map[string][]string
+}
+
+// NewGRPCAuthenticator lets a call through when the principal has at least
+// one of the roles of its full method, e.g. "/orders.v1.OrdersService/GetOrder".
+// Methods without roles are rejected.
+func NewGRPCAuthenticator(jwt *JWTAuthenticator, keys ports.APIKeysUseCase, roles map[string][]string) *GRPCAuthenticator {
+	return &GRPCAuthenticator{
+		jwt:   jwt,
+		keys:  keys,
+		roles: roles,
+	}
+}
+
+// UnaryInterceptor injects the principal into the context of unary calls.
+func (a *GRPCAuthenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := a.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor injects the principal into the context of streams.
+func (a *GRPCAuthenticator) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := a.authorize(stream.Context(), info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &principalStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+func (a *GRPCAuthenticator) authorize(ctx context.Context, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	var principal *models.Principal
+	var err error
+	if key := firstValue(md, APIKeyMetadata); key != "" {
+		principal, err = a.keys.Authenticate(key)
+	} else {
+		principal, err = a.jwt.parse(firstValue(md, AuthorizationMetadata))
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "Unauthorized")
+	}
+
+	for _, role := range a.roles[method] {
+		if principal.HasRole(role) {
+			return utils.WithPrincipal(ctx, principal), nil
+		}
+	}
+	return nil, status.Error(codes.PermissionDenied, "Forbidden")
+}
+
+func firstValue(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// UnaryLimitIP limits every unary call of an IP as LimitIP does with the
+// requests. It runs before the GRPCAuthenticator, so the calls with invalid
+// credentials are limited too.
+func (l *RateLimiter) UnaryLimitIP() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := l.limitCallIP(ctx, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamLimitIP is UnaryLimitIP for the streams, a stream counts as a call
+// when it is opened.
+func (l *RateLimiter) StreamLimitIP() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.limitCallIP(stream.Context(), stream.SetHeader); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// UnaryLimit limits the unary calls of every client per method as Limit does
+// with the routes. routes maps the full methods to the REST route of the
+// same operation, so both APIs share the limit and the window of the client.
+// It must run after the GRPCAuthenticator, so clients are identified by their
+// principal, or by the IP of the peer otherwise.
+func (l *RateLimiter) UnaryLimit(routes map[string]string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := l.limitCall(ctx, routes, info.FullMethod, func(md metadata.MD) error { return grpc.SetHeader(ctx, md) }); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamLimit is UnaryLimit for the streams, a stream counts as a call when
+// it is opened.
+func (l *RateLimiter) StreamLimit(routes map[string]string) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.limitCall(stream.Context(), routes, info.FullMethod, stream.SetHeader); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// limitCallIP only sends the RateLimit headers of the window of the IP when
+// the call is rejected, otherwise the ones of the window of the client are
+// sent.
+func (l *RateLimiter) limitCallIP(ctx context.Context, setHeader func(metadata.MD) error) error {
+	ip := utils.GetPeerIP(ctx)
+	limit := l.getIPLimit(ip)
+	result := l.allow(ctx, database.RateLimitKey(IPRateLimitRoute, ip), limit)
+	if !result.allowed {
+		return rejectCall(result, limit, setHeader)
+	}
+	return nil
+}
+
+func (l *RateLimiter) limitCall(ctx context.Context, routes map[string]string, method string, setHeader func(metadata.MD) error) error {
+	route, ok := routes[method]
+	if !ok {
+		route = method
+	}
+	client := utils.GetContextActor(ctx)
+	if client == utils.AnonymousActor {
+		client = utils.GetPeerIP(ctx)
+	}
+
+	limit := l.getLimit(route, client)
+	result := l.allow(ctx, database.RateLimitKey(route, client), limit)
+	if !result.allowed {
+		return rejectCall(result, limit, setHeader)
+	}
+	setHeader(metadata.New(result.headers(limit)))
+	return nil
+}
+
+// rejectCall sends the RateLimit headers with Retry-After and answers
+// ResourceExhausted, the code of gRPC for a 429.
+func rejectCall(result rateLimitResult, limit config.RateLimit, setHeader func(metadata.MD) error) error {
+	setHeader(metadata.New(result.headers(limit)))
+	return status.Error(codes.ResourceExhausted, "Too many requests")
+}
+
+// principalStream replaces the context of a stream with the authenticated one.
+type principalStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *principalStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,198 @@
+package middlewares
+
+import (
+	"challenge_pyegros/app/config"
+	"challenge_pyegros/app/models"
+	"challenge_pyegros/app/ports/apikeys/mocks"
+	apiKeysRepository "challenge_pyegros/app/repositories/apikeys"
+	"challenge_pyegros/app/utils"
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	getOrderMethod     = "/orders.v1.OrdersService/GetOrder"
+	searchOrdersMethod = "/orders.v1.OrdersService/SearchOrders"
+	watchOrderMethod   = "/orders.v1.OrdersService/WatchOrder"
+)
+
+var rateLimitRoutes = map[string]string{
+	getOrderMethod:     "get-order",
+	searchOrdersMethod: "search-orders",
+	watchOrderMethod:   "watch-order",
+}
+
+func TestGRPCAuthenticatorUnary(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	useCase := mocks.NewMockAPIKeysUseCase(ctrl)
+
+	storeClient := &models.Principal{Subject: "apikey:1", Roles: []string{models.RoleChannelClient}, Channel: "Store"}
+	useCase.EXPECT().Authenticate("fk_valid").Return(storeClient, nil).AnyTimes()
+	useCase.EXPECT().Authenticate("fk_revoked").Return(nil, apiKeysRepository.ErrAPIKeyRevoked)
+
+	jwtAuthenticator, err := NewJWTAuthenticator(&config.Config{JWTSecret: secret})
+	assert.NoError(t, err)
+	interceptor := NewGRPCAuthenticator(jwtAuthenticator, useCase, map[string][]string{
+		getOrderMethod: {models.RoleChannelClient},
+	}).UnaryInterceptor()
+
+	token := signHS256(t, newClaims("user-1", []string{models.RoleChannelClient}, time.Now().Add(time.Hour)), secret)
+	auditorToken := signHS256(t, newClaims("user-2", []string{models.RoleAuditor}, time.Now().Add(time.Hour)), secret)
+
+	tests := []struct {
+		name      string
+		method    string
+		md        metadata.MD
+		code      codes.Code
+		principal *models.Principal
+	}{
+		{name: "valid key", method: getOrderMethod, md: metadata.Pairs(APIKeyMetadata, "fk_valid"), code: codes.OK, principal: storeClient},
+		{name: "key takes precedence over the token", method: getOrderMethod, md: metadata.Pairs(APIKeyMetadata, "fk_valid", AuthorizationMetadata, "Bearer "+auditorToken), code: codes.OK, principal: storeClient},
+		{name: "valid token", method: getOrderMethod, md: metadata.Pairs(AuthorizationMetadata, "Bearer "+token), code: codes.OK, principal: &models.Principal{Subject: "user-1", Roles: []string{models.RoleChannelClient}}},
+		{name: "revoked key", method: getOrderMethod, md: metadata.Pairs(APIKeyMetadata, "fk_revoked"), code: codes.Unauthenticated},
+		{name: "without key nor token", method: getOrderMethod, md: metadata.MD{}, code: codes.Unauthenticated},
+		{name: "role not allowed", method: getOrderMethod, md: metadata.Pairs(AuthorizationMetadata, "Bearer "+auditorToken), code: codes.PermissionDenied},
+		{name: "method without roles", method: "/orders.v1.OrdersService/Unknown", md: metadata.Pairs(APIKeyMetadata, "fk_valid"), code: codes.PermissionDenied},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var principal *models.Principal
+			ctx := metadata.NewIncomingContext(context.Background(), test.md)
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: test.method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				principal, _ = utils.GetPrincipal(ctx)
+				return nil, nil
+			})
+
+			assert.Equal(t, test.code, status.Code(err))
+			assert.Equal(t, test.principal, principal)
+		})
+	}
+}
+
+func peerContext(ip string, principal *models.Principal) context.Context {
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: 12345}})
+	if principal != nil {
+		ctx = utils.WithPrincipal(ctx, principal)
+	}
+	return ctx
+}
+
+func TestGRPCRateLimitUnary(t *testing.T) {
+	limiter, s, _ := newTestRateLimiter(t)
+	interceptor := limiter.UnaryLimit(rateLimitRoutes)
+
+	call := func(method string, ip string, principal *models.Principal) codes.Code {
+		_, err := interceptor(peerContext(ip, principal), nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+		return status.Code(err)
+	}
+
+	// Without a principal the client is the IP of the peer.
+	assert.Equal(t, codes.OK, call(getOrderMethod, "10.0.0.1", nil))
+	assert.Equal(t, codes.OK, call(getOrderMethod, "10.0.0.1", nil))
+	assert.Equal(t, codes.ResourceExhausted, call(getOrderMethod, "10.0.0.1", nil))
+	assert.Equal(t, codes.OK, call(watchOrderMethod, "10.0.0.1", nil))
+	assert.True(t, s.Exists("orders-api:v2:ratelimit:get-order:10.0.0.1"))
+
+	// The principal is the client whatever its IP, with its own limit.
+	store := &models.Principal{Subject: "apikey:store", Roles: []string{models.RoleChannelClient}}
+	for _, ip := range []string{"10.0.0.2", "10.0.0.3", "10.0.0.4"} {
+		assert.Equal(t, codes.OK, call(getOrderMethod, ip, store))
+	}
+	assert.Equal(t, codes.ResourceExhausted, call(getOrderMethod, "10.0.0.5", store))
+
+	// The method shares the window of its REST route.
+	assert.Equal(t, http.StatusOK, request(limiter, "search-orders", "10.0.0.6", nil).Code)
+	assert.Equal(t, codes.ResourceExhausted, call(searchOrdersMethod, "10.0.0.6", nil))
+}
+
+// headerStream records the header metadata sent by the interceptors.
+type headerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (s *headerStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestGRPCRateLimitStream(t *testing.T) {
+	limiter, _, now := newTestRateLimiter(t)
+	interceptor := limiter.StreamLimit(rateLimitRoutes)
+
+	open := func() (codes.Code, metadata.MD) {
+		stream := &headerStream{ctx: peerContext("10.0.0.1", nil)}
+		err := interceptor(nil, stream, &grpc.StreamServerInfo{FullMethod: watchOrderMethod}, func(srv interface{}, stream grpc.ServerStream) error {
+			return nil
+		})
+		return status.Code(err), stream.header
+	}
+
+	code, header := open()
+	assert.Equal(t, codes.OK, code)
+	assert.Equal(t, []string{"2"}, header.Get("ratelimit-limit"))
+	assert.Equal(t, []string{"1"}, header.Get("ratelimit-remaining"))
+
+	*now = now.Add(20 * time.Second)
+	code, _ = open()
+	assert.Equal(t, codes.OK, code)
+
+	code, header = open()
+	assert.Equal(t, codes.ResourceExhausted, code)
+	assert.Equal(t, []string{"40"}, header.Get("retry-after"))
+}
+
+func TestGRPCRateLimitIPBeforeAuthentication(t *testing.T) {
+	limiter, _, _ := newTestRateLimiter(t)
+	unary := limiter.UnaryLimitIP()
+	stream := limiter.StreamLimitIP()
+
+	call := func(ip string) codes.Code {
+		_, err := unary(peerContext(ip, nil), nil, &grpc.UnaryServerInfo{FullMethod: getOrderMethod}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+		return status.Code(err)
+	}
+	open := func(ip string) (codes.Code, metadata.MD) {
+		s := &headerStream{ctx: peerContext(ip, nil)}
+		err := stream(nil, s, &grpc.StreamServerInfo{FullMethod: watchOrderMethod}, func(srv interface{}, stream grpc.ServerStream) error {
+			return nil
+		})
+		return status.Code(err), s.header
+	}
+
+	// The calls and streams of an IP share its window, whatever the method.
+	assert.Equal(t, codes.OK, call("10.0.0.1"))
+	code, header := open("10.0.0.1")
+	assert.Equal(t, codes.OK, code)
+	assert.Empty(t, header)
+	assert.Equal(t, codes.ResourceExhausted, call("10.0.0.1"))
+	code, header = open("10.0.0.1")
+	assert.Equal(t, codes.ResourceExhausted, code)
+	assert.Equal(t, []string{"0"}, header.Get("ratelimit-remaining"))
+
+	assert.Equal(t, codes.OK, call("10.0.0.2"))
+
+	// RateLimitClients applies to the IPs too.
+	assert.Equal(t, codes.OK, call("10.0.0.9"))
+	assert.Equal(t, codes.ResourceExhausted, call("10.0.0.9"))
+}
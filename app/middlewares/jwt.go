@@ -0,0 +1,206 @@
+package middlewares
+
+import (
+	"challenge_pyegros/app/config"
+	"challenge_pyegros/app/models"
+	"challenge_pyegros/app/utils"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoVerificationKeys = errors.New("No JWT secret or JWKS file configured")
+	ErrMissingToken       = errors.New("Missing bearer token")
+	ErrUnknownKey         = errors.New("Unknown signing key")
+	ErrMissingSubject     = errors.New("Token has no subject")
+)
+
+type claims struct {
+	Roles []string `json:"roles"`
+	jwt.RegisteredClaims
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// JWTAuthenticator validates HS256 tokens with a shared secret and RS256
+// tokens with the public keys of a JWKS file.
+type JWTAuthenticator struct {
+	secret  []byte
+	keys    map[string]*rsa.PublicKey
+	options []jwt.ParserOption
+}
+
+func NewJWTAuthenticator(cfg *config.Config) (*JWTAuthenticator, error) {
+	authenticator := &JWTAuthenticator{
+		secret: []byte(cfg.JWTSecret),
+		keys:   map[string]*rsa.PublicKey{},
+	}
+
+	if cfg.JWKSFile != "" {
+		keys, err := loadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		authenticator.keys = keys
+	}
+
+	if len(authenticator.secret) == 0 && len(authenticator.keys) == 0 {
+		return nil, ErrNoVerificationKeys
+	}
+
+	authenticator.options = []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	}
+	if cfg.JWTIssuer != "" {
+		authenticator.options = append(authenticator.options, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	if cfg.JWTAudience != "" {
+		authenticator.options = append(authenticator.options, jwt.WithAudience(cfg.JWTAudience))
+	}
+
+	return authenticator, nil
+}
+
+// Authenticate validates the bearer token of the request and injects the
+// principal into the request context. Requests already authenticated by a
+// previous middleware, e.g. with an API key, are passed on untouched.
+func (a *JWTAuthenticator) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := utils.GetPrincipal(r.Context()); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, err := a.parse(r.Header.Get("Authorization"))
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			utils.WriteError(w, `{"error": "Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(utils.WithPrincipal(r.Context(), principal)))
+	})
+}
+
+// RequireRoles lets the request through when the principal has at least one
+// of the roles.
+func RequireRoles(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := utils.GetPrincipal(r.Context())
+			if !ok {
+				w.Header().Set("Content-Type", "application/json")
+				utils.WriteError(w, `{"error": "Unauthorized"}`, http.StatusUnauthorized)
+				return
+			}
+
+			for _, role := range roles {
+				if principal.HasRole(role) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			utils.WriteError(w, `{"error": "Forbidden"}`, http.StatusForbidden)
+		})
+	}
+}
+
+func (a *JWTAuthenticator) parse(header string) (*models.Principal, error) {
+	tokenString, found := strings.CutPrefix(header, "Bearer ")
+	if !found || tokenString == "" {
+		return nil, ErrMissingToken
+	}
+
+	var tokenClaims claims
+	_, err := jwt.ParseWithClaims(tokenString, &tokenClaims, a.keyFunc, a.options...)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokenClaims.Subject == "" {
+		return nil, ErrMissingSubject
+	}
+
+	return &models.Principal{
+		Subject: tokenClaims.Subject,
+		Roles:   tokenClaims.Roles,
+	}, nil
+}
+
+func (a *JWTAuthenticator) keyFunc(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if len(a.secret) == 0 {
+			return nil, ErrUnknownKey
+		}
+		return a.secret, nil
+	case *jwt.SigningMethodRSA:
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := a.keys[kid]; ok {
+			return key, nil
+		}
+		if kid == "" && len(a.keys) == 1 {
+			for _, key := range a.keys {
+				return key, nil
+			}
+		}
+		return nil, ErrUnknownKey
+	}
+	return nil, ErrUnknownKey
+}
+
+func loadJWKS(path string) (map[string]*rsa.PublicKey, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var set jsonWebKeySet
+	if err = json.Unmarshal(content, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return nil, err
+		}
+
+		keys[key.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,148 @@
+package middlewares
+
+import (
+	"challenge_pyegros/app/config"
+	"challenge_pyegros/app/models"
+	"challenge_pyegros/app/utils"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+const secret = "test-secret"
+
+func newClaims(subject string, roles []string, expiresAt time.Time) claims {
+	return claims{
+		Roles: roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   subject,
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+}
+
+func signHS256(t *testing.T, tokenClaims claims, key string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims).SignedString([]byte(key))
+	assert.NoError(t, err)
+	return token
+}
+
+func writeJWKS(t *testing.T, kid string, key *rsa.PublicKey) string {
+	set := jsonWebKeySet{Keys: []jsonWebKey{{
+		Kty: "RSA",
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	content, err := json.Marshal(set)
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(path, content, 0o600))
+	return path
+}
+
+func serve(authenticator *JWTAuthenticator, authorization string, roles ...string) (*httptest.ResponseRecorder, *models.Principal) {
+	var principal *models.Principal
+	handler := authenticator.Authenticate(RequireRoles(roles...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ = utils.GetPrincipal(r.Context())
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/orders/1", nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec, principal
+}
+
+func TestNewJWTAuthenticatorWithoutKeys(t *testing.T) {
+	authenticator, err := NewJWTAuthenticator(&config.Config{})
+	assert.Nil(t, authenticator)
+	assert.Equal(t, ErrNoVerificationKeys, err)
+}
+
+func TestAuthenticateHS256(t *testing.T) {
+	authenticator, err := NewJWTAuthenticator(&config.Config{JWTSecret: secret})
+	assert.NoError(t, err)
+
+	token := signHS256(t, newClaims("adminUser123", []string{models.RoleBackoffice}, time.Now().Add(time.Hour)), secret)
+	rec, principal := serve(authenticator, "Bearer "+token, models.RoleBackoffice)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, &models.Principal{Subject: "adminUser123", Roles: []string{models.RoleBackoffice}}, principal)
+}
+
+func TestAuthenticateRejectsInvalidTokens(t *testing.T) {
+	authenticator, err := NewJWTAuthenticator(&config.Config{JWTSecret: secret})
+	assert.NoError(t, err)
+
+	roles := []string{models.RoleBackoffice}
+	tests := map[string]string{
+		"missing header":   "",
+		"not bearer":       "Basic abc",
+		"wrong signature":  "Bearer " + signHS256(t, newClaims("user", roles, time.Now().Add(time.Hour)), "other-secret"),
+		"expired":          "Bearer " + signHS256(t, newClaims("user", roles, time.Now().Add(-time.Hour)), secret),
+		"without subject":  "Bearer " + signHS256(t, newClaims("", roles, time.Now().Add(time.Hour)), secret),
+		"without expiring": "Bearer " + signHS256(t, claims{Roles: roles, RegisteredClaims: jwt.RegisteredClaims{Subject: "user"}}, secret),
+	}
+
+	for name, authorization := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec, principal := serve(authenticator, authorization, models.RoleBackoffice)
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Nil(t, principal)
+		})
+	}
+}
+
+func TestAuthenticateRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	authenticator, err := NewJWTAuthenticator(&config.Config{JWKSFile: writeJWKS(t, "key-1", &key.PublicKey)})
+	assert.NoError(t, err)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, newClaims("auditor1", []string{models.RoleAuditor}, time.Now().Add(time.Hour)))
+	token.Header["kid"] = "key-1"
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+
+	rec, principal := serve(authenticator, "Bearer "+signed, models.RoleAuditor)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "auditor1", principal.Subject)
+
+	token.Header["kid"] = "key-2"
+	signed, err = token.SignedString(key)
+	assert.NoError(t, err)
+
+	rec, _ = serve(authenticator, "Bearer "+signed, models.RoleAuditor)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// HS256 tokens are rejected when no secret is configured.
+	rec, _ = serve(authenticator, "Bearer "+signHS256(t, newClaims("user", []string{models.RoleAuditor}, time.Now().Add(time.Hour)), ""), models.RoleAuditor)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestRequireRolesForbidden(t *testing.T) {
+	authenticator, err := NewJWTAuthenticator(&config.Config{JWTSecret: secret})
+	assert.NoError(t, err)
+
+	token := signHS256(t, newClaims("auditor1", []string{models.RoleAuditor}, time.Now().Add(time.Hour)), secret)
+	rec, principal := serve(authenticator, "Bearer "+token, models.RoleBackoffice)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Nil(t, principal)
+}
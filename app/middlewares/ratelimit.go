@@ -0,0 +1,250 @@
+package middlewares
+
+import (
+	"challenge_pyegros/app/config"
+	"challenge_pyegros/app/database"
+	"challenge_pyegros/app/utils"
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript keeps a sorted set per client and route with the time of
+// every accepted request. It drops the requests older than the window, accepts
+// the request when there is room for it and returns {allowed, count, resetMs}.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local reset = window
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+return {allowed, count, reset}
+`)
+
+// IPRateLimitRoute is the name of the window of LimitIP in the keys of the
+// rate limit.
+const IPRateLimitRoute = "ip"
+
+type rateLimitResult struct {
+	allowed bool
+	count   int
+	reset   time.Duration
+}
+
+// RateLimiter limits the requests of every client per route with a sliding
+// window stored in Redis. When Redis is not available every instance falls
+// back to limiting in memory.
+type RateLimiter struct {
+	rdb      *redis.Client
+	cfg      *config.Config
+	fallback *memoryLimiter
+	now      func() time.Time
+}
+
+func NewRateLimiter(rdb *redis.Client, cfg *config.Config) *RateLimiter {
+	return &RateLimiter{
+		rdb:      rdb,
+		cfg:      cfg,
+		fallback: newMemoryLimiter(),
+		now:      time.Now,
+	}
+}
+
+// Limit returns the middleware that limits the requests of the route. It must
+// run after the authentication, so clients are identified by their principal,
+// or by their IP when the route is public.
+func (l *RateLimiter) Limit(route string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := getClient(r)
+			l.serve(w, r, next, database.RateLimitKey(route, client), l.getLimit(route, client))
+		})
+	}
+}
+
+// LimitIP returns the middleware that limits every request of an IP with
+// RateLimitIP. It runs before the authentication, so the requests with
+// invalid credentials are limited before they reach the API keys.
+func (l *RateLimiter) LimitIP() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := utils.GetSourceIP(r)
+			l.serve(w, r, next, database.RateLimitKey(IPRateLimitRoute, ip), l.getIPLimit(ip))
+		})
+	}
+}
+
+// serve sets the rate limit headers and runs next when the request is allowed.
+func (l *RateLimiter) serve(w http.ResponseWriter, r *http.Request, next http.Handler, key string, limit config.RateLimit) {
+	result := l.allow(r.Context(), key, limit)
+	for header, value := range result.headers(limit) {
+		w.Header().Set(header, value)
+	}
+
+	if !result.allowed {
+		w.Header().Set("Content-Type", "application/json")
+		utils.WriteError(w, `{"error": "Too many requests"}`, http.StatusTooManyRequests)
+		return
+	}
+
+	next.ServeHTTP(w, r)
+}
+
+// headers are the RateLimit headers of the result, with Retry-After when the
+// request was rejected.
+func (r rateLimitResult) headers(limit config.RateLimit) map[string]string {
+	resetSeconds := strconv.Itoa(int(math.Ceil(r.reset.Seconds())))
+	remaining := limit.Requests - r.count
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	headers := map[string]string{
+		"RateLimit-Limit":     strconv.Itoa(limit.Requests),
+		"RateLimit-Remaining": strconv.Itoa(remaining),
+		"RateLimit-Reset":     resetSeconds,
+	}
+	if !r.allowed {
+		headers["Retry-After"] = resetSeconds
+	}
+	return headers
+}
+
+func (l *RateLimiter) getLimit(route string, client string) config.RateLimit {
+	if limit, ok := l.cfg.RateLimitClients[client]; ok {
+		return limit
+	}
+	if limit, ok := l.cfg.RateLimitRoutes[route]; ok {
+		return limit
+	}
+	return l.cfg.RateLimitDefault
+}
+
+// getIPLimit is the limit of every request of an IP, RateLimitClients can
+// raise it for an IP as for a client.
+func (l *RateLimiter) getIPLimit(ip string) config.RateLimit {
+	if limit, ok := l.cfg.RateLimitClients[ip]; ok {
+		return limit
+	}
+	return l.cfg.RateLimitIP
+}
+
+func (l *RateLimiter) allow(ctx context.Context, key string, limit config.RateLimit) rateLimitResult {
+	now := l.now()
+
+	if l.rdb != nil {
+		result, err := l.allowRedis(ctx, key, limit, now)
+		if err == nil {
+			return result
+		}
+		fmt.Println("Error limiting with Redis, limiting in memory: " + err.Error())
+	}
+
+	return l.fallback.allow(key, limit, now)
+}
+
+func (l *RateLimiter) allowRedis(ctx context.Context, key string, limit config.RateLimit, now time.Time) (rateLimitResult, error) {
+	// The random suffix keeps apart the requests accepted in the same
+	// nanosecond, by this or other instances.
+	member := strconv.FormatInt(now.UnixNano(), 10) + "-" + strconv.FormatUint(rand.Uint64(), 36)
+	values, err := slidingWindowScript.Run(ctx, l.rdb, []string{key},
+		now.UnixMilli(), limit.Window.Milliseconds(), limit.Requests, member).Int64Slice()
+	if err != nil {
+		return rateLimitResult{}, err
+	}
+
+	return rateLimitResult{
+		allowed: values[0] == 1,
+		count:   int(values[1]),
+		reset:   time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
+
+func getClient(r *http.Request) string {
+	if principal, ok := utils.GetPrincipal(r.Context()); ok {
+		return principal.Subject
+	}
+	return utils.GetSourceIP(r)
+}
+
+// memoryLimiter is the in-process sliding window used while Redis is down.
+type memoryLimiter struct {
+	mu      sync.Mutex
+	windows map[string]*memoryWindow
+}
+
+type memoryWindow struct {
+	requests []time.Time
+	expires  time.Time
+}
+
+// maxMemoryWindows is the amount of windows kept before the expired ones are
+// swept, so clients that stopped calling do not pile up.
+const maxMemoryWindows = 10000
+
+func newMemoryLimiter() *memoryLimiter {
+	return &memoryLimiter{
+		windows: map[string]*memoryWindow{},
+	}
+}
+
+func (m *memoryLimiter) allow(key string, limit config.RateLimit, now time.Time) rateLimitResult {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.windows) > maxMemoryWindows {
+		for windowKey, window := range m.windows {
+			if now.After(window.expires) {
+				delete(m.windows, windowKey)
+			}
+		}
+	}
+
+	window, ok := m.windows[key]
+	if !ok {
+		window = &memoryWindow{}
+		m.windows[key] = window
+	}
+
+	var requests []time.Time
+	for _, request := range window.requests {
+		if now.Sub(request) < limit.Window {
+			requests = append(requests, request)
+		}
+	}
+
+	allowed := len(requests) < limit.Requests
+	if allowed {
+		requests = append(requests, now)
+	}
+	window.requests = requests
+	window.expires = requests[len(requests)-1].Add(limit.Window)
+
+	return rateLimitResult{
+		allowed: allowed,
+		count:   len(requests),
+		reset:   requests[0].Add(limit.Window).Sub(now),
+	}
+}
@@ -0,0 +1,148 @@
+package middlewares
+
+import (
+	"challenge_pyegros/app/config"
+	"challenge_pyegros/app/models"
+	"challenge_pyegros/app/ports/apikeys/mocks"
+	apiKeysRepository "challenge_pyegros/app/repositories/apikeys"
+	"challenge_pyegros/app/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+var rateLimitConfig = &config.Config{
+	RateLimitDefault: config.RateLimit{Requests: 2, Window: time.Minute},
+	RateLimitRoutes: map[string]config.RateLimit{
+		"search-orders": {Requests: 1, Window: time.Minute},
+	},
+	RateLimitClients: map[string]config.RateLimit{
+		"apikey:store": {Requests: 3, Window: time.Minute},
+		"10.0.0.9":     {Requests: 1, Window: time.Minute},
+	},
+	RateLimitIP: config.RateLimit{Requests: 2, Window: time.Minute},
+}
+
+func newTestRateLimiter(t *testing.T) (*RateLimiter, *miniredis.Miniredis, *time.Time) {
+	s := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: s.Addr()})
+
+	now := time.Date(2024, 5, 1, 14, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(rdb, rateLimitConfig)
+	limiter.now = func() time.Time { return now }
+
+	return limiter, s, &now
+}
+
+func request(limiter *RateLimiter, route string, ip string, principal *models.Principal) *httptest.ResponseRecorder {
+	handler := limiter.Limit(route)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/orders/1", nil)
+	req.RemoteAddr = ip + ":12345"
+	if principal != nil {
+		req = req.WithContext(utils.WithPrincipal(req.Context(), principal))
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRateLimitRedis(t *testing.T) {
+	limiter, s, now := newTestRateLimiter(t)
+
+	rec := request(limiter, "get-order", "10.0.0.1", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", rec.Header().Get("RateLimit-Reset"))
+
+	*now = now.Add(20 * time.Second)
+	rec = request(limiter, "get-order", "10.0.0.1", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+
+	rec = request(limiter, "get-order", "10.0.0.1", nil)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "40", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+
+	// Other clients and routes have their own window.
+	assert.Equal(t, http.StatusOK, request(limiter, "get-order", "10.0.0.2", nil).Code)
+	assert.Equal(t, http.StatusOK, request(limiter, "get-events", "10.0.0.1", nil).Code)
+
+	// The first request leaves the window after a minute.
+	*now = now.Add(41 * time.Second)
+	assert.Equal(t, http.StatusOK, request(limiter, "get-order", "10.0.0.1", nil).Code)
+
+	assert.True(t, s.Exists("orders-api:v2:ratelimit:get-order:10.0.0.1"))
+}
+
+func TestRateLimitPerRouteAndClient(t *testing.T) {
+	limiter, _, _ := newTestRateLimiter(t)
+
+	assert.Equal(t, http.StatusOK, request(limiter, "search-orders", "10.0.0.1", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, request(limiter, "search-orders", "10.0.0.1", nil).Code)
+
+	store := &models.Principal{Subject: "apikey:store", Channel: "Store"}
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, request(limiter, "search-orders", "10.0.0.1", store).Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, request(limiter, "search-orders", "10.0.0.1", store).Code)
+}
+
+func TestRateLimitFallsBackToMemory(t *testing.T) {
+	limiter, s, now := newTestRateLimiter(t)
+	s.Close()
+
+	assert.Equal(t, http.StatusOK, request(limiter, "get-order", "10.0.0.1", nil).Code)
+	assert.Equal(t, http.StatusOK, request(limiter, "get-order", "10.0.0.1", nil).Code)
+
+	rec := request(limiter, "get-order", "10.0.0.1", nil)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+	*now = now.Add(time.Minute)
+	assert.Equal(t, http.StatusOK, request(limiter, "get-order", "10.0.0.1", nil).Code)
+}
+
+func TestRateLimitWithoutRedis(t *testing.T) {
+	limiter := NewRateLimiter(nil, rateLimitConfig)
+
+	assert.Equal(t, http.StatusOK, request(limiter, "search-orders", "10.0.0.1", nil).Code)
+	assert.Equal(t, http.StatusTooManyRequests, request(limiter, "search-orders", "10.0.0.1", nil).Code)
+}
+
+func TestRateLimitIPBeforeAuthentication(t *testing.T) {
+	limiter, s, _ := newTestRateLimiter(t)
+
+	// Only the requests let through by the IP limit reach the API keys.
+	ctrl := gomock.NewController(t)
+	useCase := mocks.NewMockAPIKeysUseCase(ctrl)
+	useCase.EXPECT().Authenticate("fk_invalid").Return(nil, apiKeysRepository.ErrInvalidAPIKey).Times(2)
+
+	handler := limiter.LimitIP()(NewAPIKeyAuthenticator(useCase).Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	send := func(ip string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/orders/1", nil)
+		req.RemoteAddr = ip + ":12345"
+		req.Header.Set(APIKeyHeader, "fk_invalid")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send("10.0.0.1"))
+	assert.Equal(t, http.StatusUnauthorized, send("10.0.0.1"))
+	assert.Equal(t, http.StatusTooManyRequests, send("10.0.0.1"))
+	assert.True(t, s.Exists("orders-api:v2:ratelimit:ip:10.0.0.1"))
+
+	// The limit of a client keyed by IP takes precedence.
+	useCase.EXPECT().Authenticate("fk_invalid").Return(nil, apiKeysRepository.ErrInvalidAPIKey)
+	assert.Equal(t, http.StatusUnauthorized, send("10.0.0.9"))
+	assert.Equal(t, http.StatusTooManyRequests, send("10.0.0.9"))
+}
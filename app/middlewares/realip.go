@@ -0,0 +1,70 @@
+package middlewares
+
+import (
+	"challenge_pyegros/app/config"
+	"challenge_pyegros/app/utils"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TrustedProxies sets the source IP of the requests forwarded by the proxies
+// in front of the service. The X-Forwarded-For and X-Real-IP headers of any
+// other request are ignored, so clients cannot pick the IP they are limited
+// and audited by.
+type TrustedProxies struct {
+	networks []*net.IPNet
+}
+
+func NewTrustedProxies(cfg *config.Config) *TrustedProxies {
+	return &TrustedProxies{networks: cfg.TrustedProxies}
+}
+
+// RealIP replaces the remote address of the requests coming from a trusted
+// proxy with the client IP. It is the last address of X-Forwarded-For that is
+// not a trusted proxy, as the addresses before it could be set by the client,
+// or else X-Real-IP.
+func (p *TrustedProxies) RealIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p.trusted(utils.GetSourceIP(r)) {
+			if ip := p.clientIP(r); ip != "" {
+				r.RemoteAddr = ip
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (p *TrustedProxies) clientIP(r *http.Request) string {
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		addresses := strings.Split(forwarded, ",")
+		for i := len(addresses) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(addresses[i])
+			if net.ParseIP(ip) == nil {
+				return ""
+			}
+			if i == 0 || !p.trusted(ip) {
+				return ip
+			}
+		}
+	}
+
+	ip := strings.TrimSpace(r.Header.Get("X-Real-IP"))
+	if net.ParseIP(ip) == nil {
+		return ""
+	}
+	return ip
+}
+
+func (p *TrustedProxies) trusted(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
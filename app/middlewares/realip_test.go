@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"challenge_pyegros/app/config"
+	"challenge_pyegros/app/utils"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrustedProxiesRealIP(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+	assert.NoError(t, err)
+	trusted := NewTrustedProxies(&config.Config{TrustedProxies: []*net.IPNet{proxies}})
+
+	var sourceIP string
+	handler := trusted.RealIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sourceIP = utils.GetSourceIP(r)
+	}))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		sourceIP   string
+	}{
+		{name: "direct request", remoteAddr: "203.0.113.7:1234", sourceIP: "203.0.113.7"},
+		{name: "headers of an untrusted client", remoteAddr: "203.0.113.7:1234", headers: map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Real-IP": "198.51.100.1"}, sourceIP: "203.0.113.7"},
+		{name: "forwarded by a proxy", remoteAddr: "10.0.0.2:1234", headers: map[string]string{"X-Forwarded-For": "198.51.100.1"}, sourceIP: "198.51.100.1"},
+		{name: "spoofed by the client before the proxies", remoteAddr: "10.0.0.2:1234", headers: map[string]string{"X-Forwarded-For": "192.0.2.1, 198.51.100.1, 10.0.0.3"}, sourceIP: "198.51.100.1"},
+		{name: "real ip of a proxy", remoteAddr: "10.0.0.2:1234", headers: map[string]string{"X-Real-IP": "198.51.100.1"}, sourceIP: "198.51.100.1"},
+		{name: "invalid header of a proxy", remoteAddr: "10.0.0.2:1234", headers: map[string]string{"X-Forwarded-For": "unknown"}, sourceIP: "10.0.0.2"},
+		{name: "proxy without headers", remoteAddr: "10.0.0.2:1234", sourceIP: "10.0.0.2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/orders/1", nil)
+			req.RemoteAddr = test.remoteAddr
+			for header, value := range test.headers {
+				req.Header.Set(header, value)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, test.sourceIP, sourceIP)
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DefaultLockTTL is how long the lock of an instance is honoured, so a
+	// crashed instance does not block the migrations forever.
+	DefaultLockTTL = 10 * time.Minute
+
+	StatusApplied = "applied"
+	StatusPending = "pending"
+)
+
+// lockID is the _id of the document of schema_migrations_lock held, with
+// its owner and expiresAt, while migrating.
+const lockID = "lock"
+
+var (
+	ErrLocked           = errors.New("Another instance is running the migrations")
+	ErrLockLost         = errors.New("The migrations lock expired and another instance took it")
+	ErrDuplicateVersion = errors.New("Two migrations have the same version")
+	ErrInvalidMigration = errors.New("A migration needs a version greater than zero, a name and an Up function")
+)
+
+// Migration changes the documents of the database once. Up must be
+// idempotent: when an instance dies after Up and before the migration is
+// recorded, it runs again.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(db *mongo.Database) error
+	// Count, when set, returns how many documents Up would change, for the
+	// dry-run mode.
+	Count func(db *mongo.Database) (int64, error)
+}
+
+type Result struct {
+	Version   int64  `json:"version"`
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Documents *int64 `json:"documents,omitempty"`
+}
+
+func (r Result) String() string {
+	line := fmt.Sprintf("%04d_%s: %s", r.Version, r.Name, r.Status)
+	if r.Documents != nil {
+		line += " (" + strconv.FormatInt(*r.Documents, 10) + " documents)"
+	}
+	return line
+}
+
+// record is a document of schema_migrations.
+type record struct {
+	Version   int64     `bson:"_id"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"appliedAt"`
+}
+
+type Migrator struct {
+	db         *mongo.Client
+	database   string
+	migrations []Migration
+	owner      string
+	lockTTL    time.Duration
+	now        func() time.Time
+}
+
+type Option func(*Migrator)
+
+// WithLockTTL sets how long the lock is honoured.
+func WithLockTTL(ttl time.Duration) Option {
+	return func(m *Migrator) {
+		m.lockTTL = ttl
+	}
+}
+
+// WithDatabase sets the database migrated, orders by default.
+func WithDatabase(database string) Option {
+	return func(m *Migrator) {
+		m.database = database
+	}
+}
+
+// NewMigrator sorts the migrations by version and checks they are valid.
+func NewMigrator(client *mongo.Client, migrations []Migration, options ...Option) (*Migrator, error) {
+	sorted := append([]Migration{}, migrations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	for i, migration := range sorted {
+		if migration.Version <= 0 || migration.Name == "" || migration.Up == nil {
+			return nil, ErrInvalidMigration
+		}
+		if i > 0 && sorted[i-1].Version == migration.Version {
+			return nil, ErrDuplicateVersion
+		}
+	}
+
+	hostname, _ := os.Hostname()
+	migrator := &Migrator{
+		db:         client,
+		database:   "orders",
+		migrations: sorted,
+		owner:      hostname + "-" + strconv.Itoa(os.Getpid()),
+		lockTTL:    DefaultLockTTL,
+		now:        time.Now,
+	}
+	for _, option := range options {
+		option(migrator)
+	}
+	return migrator, nil
+}
+
+// Up runs the pending migrations in order, recording each one in
+// schema_migrations after it succeeds. It stops at the first error, returning
+// the migrations applied until then. Only the instance holding the lock
+// migrates, any other gets ErrLocked. The lock is renewed until Up returns,
+// and when it is lost anyway no other migration is started.
+func (m *Migrator) Up() ([]Result, error) {
+	err := m.acquireLock()
+	if err != nil {
+		return nil, err
+	}
+	defer m.releaseLock()
+
+	pending, err := m.pending()
+	if err != nil {
+		return nil, err
+	}
+
+	heartbeat := m.holdLock()
+	defer heartbeat.stop()
+
+	db := m.db.Database(m.database)
+	results := []Result{}
+	for _, migration := range pending {
+		if heartbeat.lost.Load() {
+			return results, ErrLockLost
+		}
+
+		err = migration.Up(db)
+		if err != nil {
+			return results, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		_, err = db.Collection("schema_migrations").InsertOne(context.TODO(), record{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			AppliedAt: m.now().UTC(),
+		})
+		if err != nil {
+			return results, err
+		}
+		results = append(results, Result{Version: migration.Version, Name: migration.Name, Status: StatusApplied})
+	}
+
+	return results, nil
+}
+
+// DryRun returns the pending migrations, with the amount of documents each
+// one would change when it has a Count, without changing anything.
+func (m *Migrator) DryRun() ([]Result, error) {
+	pending, err := m.pending()
+	if err != nil {
+		return nil, err
+	}
+
+	db := m.db.Database(m.database)
+	results := []Result{}
+	for _, migration := range pending {
+		result := Result{Version: migration.Version, Name: migration.Name, Status: StatusPending}
+		if migration.Count != nil {
+			count, err := migration.Count(db)
+			if err != nil {
+				return nil, err
+			}
+			result.Documents = &count
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (m *Migrator) pending() ([]Migration, error) {
+	cursor, err := m.db.Database(m.database).Collection("schema_migrations").Find(context.TODO(), bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var records []record
+	if err = cursor.All(context.TODO(), &records); err != nil {
+		return nil, err
+	}
+
+	applied := map[int64]bool{}
+	for _, record := range records {
+		applied[record.Version] = true
+	}
+
+	pending := []Migration{}
+	for _, migration := range m.migrations {
+		if !applied[migration.Version] {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// acquireLock takes the lock when nobody holds it or it expired. When another
+// instance holds it the upsert inserts a second document with the same _id,
+// which fails with a duplicate key error.
+func (m *Migrator) acquireLock() error {
+	now := m.now().UTC()
+	filter := bson.M{"_id": lockID, "expiresAt": bson.M{"$lt": now}}
+	update := bson.M{"$set": bson.M{"owner": m.owner, "expiresAt": now.Add(m.lockTTL)}}
+
+	collection := m.db.Database(m.database).Collection("schema_migrations_lock")
+	_, err := collection.UpdateOne(context.TODO(), filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrLocked
+	}
+	return err
+}
+
+// renewLock extends the lock while this instance holds it, ErrLockLost when
+// it expired and another instance took it.
+func (m *Migrator) renewLock() error {
+	filter := bson.M{"_id": lockID, "owner": m.owner}
+	update := bson.M{"$set": bson.M{"expiresAt": m.now().UTC().Add(m.lockTTL)}}
+
+	collection := m.db.Database(m.database).Collection("schema_migrations_lock")
+	result, err := collection.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// lockHeartbeat renews the lock while the migrations run, so a migration
+// longer than the TTL does not let another instance take it.
+type lockHeartbeat struct {
+	lost atomic.Bool
+	quit chan struct{}
+	done chan struct{}
+}
+
+// holdLock renews the lock every third of its TTL until stop is called. A
+// failed renewal is retried on the next tick, so the lock is only lost when
+// it expires first.
+func (m *Migrator) holdLock() *lockHeartbeat {
+	heartbeat := &lockHeartbeat{quit: make(chan struct{}), done: make(chan struct{})}
+
+	go func() {
+		defer close(heartbeat.done)
+		ticker := time.NewTicker(m.lockTTL / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-heartbeat.quit:
+				return
+			case <-ticker.C:
+			}
+
+			err := m.renewLock()
+			if err == ErrLockLost {
+				heartbeat.lost.Store(true)
+				return
+			} else if err != nil {
+				fmt.Println("Error renewing the migrations lock: " + err.Error())
+			}
+		}
+	}()
+
+	return heartbeat
+}
+
+func (h *lockHeartbeat) stop() {
+	close(h.quit)
+	<-h.done
+}
+
+func (m *Migrator) releaseLock() {
+	collection := m.db.Database(m.database).Collection("schema_migrations_lock")
+	_, err := collection.DeleteOne(context.TODO(), bson.M{"_id": lockID, "owner": m.owner})
+	if err != nil {
+		fmt.Println("Error releasing the migrations lock: " + err.Error())
+	}
+}
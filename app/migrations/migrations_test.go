@@ -0,0 +1,198 @@
+package migrations
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func appliedResponse(versions ...int64) bson.D {
+	records := []bson.D{}
+	for _, version := range versions {
+		records = append(records, bson.D{{Key: "_id", Value: version}, {Key: "name", Value: "migration"}})
+	}
+	return mtest.CreateCursorResponse(0, "orders.schema_migrations", mtest.FirstBatch, records...)
+}
+
+func testMigrations(ran *[]int64) []Migration {
+	up := func(version int64) func(db *mongo.Database) error {
+		return func(db *mongo.Database) error {
+			*ran = append(*ran, version)
+			return nil
+		}
+	}
+	return []Migration{
+		{Version: 3, Name: "third", Up: up(3)},
+		{Version: 1, Name: "first", Up: up(1)},
+		{Version: 2, Name: "second", Up: up(2), Count: func(db *mongo.Database) (int64, error) { return 5, nil }},
+	}
+}
+
+func TestNewMigrator(t *testing.T) {
+	up := func(db *mongo.Database) error { return nil }
+
+	_, err := NewMigrator(nil, []Migration{{Version: 1, Name: "a", Up: up}, {Version: 1, Name: "b", Up: up}})
+	assert.Equal(t, ErrDuplicateVersion, err)
+
+	_, err = NewMigrator(nil, []Migration{{Version: 0, Name: "a", Up: up}})
+	assert.Equal(t, ErrInvalidMigration, err)
+
+	_, err = NewMigrator(nil, []Migration{{Version: 1, Name: "a"}})
+	assert.Equal(t, ErrInvalidMigration, err)
+
+	_, err = NewMigrator(nil, Registry)
+	assert.Nil(t, err)
+}
+
+func TestUp(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("runs the pending migrations in order", func(mt *mtest.T) {
+		ran := []int64{}
+		migrator, err := NewMigrator(mt.Client, testMigrations(&ran))
+		assert.Nil(t, err)
+
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(),
+			appliedResponse(2),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+		)
+
+		results, err := migrator.Up()
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{1, 3}, ran)
+		assert.Equal(t, []Result{
+			{Version: 1, Name: "first", Status: StatusApplied},
+			{Version: 3, Name: "third", Status: StatusApplied},
+		}, results)
+	})
+
+	mt.Run("nothing pending", func(mt *mtest.T) {
+		ran := []int64{}
+		migrator, _ := NewMigrator(mt.Client, testMigrations(&ran))
+
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(),
+			appliedResponse(1, 2, 3),
+			mtest.CreateSuccessResponse(),
+		)
+
+		results, err := migrator.Up()
+		assert.Nil(t, err)
+		assert.Empty(t, ran)
+		assert.Empty(t, results)
+	})
+
+	mt.Run("another instance holds the lock", func(mt *mtest.T) {
+		ran := []int64{}
+		migrator, _ := NewMigrator(mt.Client, testMigrations(&ran))
+
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: 11000, Message: "duplicate key error"}))
+
+		results, err := migrator.Up()
+		assert.Equal(t, ErrLocked, err)
+		assert.Nil(t, results)
+		assert.Empty(t, ran)
+	})
+
+	mt.Run("stops at the first error", func(mt *mtest.T) {
+		failure := errors.New("boom")
+		ran := []int64{}
+		migrations := testMigrations(&ran)
+		migrations[1].Up = func(db *mongo.Database) error { return failure }
+		migrator, _ := NewMigrator(mt.Client, migrations)
+
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(),
+			appliedResponse(),
+			mtest.CreateSuccessResponse(),
+		)
+
+		results, err := migrator.Up()
+		assert.ErrorIs(t, err, failure)
+		assert.Empty(t, results)
+		assert.Empty(t, ran)
+	})
+
+	mt.Run("stops when the lock is lost during a migration", func(mt *mtest.T) {
+		ran := []int64{}
+		migrations := testMigrations(&ran)
+		migrations[1].Up = func(db *mongo.Database) error {
+			ran = append(ran, 1)
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		}
+		migrator, _ := NewMigrator(mt.Client, migrations, WithLockTTL(30*time.Millisecond))
+
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(),
+			appliedResponse(),
+			// The renewal matches no lock of this instance.
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+		)
+
+		results, err := migrator.Up()
+		assert.Equal(t, ErrLockLost, err)
+		assert.Equal(t, []Result{{Version: 1, Name: "first", Status: StatusApplied}}, results)
+		assert.Equal(t, []int64{1}, ran)
+	})
+}
+
+func TestRenewLock(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("held by the instance", func(mt *mtest.T) {
+		migrator, _ := NewMigrator(mt.Client, nil)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		assert.Nil(t, migrator.renewLock())
+
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		assert.Equal(t, migrator.owner, update.Lookup("q", "owner").StringValue())
+	})
+
+	mt.Run("taken by another instance", func(mt *mtest.T) {
+		migrator, _ := NewMigrator(mt.Client, nil)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}))
+
+		assert.Equal(t, ErrLockLost, migrator.renewLock())
+	})
+}
+
+func TestDryRun(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("lists the pending migrations", func(mt *mtest.T) {
+		ran := []int64{}
+		migrator, _ := NewMigrator(mt.Client, testMigrations(&ran))
+
+		mt.AddMockResponses(appliedResponse(1))
+
+		results, err := migrator.DryRun()
+		assert.Nil(t, err)
+		assert.Empty(t, ran)
+
+		documents := int64(5)
+		assert.Equal(t, []Result{
+			{Version: 2, Name: "second", Status: StatusPending, Documents: &documents},
+			{Version: 3, Name: "third", Status: StatusPending},
+		}, results)
+		assert.Equal(t, "0002_second: pending (5 documents)", results[0].String())
+	})
+}
+
+func TestBackfillRefundedAmount(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("success", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}))
+
+		err := backfillRefundedAmount(mt.DB)
+		assert.Nil(t, err)
+	})
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Registry lists the migrations of the service. A migration already released
+// is never changed, a new one gets the next version.
+var Registry = []Migration{
+	{
+		Version: 1,
+		Name:    "backfill_refunded_amount",
+		Up:      backfillRefundedAmount,
+		Count:   countWithoutRefundedAmount,
+	},
+}
+
+// withoutRefundedAmount matches the orders created before partial returns,
+// which have no refundedAmount.
+var withoutRefundedAmount = bson.M{"refundedAmount": bson.M{"$exists": false}}
+
+func backfillRefundedAmount(db *mongo.Database) error {
+	update := bson.M{"$set": bson.M{"refundedAmount": 0}}
+	_, err := db.Collection("orders").UpdateMany(context.TODO(), withoutRefundedAmount, update)
+	return err
+}
+
+func countWithoutRefundedAmount(db *mongo.Database) (int64, error) {
+	return db.Collection("orders").CountDocuments(context.TODO(), withoutRefundedAmount)
+}
@@ -0,0 +1,26 @@
+package models
+
+// APIKey is a server-to-server credential bound to one channel. Only the
+// SHA-256 hash of the key is stored.
+type APIKey struct {
+	ID        string   `bson:"_id" json:"id"`
+	Hash      string   `bson:"hash" json:"-"`
+	Prefix    string   `bson:"prefix" json:"prefix"`
+	Channel   Channel  `bson:"channel" json:"channel"`
+	Scopes    []string `bson:"scopes" json:"scopes"`
+	CreatedOn string   `bson:"createdOn" json:"createdOn"`
+	RotatedOn string   `bson:"rotatedOn,omitempty" json:"rotatedOn,omitempty"`
+	RevokedOn string   `bson:"revokedOn,omitempty" json:"revokedOn,omitempty"`
+}
+
+type APIKeyRequest struct {
+	Channel Channel  `json:"channel"`
+	Scopes  []string `json:"scopes"`
+}
+
+// ResponseAPIKey is the only response that carries the raw key, it cannot be
+// recovered afterwards.
+type ResponseAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
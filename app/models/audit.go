@@ -0,0 +1,26 @@
+package models
+
+const (
+	AuditActionCreateOrder = "CreateOrder"
+	AuditActionAddEvent    = "AddEvent"
+	AuditActionAmendOrder  = "AmendOrder"
+
+	// The admin actions record what they act on in Target: the API key ID or
+	// the purged cache pattern.
+	AuditActionIssueAPIKey  = "IssueAPIKey"
+	AuditActionRotateAPIKey = "RotateAPIKey"
+	AuditActionRevokeAPIKey = "RevokeAPIKey"
+	AuditActionPurgeCache   = "PurgeCache"
+)
+
+type AuditEntry struct {
+	Actor          string      `bson:"actor" json:"actor"`
+	Action         string      `bson:"action" json:"action"`
+	OrderID        int64       `bson:"orderID" json:"orderID"`
+	PreviousStatus OrderStatus `bson:"previousStatus" json:"previousStatus"`
+	NewStatus      OrderStatus `bson:"newStatus" json:"newStatus"`
+	Target         string      `bson:"target,omitempty" json:"target,omitempty"`
+	RequestID      string      `bson:"requestID" json:"requestID"`
+	SourceIP       string      `bson:"sourceIP" json:"sourceIP"`
+	Timestamp      string      `bson:"timestamp" json:"timestamp"`
+}
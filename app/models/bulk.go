@@ -0,0 +1,56 @@
+package models
+
+const (
+	BulkStatusCreated   = "created"
+	BulkStatusApplied   = "applied"
+	BulkStatusDuplicate = "duplicate"
+	BulkStatusError     = "error"
+)
+
+// BulkOrderResult is the outcome of one item of a bulk import, Index is its
+// position in the request. Code identifies the error of failed items.
+type BulkOrderResult struct {
+	Index   int    `json:"index"`
+	Status  string `json:"status"`
+	OrderID int64  `json:"orderID,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type ResponseBulkOrders struct {
+	Created    int               `json:"created"`
+	Duplicates int               `json:"duplicates"`
+	Errors     int               `json:"errors"`
+	Results    []BulkOrderResult `json:"results"`
+}
+
+type BulkEventItem struct {
+	OrderID int64 `json:"orderId"`
+	Event   Event `json:"event"`
+}
+
+// BulkEventResult is the outcome of one event of a bulk ingestion, Index is
+// its position in the request.
+type BulkEventResult struct {
+	Index          int         `json:"index"`
+	Status         string      `json:"status"`
+	OrderID        int64       `json:"orderID"`
+	EventID        string      `json:"eventID"`
+	PreviousStatus OrderStatus `json:"previousStatus,omitempty"`
+	NewStatus      OrderStatus `json:"newStatus,omitempty"`
+	Code           string      `json:"code,omitempty"`
+	Error          string      `json:"error,omitempty"`
+}
+
+type ResponseBulkEvents struct {
+	Applied    int               `json:"applied"`
+	Duplicates int               `json:"duplicates"`
+	Errors     int               `json:"errors"`
+	Results    []BulkEventResult `json:"results"`
+}
+
+// OrderEvents is an order with the events applied to it, written together.
+type OrderEvents struct {
+	Order  Order
+	Events []Event
+}
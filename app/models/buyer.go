@@ -0,0 +1,13 @@
+package models
+
+// BuyerSummary sums every order of a buyer. TotalSpent is the total value of
+// the orders that were not canceled minus the refunded amount, and
+// FavouriteChannel is the channel with most orders.
+type BuyerSummary struct {
+	TotalSpent        float64          `json:"totalSpent"`
+	OrderCount        int64            `json:"orderCount"`
+	OrdersByStatus    map[string]int64 `json:"ordersByStatus"`
+	FirstPurchaseDate string           `json:"firstPurchaseDate"`
+	LastPurchaseDate  string           `json:"lastPurchaseDate"`
+	FavouriteChannel  string           `json:"favouriteChannel"`
+}
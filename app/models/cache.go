@@ -0,0 +1,18 @@
+package models
+
+type CacheKey struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+	// TTL is in seconds, -1 when the key does not expire.
+	TTL int64 `json:"ttl"`
+}
+
+type ResponseCacheKeys struct {
+	Pattern string     `json:"pattern"`
+	Keys    []CacheKey `json:"keys"`
+}
+
+type ResponsePurge struct {
+	Pattern string `json:"pattern"`
+	Deleted int64  `json:"deleted"`
+}
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+var (
+	ErrUnknownOrderStatus = errors.New("Unknown order status")
+	ErrUnknownEventType   = errors.New("Unknown event type")
+	ErrUnknownChannel     = errors.New("Unknown channel")
+)
+
+// OrderStatus is the status of an order. The empty value means the status is
+// not set and is the only value outside the enum accepted when marshalling.
+type OrderStatus string
+
+const (
+	StatusCreated           OrderStatus = "Created"
+	StatusPaymentReceived   OrderStatus = "PaymentReceived"
+	StatusCanceled          OrderStatus = "Canceled"
+	StatusInvoiced          OrderStatus = "Invoiced"
+	StatusReturned          OrderStatus = "Returned"
+	StatusPartiallyReturned OrderStatus = "PartiallyReturned"
+)
+
+var OrderStatuses = []OrderStatus{StatusCreated, StatusPaymentReceived, StatusCanceled, StatusInvoiced, StatusReturned, StatusPartiallyReturned}
+
+// EventType is the type of an event of an order.
+type EventType string
+
+const (
+	EventPaymentReceived EventType = "PaymentReceived"
+	EventCanceled        EventType = "Canceled"
+	EventInvoiced        EventType = "Invoiced"
+	EventReturned        EventType = "Returned"
+	EventAmended         EventType = "Amended"
+)
+
+var EventTypes = []EventType{EventPaymentReceived, EventCanceled, EventInvoiced, EventReturned, EventAmended}
+
+// Channel is the sales channel of an order.
+type Channel string
+
+const (
+	ChannelEcommerce  Channel = "Ecommerce"
+	ChannelCallCenter Channel = "CallCenter"
+	ChannelStore      Channel = "Store"
+	ChannelAffiliate  Channel = "Affiliate"
+)
+
+var Channels = []Channel{ChannelEcommerce, ChannelCallCenter, ChannelStore, ChannelAffiliate}
+
+func (s OrderStatus) IsValid() bool {
+	return slices.Contains(OrderStatuses, s)
+}
+
+func (s OrderStatus) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(s, OrderStatuses, ErrUnknownOrderStatus)
+}
+
+func (s *OrderStatus) UnmarshalJSON(data []byte) error {
+	return unmarshalEnumJSON(data, s, OrderStatuses, ErrUnknownOrderStatus)
+}
+
+func (s OrderStatus) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return marshalEnumBSON(s, OrderStatuses, ErrUnknownOrderStatus)
+}
+
+func (s *OrderStatus) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	return unmarshalEnumBSON(t, data, s, OrderStatuses, ErrUnknownOrderStatus)
+}
+
+func (e EventType) IsValid() bool {
+	return slices.Contains(EventTypes, e)
+}
+
+func (e EventType) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(e, EventTypes, ErrUnknownEventType)
+}
+
+func (e *EventType) UnmarshalJSON(data []byte) error {
+	return unmarshalEnumJSON(data, e, EventTypes, ErrUnknownEventType)
+}
+
+func (e EventType) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return marshalEnumBSON(e, EventTypes, ErrUnknownEventType)
+}
+
+func (e *EventType) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	return unmarshalEnumBSON(t, data, e, EventTypes, ErrUnknownEventType)
+}
+
+func (c Channel) IsValid() bool {
+	return slices.Contains(Channels, c)
+}
+
+func (c Channel) MarshalJSON() ([]byte, error) {
+	return marshalEnumJSON(c, Channels, ErrUnknownChannel)
+}
+
+func (c *Channel) UnmarshalJSON(data []byte) error {
+	return unmarshalEnumJSON(data, c, Channels, ErrUnknownChannel)
+}
+
+func (c Channel) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return marshalEnumBSON(c, Channels, ErrUnknownChannel)
+}
+
+func (c *Channel) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	return unmarshalEnumBSON(t, data, c, Channels, ErrUnknownChannel)
+}
+
+func marshalEnumJSON[T ~string](value T, values []T, err error) ([]byte, error) {
+	if value != "" && !slices.Contains(values, value) {
+		return nil, err
+	}
+	return json.Marshal(string(value))
+}
+
+func unmarshalEnumJSON[T ~string](data []byte, target *T, values []T, err error) error {
+	var value string
+	if jsonErr := json.Unmarshal(data, &value); jsonErr != nil {
+		return err
+	}
+	if value != "" && !slices.Contains(values, T(value)) {
+		return err
+	}
+	*target = T(value)
+	return nil
+}
+
+func marshalEnumBSON[T ~string](value T, values []T, err error) (bsontype.Type, []byte, error) {
+	if value != "" && !slices.Contains(values, value) {
+		return 0, nil, err
+	}
+	return bson.MarshalValue(string(value))
+}
+
+// unmarshalEnumBSON decodes a BSON string of the enum, a null is decoded as
+// the empty value.
+func unmarshalEnumBSON[T ~string](t bsontype.Type, data []byte, target *T, values []T, err error) error {
+	if t == bsontype.Null {
+		*target = ""
+		return nil
+	}
+
+	value, ok := bson.RawValue{Type: t, Value: data}.StringValueOK()
+	if !ok || (value != "" && !slices.Contains(values, T(value))) {
+		return err
+	}
+	*target = T(value)
+	return nil
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestEnumJSON(t *testing.T) {
+	var order Order
+	err := json.Unmarshal([]byte(`{"channel": "Store", "status": "Invoiced", "events": [{"type": "Returned"}]}`), &order)
+	assert.Nil(t, err)
+	assert.Equal(t, ChannelStore, order.Channel)
+	assert.Equal(t, StatusInvoiced, order.Status)
+	assert.Equal(t, EventReturned, order.Events[0].Type)
+
+	content, err := json.Marshal(Event{Type: EventCanceled})
+	assert.Nil(t, err)
+	assert.Contains(t, string(content), `"type":"Canceled"`)
+}
+
+func TestEnumJSONRejectsUnknownValues(t *testing.T) {
+	var order Order
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"channel": "Marketplace"}`), &order), ErrUnknownChannel)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"status": "Shipped"}`), &order), ErrUnknownOrderStatus)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"events": [{"type": "Created"}]}`), &order), ErrUnknownEventType)
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"status": 1}`), &order), ErrUnknownOrderStatus)
+
+	_, err := json.Marshal(Order{Status: "Shipped"})
+	assert.ErrorIs(t, err, ErrUnknownOrderStatus)
+}
+
+func TestEnumBSON(t *testing.T) {
+	document, err := bson.Marshal(Order{Channel: ChannelAffiliate, Status: StatusPartiallyReturned})
+	assert.Nil(t, err)
+
+	var raw bson.M
+	assert.Nil(t, bson.Unmarshal(document, &raw))
+	assert.Equal(t, "Affiliate", raw["channel"])
+	assert.Equal(t, "PartiallyReturned", raw["status"])
+
+	var order Order
+	assert.Nil(t, bson.Unmarshal(document, &order))
+	assert.Equal(t, ChannelAffiliate, order.Channel)
+	assert.Equal(t, StatusPartiallyReturned, order.Status)
+}
+
+func TestEnumBSONRejectsUnknownValues(t *testing.T) {
+	document, err := bson.Marshal(bson.M{"status": "Shipped"})
+	assert.Nil(t, err)
+
+	var order Order
+	assert.ErrorIs(t, bson.Unmarshal(document, &order), ErrUnknownOrderStatus)
+
+	_, err = bson.Marshal(Order{Channel: "Marketplace"})
+	assert.ErrorIs(t, err, ErrUnknownChannel)
+
+	document, err = bson.Marshal(bson.M{"status": nil})
+	assert.Nil(t, err)
+	assert.Nil(t, bson.Unmarshal(document, &order))
+	assert.Equal(t, OrderStatus(""), order.Status)
+}
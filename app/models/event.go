@@ -1,8 +1,42 @@
 package models
 
 type Event struct {
-	Id   string `json:"id"`
-	Type string `json:"type"`
-	Date string `json:"date"`
-	User string `json:"user"`
+	Id     string       `json:"id"`
+	Type   EventType    `json:"type"`
+	Date   string       `json:"date"`
+	User   string       `json:"user"`
+	Reason *EventReason `bson:"reason,omitempty" json:"reason,omitempty"`
+	// Changes is the diff applied by an Amended event.
+	Changes []FieldChange `bson:"changes,omitempty" json:"changes,omitempty"`
+}
+
+// EventReason is the payload of Canceled and Returned events. The code must
+// be one of the catalogue of the event type, and returns must list the
+// returned products.
+type EventReason struct {
+	Code    string         `bson:"code" json:"code"`
+	Comment string         `bson:"comment,omitempty" json:"comment,omitempty"`
+	Items   []ReturnedItem `bson:"items,omitempty" json:"items,omitempty"`
+}
+
+type ReturnedItem struct {
+	Sku      string `bson:"sku" json:"sku"`
+	Quantity int64  `bson:"quantity" json:"quantity"`
+}
+
+// FieldChange is a field of the order changed by an amendment, with its
+// previous and new values. Products are addressed by their SKU, e.g.
+// "products.P001.quantity", and a product added or removed has an empty
+// previous or new value.
+type FieldChange struct {
+	Field string `bson:"field" json:"field"`
+	From  string `bson:"from" json:"from"`
+	To    string `bson:"to" json:"to"`
+}
+
+// TimelineEvent is an event of the order history together with the status
+// the order had right after the event was applied.
+type TimelineEvent struct {
+	Event
+	Status OrderStatus `json:"status"`
 }
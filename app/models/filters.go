@@ -1,9 +1,35 @@
 package models
 
 type Filters struct {
-	OrderId        int64  `json:"orderId"`
-	DocumentNumber string `json:"documentNumber"`
-	Status         string `json:"status"`
-	CreatedOnFrom  string `json:"createdOnFrom"`
-	CreatedOnTo    string `json:"createdOnTo"`
+	OrderId        int64       `json:"orderId"`
+	DocumentNumber string      `json:"documentNumber"`
+	Status         OrderStatus `json:"status"`
+	Channel        Channel     `json:"channel"`
+	CreatedOnFrom  string      `json:"createdOnFrom"`
+	CreatedOnTo    string      `json:"createdOnTo"`
+}
+
+type EventFilters struct {
+	Type     EventType `json:"type"`
+	User     string    `json:"user"`
+	DateFrom string    `json:"dateFrom"`
+	DateTo   string    `json:"dateTo"`
+	Page     int64     `json:"page"`
+	PageSize int64     `json:"pageSize"`
+}
+
+type AuditFilters struct {
+	Actor    string `json:"actor"`
+	Action   string `json:"action"`
+	OrderId  int64  `json:"orderId"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Page     int64  `json:"page"`
+	PageSize int64  `json:"pageSize"`
+}
+
+type BuyerFilters struct {
+	Channel  Channel `json:"channel"`
+	Page     int64   `json:"page"`
+	PageSize int64   `json:"pageSize"`
 }
@@ -1,15 +1,24 @@
 package models
 
+// ExternalReferenceIDs maps every channel to its external reference ID.
+var ExternalReferenceIDs = map[Channel]string{
+	ChannelEcommerce:  "abc-123",
+	ChannelCallCenter: "def-456",
+	ChannelStore:      "ghi-789",
+	ChannelAffiliate:  "jkl-012",
+}
+
 type Order struct {
-	OrderID             int64     `bson:"id" json:"orderID"`
-	ExternalReferenceID string    `bson:"externalReferenceID" json:"externalReferenceID"`
-	Channel             string    `bson:"channel" json:"channel"`
-	PurchaseDate        string    `bson:"purchaseDate" json:"purchaseDate"`
-	TotalValue          float64   `bson:"totalValue" json:"totalValue"`
-	Buyer               Buyer     `bson:"buyer" json:"buyer"`
-	Products            []Product `bson:"products" json:"products"`
-	Status              string    `bson:"status" json:"status"`
-	Events              []Event   `bson:"events" json:"events"`
+	OrderID             int64       `bson:"id" json:"orderID"`
+	ExternalReferenceID string      `bson:"externalReferenceID" json:"externalReferenceID"`
+	Channel             Channel     `bson:"channel" json:"channel"`
+	PurchaseDate        string      `bson:"purchaseDate" json:"purchaseDate"`
+	TotalValue          float64     `bson:"totalValue" json:"totalValue"`
+	RefundedAmount      float64     `bson:"refundedAmount" json:"refundedAmount"`
+	Buyer               Buyer       `bson:"buyer" json:"buyer"`
+	Products            []Product   `bson:"products" json:"products"`
+	Status              OrderStatus `bson:"status" json:"status"`
+	Events              []Event     `bson:"events" json:"events"`
 }
 
 type Buyer struct {
@@ -25,4 +34,6 @@ type Product struct {
 	Description string  `bson:"description" json:"description"`
 	Price       float64 `bson:"price" json:"price"`
 	Quantity    int64   `bson:"quantity" json:"quantity"`
+	// ReturnedQuantity is the amount of units of the line already returned.
+	ReturnedQuantity int64 `bson:"returnedQuantity" json:"returnedQuantity"`
 }
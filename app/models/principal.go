@@ -0,0 +1,26 @@
+package models
+
+const (
+	RoleChannelClient = "channel-client"
+	RoleBackoffice    = "backoffice"
+	RoleAuditor       = "auditor"
+	RoleAdmin         = "admin"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string   `json:"subject"`
+	Roles   []string `json:"roles"`
+	// Channel is set when the principal is bound to a single channel, as
+	// API keys are.
+	Channel Channel `json:"channel,omitempty"`
+}
+
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package models
+
+type Reason struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+// ReasonCatalogue lists the reason codes allowed per event type.
+type ReasonCatalogue map[EventType][]Reason
+
+var DefaultReasonCatalogue = ReasonCatalogue{
+	EventCanceled: {
+		{Code: "CUSTOMER_REQUEST", Description: "The customer asked to cancel the order"},
+		{Code: "PAYMENT_FAILED", Description: "The payment was rejected"},
+		{Code: "OUT_OF_STOCK", Description: "A product is out of stock"},
+		{Code: "FRAUD_SUSPECTED", Description: "The order was flagged as fraudulent"},
+		{Code: "DUPLICATE_ORDER", Description: "The order was placed twice"},
+	},
+	EventReturned: {
+		{Code: "DEFECTIVE", Description: "The product is defective"},
+		{Code: "WRONG_ITEM", Description: "The customer received another product"},
+		{Code: "NOT_AS_DESCRIBED", Description: "The product does not match its description"},
+		{Code: "DAMAGED_IN_TRANSIT", Description: "The product was damaged during the delivery"},
+		{Code: "CHANGED_MIND", Description: "The customer does not want the product anymore"},
+	},
+}
+
+func (c ReasonCatalogue) HasCode(eventType EventType, code string) bool {
+	for _, reason := range c[eventType] {
+		if reason.Code == code {
+			return true
+		}
+	}
+	return false
+}
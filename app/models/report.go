@@ -0,0 +1,51 @@
+package models
+
+type ReportFilters struct {
+	Type EventType `json:"type"`
+	From string    `json:"from"`
+	To   string    `json:"to"`
+}
+
+type ReasonReport struct {
+	Type  string `bson:"type" json:"type"`
+	Code  string `bson:"code" json:"code"`
+	Count int64  `bson:"count" json:"count"`
+	// Units is the amount of returned products, always 0 for cancellations.
+	Units int64 `bson:"units" json:"units"`
+}
+
+const (
+	SalesGroupChannel = "channel"
+	SalesGroupStatus  = "status"
+	SalesGroupDay     = "day"
+	SalesGroupWeek    = "week"
+	SalesGroupMonth   = "month"
+	SalesGroupSku     = "sku"
+)
+
+// SalesGroups lists the accepted values of SalesFilters.GroupBy.
+var SalesGroups = []string{SalesGroupChannel, SalesGroupStatus, SalesGroupDay, SalesGroupWeek, SalesGroupMonth, SalesGroupSku}
+
+type SalesFilters struct {
+	GroupBy string `json:"groupBy"`
+	From    string `json:"from"`
+	To      string `json:"to"`
+}
+
+// SalesReport sums the orders of a group. Days, weeks and months are
+// identified by their first day, e.g. 2024-05-01, and weeks start on Monday.
+// When grouping by SKU the value is the price by quantity of the product
+// lines instead of the total value of the orders.
+type SalesReport struct {
+	Group      string  `bson:"group" json:"group"`
+	OrderCount int64   `bson:"orderCount" json:"orderCount"`
+	Units      int64   `bson:"units" json:"units"`
+	TotalValue float64 `bson:"totalValue" json:"totalValue"`
+}
+
+type ResponseSalesReport struct {
+	GroupBy string        `json:"groupBy"`
+	From    string        `json:"from"`
+	To      string        `json:"to"`
+	Groups  []SalesReport `json:"groups"`
+}
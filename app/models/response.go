@@ -1,28 +1,63 @@
 package models
 
 type ResponseCreate struct {
-	OrderID   int64  `json:"orderID"`
-	Status    string `json:"status"`
-	UpdatedOn string `json:"updatedOn"`
+	OrderID   int64       `json:"orderID"`
+	Status    OrderStatus `json:"status"`
+	UpdatedOn string      `json:"updatedOn"`
+	// Duplicate is set when the order was already created by a previous
+	// request, nothing was written. It is not part of the response.
+	Duplicate bool `json:"-"`
 }
 
 type ResponseUpdate struct {
-	OrderID        int64  `json:"orderID"`
-	PreviousStatus string `json:"previousStatus"`
-	NewStatus      string `json:"newStatus"`
-	UpdatedOn      string `json:"updatedOn"`
+	OrderID        int64       `json:"orderID"`
+	PreviousStatus OrderStatus `json:"previousStatus"`
+	NewStatus      OrderStatus `json:"newStatus"`
+	UpdatedOn      string      `json:"updatedOn"`
+	// Duplicate is set when the event was already applied by a previous
+	// request, nothing was written. It is not part of the response.
+	Duplicate bool `json:"-"`
+}
+
+type ResponseAmend struct {
+	OrderID   int64         `json:"orderID"`
+	Status    OrderStatus   `json:"status"`
+	UpdatedOn string        `json:"updatedOn"`
+	Changes   []FieldChange `json:"changes"`
 }
 
 type ResponseGet struct {
-	OrderID             int64     `json:"orderID"`
-	ExternalReferenceID string    `json:"externalReferenceID"`
-	Channel             string    `json:"channel"`
-	ChannelTranslate    string    `json:"channelTranslate"`
-	PurchaseDate        string    `json:"purchaseDate"`
-	TotalValue          float64   `json:"totalValue"`
-	Buyer               Buyer     `json:"buyer"`
-	Products            []Product `json:"product"`
-	Status              string    `json:"status"`
-	StatusTranslate     string    `json:"statusTranslate"`
-	Events              []Event   `json:"events"`
+	OrderID             int64       `json:"orderID"`
+	ExternalReferenceID string      `json:"externalReferenceID"`
+	Channel             Channel     `json:"channel"`
+	ChannelTranslate    string      `json:"channelTranslate"`
+	PurchaseDate        string      `json:"purchaseDate"`
+	TotalValue          float64     `json:"totalValue"`
+	RefundedAmount      float64     `json:"refundedAmount"`
+	Buyer               Buyer       `json:"buyer"`
+	Products            []Product   `json:"product"`
+	Status              OrderStatus `json:"status"`
+	StatusTranslate     string      `json:"statusTranslate"`
+	Events              []Event     `json:"events"`
+}
+
+type ResponseEvents struct {
+	OrderID  int64           `json:"orderID"`
+	Page     int64           `json:"page"`
+	PageSize int64           `json:"pageSize"`
+	Total    int64           `json:"total"`
+	Events   []TimelineEvent `json:"events"`
+}
+
+type ResponseBuyerOrders struct {
+	DocumentNumber string       `json:"documentNumber"`
+	Page           int64        `json:"page"`
+	PageSize       int64        `json:"pageSize"`
+	Summary        BuyerSummary `json:"summary"`
+	Orders         []Order      `json:"orders"`
+}
+
+// ResponseError is the body of every failed request.
+type ResponseError struct {
+	Error string `json:"error"`
 }
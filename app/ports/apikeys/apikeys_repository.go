@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"challenge_pyegros/app/models"
+)
+
+//go:generate go run go.uber.org/mock/mockgen@v0.5.0 -source=./$GOFILE -destination=./mocks/$GOFILE -package mocks
+
+type APIKeysRepository interface {
+	IssueAPIKey(request models.APIKeyRequest) (*models.ResponseAPIKey, error)
+	RotateAPIKey(id string) (*models.ResponseAPIKey, error)
+	RevokeAPIKey(id string) (*models.APIKey, error)
+	Authenticate(key string) (*models.Principal, error)
+}
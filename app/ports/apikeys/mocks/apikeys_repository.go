@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./apikeys_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./apikeys_repository.go -destination=./mocks/apikeys_repository.go -package mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	models "challenge_pyegros/app/models"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAPIKeysRepository is a mock of APIKeysRepository interface.
+type MockAPIKeysRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeysRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockAPIKeysRepositoryMockRecorder is the mock recorder for MockAPIKeysRepository.
+type MockAPIKeysRepositoryMockRecorder struct {
+	mock *MockAPIKeysRepository
+}
+
+// NewMockAPIKeysRepository creates a new mock instance.
+func NewMockAPIKeysRepository(ctrl *gomock.Controller) *MockAPIKeysRepository {
+	mock := &MockAPIKeysRepository{ctrl: ctrl}
+	mock.recorder = &MockAPIKeysRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeysRepository) EXPECT() *MockAPIKeysRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockAPIKeysRepository) Authenticate(key string) (*models.Principal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", key)
+	ret0, _ := ret[0].(*models.Principal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockAPIKeysRepositoryMockRecorder) Authenticate(key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockAPIKeysRepository)(nil).Authenticate), key)
+}
+
+// IssueAPIKey mocks base method.
+func (m *MockAPIKeysRepository) IssueAPIKey(request models.APIKeyRequest) (*models.ResponseAPIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueAPIKey", request)
+	ret0, _ := ret[0].(*models.ResponseAPIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueAPIKey indicates an expected call of IssueAPIKey.
+func (mr *MockAPIKeysRepositoryMockRecorder) IssueAPIKey(request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueAPIKey", reflect.TypeOf((*MockAPIKeysRepository)(nil).IssueAPIKey), request)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockAPIKeysRepository) RevokeAPIKey(id string) (*models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", id)
+	ret0, _ := ret[0].(*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockAPIKeysRepositoryMockRecorder) RevokeAPIKey(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockAPIKeysRepository)(nil).RevokeAPIKey), id)
+}
+
+// RotateAPIKey mocks base method.
+func (m *MockAPIKeysRepository) RotateAPIKey(id string) (*models.ResponseAPIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateAPIKey", id)
+	ret0, _ := ret[0].(*models.ResponseAPIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateAPIKey indicates an expected call of RotateAPIKey.
+func (mr *MockAPIKeysRepositoryMockRecorder) RotateAPIKey(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateAPIKey", reflect.TypeOf((*MockAPIKeysRepository)(nil).RotateAPIKey), id)
+}
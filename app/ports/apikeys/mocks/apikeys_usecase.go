@@ -0,0 +1,101 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./apikeys_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./apikeys_usecase.go -destination=./mocks/apikeys_usecase.go -package mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	models "challenge_pyegros/app/models"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAPIKeysUseCase is a mock of APIKeysUseCase interface.
+type MockAPIKeysUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeysUseCaseMockRecorder
+	isgomock struct{}
+}
+
+// MockAPIKeysUseCaseMockRecorder is the mock recorder for MockAPIKeysUseCase.
+type MockAPIKeysUseCaseMockRecorder struct {
+	mock *MockAPIKeysUseCase
+}
+
+// NewMockAPIKeysUseCase creates a new mock instance.
+func NewMockAPIKeysUseCase(ctrl *gomock.Controller) *MockAPIKeysUseCase {
+	mock := &MockAPIKeysUseCase{ctrl: ctrl}
+	mock.recorder = &MockAPIKeysUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeysUseCase) EXPECT() *MockAPIKeysUseCaseMockRecorder {
+	return m.recorder
+}
+
+// Authenticate mocks base method.
+func (m *MockAPIKeysUseCase) Authenticate(key string) (*models.Principal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", key)
+	ret0, _ := ret[0].(*models.Principal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate.
+func (mr *MockAPIKeysUseCaseMockRecorder) Authenticate(key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockAPIKeysUseCase)(nil).Authenticate), key)
+}
+
+// IssueAPIKey mocks base method.
+func (m *MockAPIKeysUseCase) IssueAPIKey(request models.APIKeyRequest) (*models.ResponseAPIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueAPIKey", request)
+	ret0, _ := ret[0].(*models.ResponseAPIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueAPIKey indicates an expected call of IssueAPIKey.
+func (mr *MockAPIKeysUseCaseMockRecorder) IssueAPIKey(request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueAPIKey", reflect.TypeOf((*MockAPIKeysUseCase)(nil).IssueAPIKey), request)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockAPIKeysUseCase) RevokeAPIKey(id string) (*models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", id)
+	ret0, _ := ret[0].(*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockAPIKeysUseCaseMockRecorder) RevokeAPIKey(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockAPIKeysUseCase)(nil).RevokeAPIKey), id)
+}
+
+// RotateAPIKey mocks base method.
+func (m *MockAPIKeysUseCase) RotateAPIKey(id string) (*models.ResponseAPIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateAPIKey", id)
+	ret0, _ := ret[0].(*models.ResponseAPIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RotateAPIKey indicates an expected call of RotateAPIKey.
+func (mr *MockAPIKeysUseCaseMockRecorder) RotateAPIKey(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateAPIKey", reflect.TypeOf((*MockAPIKeysUseCase)(nil).RotateAPIKey), id)
+}
@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./audit_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./audit_repository.go -destination=./mocks/audit_repository.go -package mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	models "challenge_pyegros/app/models"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuditRepository is a mock of AuditRepository interface.
+type MockAuditRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditRepositoryMockRecorder is the mock recorder for MockAuditRepository.
+type MockAuditRepositoryMockRecorder struct {
+	mock *MockAuditRepository
+}
+
+// NewMockAuditRepository creates a new mock instance.
+func NewMockAuditRepository(ctrl *gomock.Controller) *MockAuditRepository {
+	mock := &MockAuditRepository{ctrl: ctrl}
+	mock.recorder = &MockAuditRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditRepository) EXPECT() *MockAuditRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetAuditEntries mocks base method.
+func (m *MockAuditRepository) GetAuditEntries(filters models.AuditFilters) ([]models.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditEntries", filters)
+	ret0, _ := ret[0].([]models.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditEntries indicates an expected call of GetAuditEntries.
+func (mr *MockAuditRepositoryMockRecorder) GetAuditEntries(filters any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditEntries", reflect.TypeOf((*MockAuditRepository)(nil).GetAuditEntries), filters)
+}
+
+// Record mocks base method.
+func (m *MockAuditRepository) Record(entry models.AuditEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAuditRepositoryMockRecorder) Record(entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAuditRepository)(nil).Record), entry)
+}
@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./audit_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./audit_usecase.go -destination=./mocks/audit_usecase.go -package mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	models "challenge_pyegros/app/models"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockAuditUseCase is a mock of AuditUseCase interface.
+type MockAuditUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockAuditUseCaseMockRecorder
+	isgomock struct{}
+}
+
+// MockAuditUseCaseMockRecorder is the mock recorder for MockAuditUseCase.
+type MockAuditUseCaseMockRecorder struct {
+	mock *MockAuditUseCase
+}
+
+// NewMockAuditUseCase creates a new mock instance.
+func NewMockAuditUseCase(ctrl *gomock.Controller) *MockAuditUseCase {
+	mock := &MockAuditUseCase{ctrl: ctrl}
+	mock.recorder = &MockAuditUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAuditUseCase) EXPECT() *MockAuditUseCaseMockRecorder {
+	return m.recorder
+}
+
+// GetAuditEntries mocks base method.
+func (m *MockAuditUseCase) GetAuditEntries(filters models.AuditFilters) ([]models.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditEntries", filters)
+	ret0, _ := ret[0].([]models.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditEntries indicates an expected call of GetAuditEntries.
+func (mr *MockAuditUseCaseMockRecorder) GetAuditEntries(filters any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditEntries", reflect.TypeOf((*MockAuditUseCase)(nil).GetAuditEntries), filters)
+}
+
+// Record mocks base method.
+func (m *MockAuditUseCase) Record(entry models.AuditEntry) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Record indicates an expected call of Record.
+func (mr *MockAuditUseCaseMockRecorder) Record(entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockAuditUseCase)(nil).Record), entry)
+}
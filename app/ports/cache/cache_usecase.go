@@ -0,0 +1,12 @@
+package ports
+
+import (
+	"challenge_pyegros/app/models"
+)
+
+//go:generate go run go.uber.org/mock/mockgen@v0.5.0 -source=./$GOFILE -destination=./mocks/$GOFILE -package mocks
+
+type CacheUseCase interface {
+	GetKeys(pattern string, limit int64) (*models.ResponseCacheKeys, error)
+	PurgeKeys(pattern string) (*models.ResponsePurge, error)
+}
@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./cache_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./cache_repository.go -destination=./mocks/cache_repository.go -package mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	models "challenge_pyegros/app/models"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCacheRepository is a mock of CacheRepository interface.
+type MockCacheRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockCacheRepositoryMockRecorder is the mock recorder for MockCacheRepository.
+type MockCacheRepositoryMockRecorder struct {
+	mock *MockCacheRepository
+}
+
+// NewMockCacheRepository creates a new mock instance.
+func NewMockCacheRepository(ctrl *gomock.Controller) *MockCacheRepository {
+	mock := &MockCacheRepository{ctrl: ctrl}
+	mock.recorder = &MockCacheRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheRepository) EXPECT() *MockCacheRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetKeys mocks base method.
+func (m *MockCacheRepository) GetKeys(pattern string, limit int64) (*models.ResponseCacheKeys, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeys", pattern, limit)
+	ret0, _ := ret[0].(*models.ResponseCacheKeys)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeys indicates an expected call of GetKeys.
+func (mr *MockCacheRepositoryMockRecorder) GetKeys(pattern, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeys", reflect.TypeOf((*MockCacheRepository)(nil).GetKeys), pattern, limit)
+}
+
+// PurgeKeys mocks base method.
+func (m *MockCacheRepository) PurgeKeys(pattern string) (*models.ResponsePurge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeKeys", pattern)
+	ret0, _ := ret[0].(*models.ResponsePurge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeKeys indicates an expected call of PurgeKeys.
+func (mr *MockCacheRepositoryMockRecorder) PurgeKeys(pattern any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeKeys", reflect.TypeOf((*MockCacheRepository)(nil).PurgeKeys), pattern)
+}
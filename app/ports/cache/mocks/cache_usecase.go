@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./cache_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./cache_usecase.go -destination=./mocks/cache_usecase.go -package mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	models "challenge_pyegros/app/models"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockCacheUseCase is a mock of CacheUseCase interface.
+type MockCacheUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheUseCaseMockRecorder
+	isgomock struct{}
+}
+
+// MockCacheUseCaseMockRecorder is the mock recorder for MockCacheUseCase.
+type MockCacheUseCaseMockRecorder struct {
+	mock *MockCacheUseCase
+}
+
+// NewMockCacheUseCase creates a new mock instance.
+func NewMockCacheUseCase(ctrl *gomock.Controller) *MockCacheUseCase {
+	mock := &MockCacheUseCase{ctrl: ctrl}
+	mock.recorder = &MockCacheUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheUseCase) EXPECT() *MockCacheUseCaseMockRecorder {
+	return m.recorder
+}
+
+// GetKeys mocks base method.
+func (m *MockCacheUseCase) GetKeys(pattern string, limit int64) (*models.ResponseCacheKeys, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetKeys", pattern, limit)
+	ret0, _ := ret[0].(*models.ResponseCacheKeys)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetKeys indicates an expected call of GetKeys.
+func (mr *MockCacheUseCaseMockRecorder) GetKeys(pattern, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetKeys", reflect.TypeOf((*MockCacheUseCase)(nil).GetKeys), pattern, limit)
+}
+
+// PurgeKeys mocks base method.
+func (m *MockCacheUseCase) PurgeKeys(pattern string) (*models.ResponsePurge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeKeys", pattern)
+	ret0, _ := ret[0].(*models.ResponsePurge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeKeys indicates an expected call of PurgeKeys.
+func (mr *MockCacheUseCaseMockRecorder) PurgeKeys(pattern any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeKeys", reflect.TypeOf((*MockCacheUseCase)(nil).PurgeKeys), pattern)
+}
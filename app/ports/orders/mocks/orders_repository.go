@@ -40,19 +40,49 @@ func (m *MockOrdersRepository) EXPECT() *MockOrdersRepositoryMockRecorder {
 	return m.recorder
 }
 
-// CreateOrder mocks base method.
-func (m *MockOrdersRepository) CreateOrder(order models.Order) (*models.ResponseCreate, error) {
+// FindOrderByID mocks base method.
+func (m *MockOrdersRepository) FindOrderByID(orderID int64) (*models.Order, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateOrder", order)
-	ret0, _ := ret[0].(*models.ResponseCreate)
+	ret := m.ctrl.Call(m, "FindOrderByID", orderID)
+	ret0, _ := ret[0].(*models.Order)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateOrder indicates an expected call of CreateOrder.
-func (mr *MockOrdersRepositoryMockRecorder) CreateOrder(order any) *gomock.Call {
+// FindOrderByID indicates an expected call of FindOrderByID.
+func (mr *MockOrdersRepositoryMockRecorder) FindOrderByID(orderID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockOrdersRepository)(nil).CreateOrder), order)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOrderByID", reflect.TypeOf((*MockOrdersRepository)(nil).FindOrderByID), orderID)
+}
+
+// FindOrdersByIDs mocks base method.
+func (m *MockOrdersRepository) FindOrdersByIDs(orderIDs []int64) ([]models.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindOrdersByIDs", orderIDs)
+	ret0, _ := ret[0].([]models.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindOrdersByIDs indicates an expected call of FindOrdersByIDs.
+func (mr *MockOrdersRepositoryMockRecorder) FindOrdersByIDs(orderIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindOrdersByIDs", reflect.TypeOf((*MockOrdersRepository)(nil).FindOrdersByIDs), orderIDs)
+}
+
+// GetBuyerOrders mocks base method.
+func (m *MockOrdersRepository) GetBuyerOrders(documentNumber string, filters models.BuyerFilters) (*models.ResponseBuyerOrders, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBuyerOrders", documentNumber, filters)
+	ret0, _ := ret[0].(*models.ResponseBuyerOrders)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBuyerOrders indicates an expected call of GetBuyerOrders.
+func (mr *MockOrdersRepositoryMockRecorder) GetBuyerOrders(documentNumber, filters any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBuyerOrders", reflect.TypeOf((*MockOrdersRepository)(nil).GetBuyerOrders), documentNumber, filters)
 }
 
 // GetOrderByFilters mocks base method.
@@ -70,32 +100,103 @@ func (mr *MockOrdersRepositoryMockRecorder) GetOrderByFilters(filters any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByFilters", reflect.TypeOf((*MockOrdersRepository)(nil).GetOrderByFilters), filters)
 }
 
-// GetOrderByID mocks base method.
-func (m *MockOrdersRepository) GetOrderByID(orderID int64) (*models.ResponseGet, error) {
+// InsertOrder mocks base method.
+func (m *MockOrdersRepository) InsertOrder(order models.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertOrder", order)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InsertOrder indicates an expected call of InsertOrder.
+func (mr *MockOrdersRepositoryMockRecorder) InsertOrder(order any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertOrder", reflect.TypeOf((*MockOrdersRepository)(nil).InsertOrder), order)
+}
+
+// ObtainID mocks base method.
+func (m *MockOrdersRepository) ObtainID() (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetOrderByID", orderID)
-	ret0, _ := ret[0].(*models.ResponseGet)
+	ret := m.ctrl.Call(m, "ObtainID")
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetOrderByID indicates an expected call of GetOrderByID.
-func (mr *MockOrdersRepositoryMockRecorder) GetOrderByID(orderID any) *gomock.Call {
+// ObtainID indicates an expected call of ObtainID.
+func (mr *MockOrdersRepositoryMockRecorder) ObtainID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObtainID", reflect.TypeOf((*MockOrdersRepository)(nil).ObtainID))
+}
+
+// ObtainIDBlock mocks base method.
+func (m *MockOrdersRepository) ObtainIDBlock(size int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ObtainIDBlock", size)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ObtainIDBlock indicates an expected call of ObtainIDBlock.
+func (mr *MockOrdersRepositoryMockRecorder) ObtainIDBlock(size any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ObtainIDBlock", reflect.TypeOf((*MockOrdersRepository)(nil).ObtainIDBlock), size)
+}
+
+// StreamOrdersByFilters mocks base method.
+func (m *MockOrdersRepository) StreamOrdersByFilters(filters models.Filters, fn func(models.Order) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamOrdersByFilters", filters, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamOrdersByFilters indicates an expected call of StreamOrdersByFilters.
+func (mr *MockOrdersRepositoryMockRecorder) StreamOrdersByFilters(filters, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamOrdersByFilters", reflect.TypeOf((*MockOrdersRepository)(nil).StreamOrdersByFilters), filters, fn)
+}
+
+// UpdateAmendedOrder mocks base method.
+func (m *MockOrdersRepository) UpdateAmendedOrder(order models.Order, event models.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAmendedOrder", order, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateAmendedOrder indicates an expected call of UpdateAmendedOrder.
+func (mr *MockOrdersRepositoryMockRecorder) UpdateAmendedOrder(order, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAmendedOrder", reflect.TypeOf((*MockOrdersRepository)(nil).UpdateAmendedOrder), order, event)
+}
+
+// UpdateOrderEvents mocks base method.
+func (m *MockOrdersRepository) UpdateOrderEvents(order models.Order, events []models.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrderEvents", order, events)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOrderEvents indicates an expected call of UpdateOrderEvents.
+func (mr *MockOrdersRepositoryMockRecorder) UpdateOrderEvents(order, events any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByID", reflect.TypeOf((*MockOrdersRepository)(nil).GetOrderByID), orderID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderEvents", reflect.TypeOf((*MockOrdersRepository)(nil).UpdateOrderEvents), order, events)
 }
 
-// UpdateEventOrder mocks base method.
-func (m *MockOrdersRepository) UpdateEventOrder(orderID int64, event models.Event) (*models.ResponseUpdate, error) {
+// UpdateOrdersEvents mocks base method.
+func (m *MockOrdersRepository) UpdateOrdersEvents(updates []models.OrderEvents) ([]error, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateEventOrder", orderID, event)
-	ret0, _ := ret[0].(*models.ResponseUpdate)
+	ret := m.ctrl.Call(m, "UpdateOrdersEvents", updates)
+	ret0, _ := ret[0].([]error)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// UpdateEventOrder indicates an expected call of UpdateEventOrder.
-func (mr *MockOrdersRepositoryMockRecorder) UpdateEventOrder(orderID, event any) *gomock.Call {
+// UpdateOrdersEvents indicates an expected call of UpdateOrdersEvents.
+func (mr *MockOrdersRepositoryMockRecorder) UpdateOrdersEvents(updates any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEventOrder", reflect.TypeOf((*MockOrdersRepository)(nil).UpdateEventOrder), orderID, event)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrdersEvents", reflect.TypeOf((*MockOrdersRepository)(nil).UpdateOrdersEvents), updates)
 }
@@ -40,6 +40,21 @@ func (m *MockOrdersUseCase) EXPECT() *MockOrdersUseCaseMockRecorder {
 	return m.recorder
 }
 
+// AmendOrder mocks base method.
+func (m *MockOrdersUseCase) AmendOrder(orderID int64, patch []byte, user string) (*models.ResponseAmend, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AmendOrder", orderID, patch, user)
+	ret0, _ := ret[0].(*models.ResponseAmend)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AmendOrder indicates an expected call of AmendOrder.
+func (mr *MockOrdersUseCaseMockRecorder) AmendOrder(orderID, patch, user any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AmendOrder", reflect.TypeOf((*MockOrdersUseCase)(nil).AmendOrder), orderID, patch, user)
+}
+
 // CreateOrder mocks base method.
 func (m *MockOrdersUseCase) CreateOrder(order models.Order) (*models.ResponseCreate, error) {
 	m.ctrl.T.Helper()
@@ -55,6 +70,36 @@ func (mr *MockOrdersUseCaseMockRecorder) CreateOrder(order any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockOrdersUseCase)(nil).CreateOrder), order)
 }
 
+// CreateOrders mocks base method.
+func (m *MockOrdersUseCase) CreateOrders(orders []models.Order) ([]models.BulkOrderResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrders", orders)
+	ret0, _ := ret[0].([]models.BulkOrderResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrders indicates an expected call of CreateOrders.
+func (mr *MockOrdersUseCaseMockRecorder) CreateOrders(orders any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrders", reflect.TypeOf((*MockOrdersUseCase)(nil).CreateOrders), orders)
+}
+
+// GetBuyerOrders mocks base method.
+func (m *MockOrdersUseCase) GetBuyerOrders(documentNumber string, filters models.BuyerFilters) (*models.ResponseBuyerOrders, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBuyerOrders", documentNumber, filters)
+	ret0, _ := ret[0].(*models.ResponseBuyerOrders)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBuyerOrders indicates an expected call of GetBuyerOrders.
+func (mr *MockOrdersUseCaseMockRecorder) GetBuyerOrders(documentNumber, filters any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBuyerOrders", reflect.TypeOf((*MockOrdersUseCase)(nil).GetBuyerOrders), documentNumber, filters)
+}
+
 // GetOrderByFilters mocks base method.
 func (m *MockOrdersUseCase) GetOrderByFilters(filters models.Filters) ([]models.Order, error) {
 	m.ctrl.T.Helper()
@@ -85,6 +130,35 @@ func (mr *MockOrdersUseCaseMockRecorder) GetOrderByID(orderID any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByID", reflect.TypeOf((*MockOrdersUseCase)(nil).GetOrderByID), orderID)
 }
 
+// GetOrderEvents mocks base method.
+func (m *MockOrdersUseCase) GetOrderEvents(orderID int64, filters models.EventFilters) (*models.ResponseEvents, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderEvents", orderID, filters)
+	ret0, _ := ret[0].(*models.ResponseEvents)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderEvents indicates an expected call of GetOrderEvents.
+func (mr *MockOrdersUseCaseMockRecorder) GetOrderEvents(orderID, filters any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderEvents", reflect.TypeOf((*MockOrdersUseCase)(nil).GetOrderEvents), orderID, filters)
+}
+
+// StreamOrdersByFilters mocks base method.
+func (m *MockOrdersUseCase) StreamOrdersByFilters(filters models.Filters, fn func(models.Order) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamOrdersByFilters", filters, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamOrdersByFilters indicates an expected call of StreamOrdersByFilters.
+func (mr *MockOrdersUseCaseMockRecorder) StreamOrdersByFilters(filters, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamOrdersByFilters", reflect.TypeOf((*MockOrdersUseCase)(nil).StreamOrdersByFilters), filters, fn)
+}
+
 // UpdateEventOrder mocks base method.
 func (m *MockOrdersUseCase) UpdateEventOrder(orderID int64, event models.Event) (*models.ResponseUpdate, error) {
 	m.ctrl.T.Helper()
@@ -99,3 +173,18 @@ func (mr *MockOrdersUseCaseMockRecorder) UpdateEventOrder(orderID, event any) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEventOrder", reflect.TypeOf((*MockOrdersUseCase)(nil).UpdateEventOrder), orderID, event)
 }
+
+// UpdateEventOrders mocks base method.
+func (m *MockOrdersUseCase) UpdateEventOrders(items []models.BulkEventItem, channel models.Channel) ([]models.BulkEventResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateEventOrders", items, channel)
+	ret0, _ := ret[0].([]models.BulkEventResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateEventOrders indicates an expected call of UpdateEventOrders.
+func (mr *MockOrdersUseCaseMockRecorder) UpdateEventOrders(items, channel any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateEventOrders", reflect.TypeOf((*MockOrdersUseCase)(nil).UpdateEventOrders), items, channel)
+}
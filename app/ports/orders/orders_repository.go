@@ -2,13 +2,26 @@ package ports
 
 import (
 	"challenge_pyegros/app/models"
+	"errors"
 )
 
+// ErrOrderChanged is returned by the updates of the events and the amendments
+// when the order was written by another request after it was read, they must
+// be applied again to the current order.
+var ErrOrderChanged = errors.New("The order was changed by another request, try again")
+
 //go:generate go run go.uber.org/mock/mockgen@v0.5.0 -source=./$GOFILE -destination=./mocks/$GOFILE -package mocks
 
 type OrdersRepository interface {
-	CreateOrder(order models.Order) (*models.ResponseCreate, error)
-	UpdateEventOrder(orderID int64, event models.Event) (*models.ResponseUpdate, error)
-	GetOrderByID(orderID int64) (*models.ResponseGet, error)
+	ObtainID() (int64, error)
+	ObtainIDBlock(size int64) (int64, error)
+	InsertOrder(order models.Order) error
+	FindOrderByID(orderID int64) (*models.Order, error)
+	FindOrdersByIDs(orderIDs []int64) ([]models.Order, error)
+	UpdateOrderEvents(order models.Order, events []models.Event) error
+	UpdateOrdersEvents(updates []models.OrderEvents) ([]error, error)
+	UpdateAmendedOrder(order models.Order, event models.Event) error
 	GetOrderByFilters(filters models.Filters) ([]models.Order, error)
+	StreamOrdersByFilters(filters models.Filters, fn func(order models.Order) error) error
+	GetBuyerOrders(documentNumber string, filters models.BuyerFilters) (*models.ResponseBuyerOrders, error)
 }
@@ -8,7 +8,13 @@ import (
 
 type OrdersUseCase interface {
 	CreateOrder(order models.Order) (*models.ResponseCreate, error)
+	CreateOrders(orders []models.Order) ([]models.BulkOrderResult, error)
 	UpdateEventOrder(orderID int64, event models.Event) (*models.ResponseUpdate, error)
+	UpdateEventOrders(items []models.BulkEventItem, channel models.Channel) ([]models.BulkEventResult, error)
+	AmendOrder(orderID int64, patch []byte, user string) (*models.ResponseAmend, error)
 	GetOrderByID(orderID int64) (*models.ResponseGet, error)
 	GetOrderByFilters(filters models.Filters) ([]models.Order, error)
+	StreamOrdersByFilters(filters models.Filters, fn func(order models.Order) error) error
+	GetBuyerOrders(documentNumber string, filters models.BuyerFilters) (*models.ResponseBuyerOrders, error)
+	GetOrderEvents(orderID int64, filters models.EventFilters) (*models.ResponseEvents, error)
 }
@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./reports_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./reports_repository.go -destination=./mocks/reports_repository.go -package mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	models "challenge_pyegros/app/models"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockReportsRepository is a mock of ReportsRepository interface.
+type MockReportsRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockReportsRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockReportsRepositoryMockRecorder is the mock recorder for MockReportsRepository.
+type MockReportsRepositoryMockRecorder struct {
+	mock *MockReportsRepository
+}
+
+// NewMockReportsRepository creates a new mock instance.
+func NewMockReportsRepository(ctrl *gomock.Controller) *MockReportsRepository {
+	mock := &MockReportsRepository{ctrl: ctrl}
+	mock.recorder = &MockReportsRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReportsRepository) EXPECT() *MockReportsRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetReasonsReport mocks base method.
+func (m *MockReportsRepository) GetReasonsReport(filters models.ReportFilters) ([]models.ReasonReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReasonsReport", filters)
+	ret0, _ := ret[0].([]models.ReasonReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReasonsReport indicates an expected call of GetReasonsReport.
+func (mr *MockReportsRepositoryMockRecorder) GetReasonsReport(filters any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReasonsReport", reflect.TypeOf((*MockReportsRepository)(nil).GetReasonsReport), filters)
+}
+
+// GetSalesReport mocks base method.
+func (m *MockReportsRepository) GetSalesReport(filters models.SalesFilters) (*models.ResponseSalesReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSalesReport", filters)
+	ret0, _ := ret[0].(*models.ResponseSalesReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSalesReport indicates an expected call of GetSalesReport.
+func (mr *MockReportsRepositoryMockRecorder) GetSalesReport(filters any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSalesReport", reflect.TypeOf((*MockReportsRepository)(nil).GetSalesReport), filters)
+}
@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./reports_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=./reports_usecase.go -destination=./mocks/reports_usecase.go -package mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	models "challenge_pyegros/app/models"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockReportsUseCase is a mock of ReportsUseCase interface.
+type MockReportsUseCase struct {
+	ctrl     *gomock.Controller
+	recorder *MockReportsUseCaseMockRecorder
+	isgomock struct{}
+}
+
+// MockReportsUseCaseMockRecorder is the mock recorder for MockReportsUseCase.
+type MockReportsUseCaseMockRecorder struct {
+	mock *MockReportsUseCase
+}
+
+// NewMockReportsUseCase creates a new mock instance.
+func NewMockReportsUseCase(ctrl *gomock.Controller) *MockReportsUseCase {
+	mock := &MockReportsUseCase{ctrl: ctrl}
+	mock.recorder = &MockReportsUseCaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReportsUseCase) EXPECT() *MockReportsUseCaseMockRecorder {
+	return m.recorder
+}
+
+// GetReasonsReport mocks base method.
+func (m *MockReportsUseCase) GetReasonsReport(filters models.ReportFilters) ([]models.ReasonReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetReasonsReport", filters)
+	ret0, _ := ret[0].([]models.ReasonReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetReasonsReport indicates an expected call of GetReasonsReport.
+func (mr *MockReportsUseCaseMockRecorder) GetReasonsReport(filters any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetReasonsReport", reflect.TypeOf((*MockReportsUseCase)(nil).GetReasonsReport), filters)
+}
+
+// GetSalesReport mocks base method.
+func (m *MockReportsUseCase) GetSalesReport(filters models.SalesFilters) (*models.ResponseSalesReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSalesReport", filters)
+	ret0, _ := ret[0].(*models.ResponseSalesReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSalesReport indicates an expected call of GetSalesReport.
+func (mr *MockReportsUseCaseMockRecorder) GetSalesReport(filters any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSalesReport", reflect.TypeOf((*MockReportsUseCase)(nil).GetSalesReport), filters)
+}
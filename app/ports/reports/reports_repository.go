@@ -0,0 +1,12 @@
+package ports
+
+import (
+	"challenge_pyegros/app/models"
+)
+
+//go:generate go run go.uber.org/mock/mockgen@v0.5.0 -source=./$GOFILE -destination=./mocks/$GOFILE -package mocks
+
+type ReportsRepository interface {
+	GetReasonsReport(filters models.ReportFilters) ([]models.ReasonReport, error)
+	GetSalesReport(filters models.SalesFilters) (*models.ResponseSalesReport, error)
+}
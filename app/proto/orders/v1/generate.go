@@ -0,0 +1,4 @@
+// Package ordersv1 is the gRPC API of the orders, generated from orders.proto.
+package ordersv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative orders/v1/orders.proto
@@ -0,0 +1,1317 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: orders/v1/orders.proto
+
+package ordersv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type OrderStatus int32
+
+const (
+	OrderStatus_ORDER_STATUS_UNSPECIFIED        OrderStatus = 0
+	OrderStatus_ORDER_STATUS_CREATED            OrderStatus = 1
+	OrderStatus_ORDER_STATUS_PAYMENT_RECEIVED   OrderStatus = 2
+	OrderStatus_ORDER_STATUS_CANCELED           OrderStatus = 3
+	OrderStatus_ORDER_STATUS_INVOICED           OrderStatus = 4
+	OrderStatus_ORDER_STATUS_RETURNED           OrderStatus = 5
+	OrderStatus_ORDER_STATUS_PARTIALLY_RETURNED OrderStatus = 6
+)
+
+// Enum value maps for OrderStatus.
+var (
+	OrderStatus_name = map[int32]string{
+		0: "ORDER_STATUS_UNSPECIFIED",
+		1: "ORDER_STATUS_CREATED",
+		2: "ORDER_STATUS_PAYMENT_RECEIVED",
+		3: "ORDER_STATUS_CANCELED",
+		4: "ORDER_STATUS_INVOICED",
+		5: "ORDER_STATUS_RETURNED",
+		6: "ORDER_STATUS_PARTIALLY_RETURNED",
+	}
+	OrderStatus_value = map[string]int32{
+		"ORDER_STATUS_UNSPECIFIED":        0,
+		"ORDER_STATUS_CREATED":            1,
+		"ORDER_STATUS_PAYMENT_RECEIVED":   2,
+		"ORDER_STATUS_CANCELED":           3,
+		"ORDER_STATUS_INVOICED":           4,
+		"ORDER_STATUS_RETURNED":           5,
+		"ORDER_STATUS_PARTIALLY_RETURNED": 6,
+	}
+)
+
+func (x OrderStatus) Enum() *OrderStatus {
+	p := new(OrderStatus)
+	*p = x
+	return p
+}
+
+func (x OrderStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (OrderStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_orders_v1_orders_proto_enumTypes[0].Descriptor()
+}
+
+func (OrderStatus) Type() protoreflect.EnumType {
+	return &file_orders_v1_orders_proto_enumTypes[0]
+}
+
+func (x OrderStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use OrderStatus.Descriptor instead.
+func (OrderStatus) EnumDescriptor() ([]byte, []int) {
+	return file_orders_v1_orders_proto_rawDescGZIP(), []int{0}
+}
+
+type EventType int32
+
+const (
+	EventType_EVENT_TYPE_UNSPECIFIED      EventType = 0
+	EventType_EVENT_TYPE_PAYMENT_RECEIVED EventType = 1
+	EventType_EVENT_TYPE_CANCELED         EventType = 2
+	EventType_EVENT_TYPE_INVOICED         EventType = 3
+	EventType_EVENT_TYPE_RETURNED         EventType = 4
+	EventType_EVENT_TYPE_AMENDED          EventType = 5
+)
+
+// Enum value maps for EventType.
+var (
+	EventType_name = map[int32]string{
+		0: "EVENT_TYPE_UNSPECIFIED",
+		1: "EVENT_TYPE_PAYMENT_RECEIVED",
+		2: "EVENT_TYPE_CANCELED",
+		3: "EVENT_TYPE_INVOICED",
+		4: "EVENT_TYPE_RETURNED",
+		5: "EVENT_TYPE_AMENDED",
+	}
+	EventType_value = map[string]int32{
+		"EVENT_TYPE_UNSPECIFIED":      0,
+		"EVENT_TYPE_PAYMENT_RECEIVED": 1,
+		"EVENT_TYPE_CANCELED":         2,
+		"EVENT_TYPE_INVOICED":         3,
+		"EVENT_TYPE_RETURNED":         4,
+		"EVENT_TYPE_AMENDED":          5,
+	}
+)
+
+func (x EventType) Enum() *EventType {
+	p := new(EventType)
+	*p = x
+	return p
+}
+
+func (x EventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_orders_v1_orders_proto_enumTypes[1].Descriptor()
+}
+
+func (EventType) Type() protoreflect.EnumType {
+	return &file_orders_v1_orders_proto_enumTypes[1]
+}
+
+func (x EventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventType.Descriptor instead.
+func (EventType) EnumDescriptor() ([]byte, []int) {
+	return file_orders_v1_orders_proto_rawDescGZIP(), []int{1}
+}
+
+type Channel int32
+
+const (
+	Channel_CHANNEL_UNSPECIFIED Channel = 0
+	Channel_CHANNEL_ECOMMERCE   Channel = 1
+	Channel_CHANNEL_CALL_CENTER Channel = 2
+	Channel_CHANNEL_STORE       Channel = 3
+	Channel_CHANNEL_AFFILIATE   Channel = 4
+)
+
+// Enum value maps for Channel.
+var (
+	Channel_name = map[int32]string{
+		0: "CHANNEL_UNSPECIFIED",
+		1: "CHANNEL_ECOMMERCE",
+		2: "CHANNEL_CALL_CENTER",
+		3: "CHANNEL_STORE",
+		4: "CHANNEL_AFFILIATE",
+	}
+	Channel_value = map[string]int32{
+		"CHANNEL_UNSPECIFIED": 0,
+		"CHANNEL_ECOMMERCE":   1,
+		"CHANNEL_CALL_CENTER": 2,
+		"CHANNEL_STORE":       3,
+		"CHANNEL_AFFILIATE":   4,
+	}
+)
+
+func (x Channel) Enum() *Channel {
+	p := new(Channel)
+	*p = x
+	return p
+}
+
+func (x Channel) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Channel) Descriptor() protoreflect.EnumDescriptor {
+	return file_orders_v1_orders_proto_enumTypes[2].Descriptor()
+}
+
+func (Channel) Type() protoreflect.EnumType {
+	return &file_orders_v1_orders_proto_enumTypes[2]
+}
+
+func (x Channel) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Channel.Descriptor instead.
+func (Channel) EnumDescriptor() ([]byte, []int) {
+	return file_orders_v1_orders_proto_rawDescGZIP(), []int{2}
+}
+
+type Buyer struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	FirstName      string                 `protobuf:"bytes,1,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
+	LastName       string                 `protobuf:"bytes,2,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
+	DocumentNumber string                 `protobuf:"bytes,3,opt,name=document_number,json=documentNumber,proto3" json:"document_number,omitempty"`
+	Phone          string                 `protobuf:"bytes,4,opt,name=phone,proto3" json:"phone,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Buyer) Reset() {
+	*x = Buyer{}
+	mi := &file_orders_v1_orders_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Buyer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Buyer) ProtoMessage() {}
+
+func (x *Buyer) ProtoReflect() protoreflect.Message {
+	mi := &file_orders_v1_orders_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Buyer.ProtoReflect.Descriptor instead.
+func (*Buyer) Descriptor() ([]byte, []int) {
+	return file_orders_v1_orders_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Buyer) GetFirstName() string {
+	if x != nil {
+		return x.FirstName
+	}
+	return ""
+}
+
+func (x *Buyer) GetLastName() string {
+	if x != nil {
+		return x.LastName
+	}
+	return ""
+}
+
+func (x *Buyer) GetDocumentNumber() string {
+	if x != nil {
+		return x.DocumentNumber
+	}
+	return ""
+}
+
+func (x *Buyer) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+type Product struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Sku              string                 `protobuf:"bytes,1,opt,name=sku,proto3" json:"sku,omitempty"`
+	Name             string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description      string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Price            float64                `protobuf:"fixed64,4,opt,name=price,proto3" json:"price,omitempty"`
+	Quantity         int64                  `protobuf:"varint,5,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	ReturnedQuantity int64                  `protobuf:"varint,6,opt,name=returned_quantity,json=returnedQuantity,proto3" json:"returned_quantity,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Product) Reset() {
+	*x = Product{}
+	mi := &file_orders_v1_orders_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Product) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Product) ProtoMessage() {}
+
+func (x *Product) ProtoReflect() protoreflect.Message {
+	mi := &file_orders_v1_orders_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Product.ProtoReflect.Descriptor instead.
+func (*Product) Descriptor() ([]byte, []int) {
+	return file_orders_v1_orders_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Product) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *Product) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Product) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Product) GetPrice() float64 {
+	if x != nil {
+		return x.Price
+	}
+	return 0
+}
+
+func (x *Product) GetQuantity() int64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+func (x *Product) GetReturnedQuantity() int64 {
+	if x != nil {
+		return x.ReturnedQuantity
+	}
+	return 0
+}
+
+type ReturnedItem struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sku           string                 `protobuf:"bytes,1,opt,name=sku,proto3" json:"sku,omitempty"`
+	Quantity      int64                  `protobuf:"varint,2,opt,name=quantity,proto3" json:"quantity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReturnedItem) Reset() {
+	*x = ReturnedItem{}
+	mi := &file_orders_v1_orders_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReturnedItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReturnedItem) ProtoMessage() {}
+
+func (x *ReturnedItem) ProtoReflect() protoreflect.Message {
+	mi := &file_orders_v1_orders_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReturnedItem.ProtoReflect.Descriptor instead.
+func (*ReturnedItem) Descriptor() ([]byte, []int) {
+	return file_orders_v1_orders_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ReturnedItem) GetSku() string {
+	if x != nil {
+		return x.Sku
+	}
+	return ""
+}
+
+func (x *ReturnedItem) GetQuantity() int64 {
+	if x != nil {
+		return x.Quantity
+	}
+	return 0
+}
+
+type EventReason struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Code          string                 `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Comment       string                 `protobuf:"bytes,2,opt,name=comment,proto3" json:"comment,omitempty"`
+	Items         []*ReturnedItem        `protobuf:"bytes,3,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventReason) Reset() {
+	*x = EventReason{}
+	mi := &file_orders_v1_orders_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventReason) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventReason) ProtoMessage() {}
+
+func (x *EventReason) ProtoReflect() protoreflect.Message {
+	mi := &file_orders_v1_orders_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventReason.ProtoReflect.Descriptor instead.
+func (*EventReason) Descriptor() ([]byte, []int) {
+	return file_orders_v1_orders_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *EventReason) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *EventReason) GetComment() string {
+	if x != nil {
+		return x.Comment
+	}
+	return ""
+}
+
+func (x *EventReason) GetItems() []*ReturnedItem {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+type FieldChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Field         string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	From          string                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FieldChange) Reset() {
+	*x = FieldChange{}
+	mi := &file_orders_v1_orders_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FieldChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FieldChange) ProtoMessage() {}
+
+func (x *FieldChange) ProtoReflect() protoreflect.Message {
+	mi := &file_orders_v1_orders_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FieldChange.ProtoReflect.Descriptor instead.
+func (*FieldChange) Descriptor() ([]byte, []int) {
+	return file_orders_v1_orders_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FieldChange) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *FieldChange) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *FieldChange) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+type Event struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type          EventType              `protobuf:"varint,2,opt,name=type,proto3,enum=orders.v1.EventType" json:"type,omitempty"`
+	Date          string                 `protobuf:"bytes,3,opt,name=date,proto3" json:"date,omitempty"`
+	User          string                 `protobuf:"bytes,4,opt,name=user,proto3" json:"user,omitempty"`
+	Reason        *EventReason           `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	Changes       []*FieldChange         `protobuf:"bytes,6,rep,name=changes,proto3" json:"changes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_orders_v1_orders_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_orders_v1_orders_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_orders_v1_orders_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Event) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Event) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *Event) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *Event) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *Event) GetReason() *EventReason {
+	if x != nil {
+		return x.Reason
+	}
+	return nil
+}
+
+func (x *Event) GetChanges() []*FieldChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+type Order struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	OrderId             int64                  `protobuf:"varint,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	ExternalReferenceId string                 `protobuf:"bytes,2,opt,name=external_reference_id,json=externalReferenceId,proto3" json:"external_reference_id,omitempty"`
+	Channel             Channel                `protobuf:"varint,3,opt,name=channel,proto3,enum=orders.v1.Channel" json:"channel,omitempty"`
+	PurchaseDate        string                 `protobuf:"bytes,4,opt,name=purchase_date,json=purchaseDate,proto3" json:"purchase_date,omitempty"`
+	TotalValue          float64                `protobuf:"fixed64,5,opt,name=total_value,json=totalValue,proto3" json:"total_value,omitempty"`
+	RefundedAmount      float64                `protobuf:"fixed64,6,opt,name=refunded_amount,json=refundedAmount,proto3" json:"refunded_amount,omitempty"`
+	Buyer               *Buyer                 `protobuf:"bytes,7,opt,name=buyer,proto3" json:"buyer,omitempty"`
+	Products            []*Product             `protobuf:"bytes,8,rep,name=products,proto3" json:"products,omitempty"`
+	Status              OrderStatus            `protobuf:"varint,9,opt,name=status,proto3,enum=orders.v1.OrderStatus" json:"status,omitempty"`
+	Events              []*Event               `protobuf:"bytes,10,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *Order) Reset() {
+	*x = Order{}
+	mi := &file_orders_v1_orders_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Order) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Order) ProtoMessage() {}
+
+func (x *Order) ProtoReflect() protoreflect.Message {
+	mi := &file_orders_v1_orders_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Order.ProtoReflect.Descriptor instead.
+func (*Order) Descriptor() ([]byte, []int) {
+	return file_orders_v1_orders_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Order) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+func (x *Order) GetExternalReferenceId() string {
+	if x != nil {
+		return x.ExternalReferenceId
+	}
+	return ""
+}
+
+func (x *Order) GetChannel() Channel {
+	if x != nil {
+		return x.Channel
+	}
+	return Channel_CHANNEL_UNSPECIFIED
+}
+
+func (x *Order) GetPurchaseDate() string {
+	if x != nil {
+		return x.PurchaseDate
+	}
+	return ""
+}
+
+func (x *Order) GetTotalValue() float64 {
+	if x != nil {
+		return x.TotalValue
+	}
+	return 0
+}
+
+func (x *Order) GetRefundedAmount() float64 {
+	if x != nil {
+		return x.RefundedAmount
+	}
+	return 0
+}
+
+func (x *Order) GetBuyer() *Buyer {
+	if x != nil {
+		return x.Buyer
+	}
+	return nil
+}
+
+func (x *Order) GetProducts() []*Product {
+	if x != nil {
+		return x.Products
+	}
+	return nil
+}
+
+func (x *Order) GetStatus() OrderStatus {
+	if x != nil {
+		return x.Status
+	}
+	return OrderStatus_ORDER_STATUS_UNSPECIFIED
+}
+
+func (x *Order) GetEvents() []*Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+// CreateOrderRequest ignores the ID, status and events of the order.
+type CreateOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Order         *Order                 `protobuf:"bytes,1,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateOrderRequest) Reset() {
+	*x = CreateOrderRequest{}
+	mi := &file_orders_v1_orders_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateOrderRequest) ProtoMessage() {}
+
+func (x *CreateOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orders_v1_orders_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateOrderRequest.ProtoReflect.Descriptor instead.
+func (*CreateOrderRequest) Descriptor() ([]byte, []int) {
+	return file_orders_v1_orders_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CreateOrderRequest) GetOrder() *Order {
+	if x != nil {
+		return x.Order
+	}
+	return nil
+}
+
+type CreateOrderResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       int64                  `protobuf:"varint,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Status        OrderStatus            `protobuf:"varint,2,opt,name=status,proto3,enum=orders.v1.OrderStatus" json:"status,omitempty"`
+	UpdatedOn     string                 `protobuf:"bytes,3,opt,name=updated_on,json=updatedOn,proto3" json:"updated_on,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateOrderResponse) Reset() {
+	*x = CreateOrderResponse{}
+	mi := &file_orders_v1_orders_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateOrderResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateOrderResponse) ProtoMessage() {}
+
+func (x *CreateOrderResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_orders_v1_orders_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateOrderResponse.ProtoReflect.Descriptor instead.
+func (*CreateOrderResponse) Descriptor() ([]byte, []int) {
+	return file_orders_v1_orders_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *CreateOrderResponse) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+func (x *CreateOrderResponse) GetStatus() OrderStatus {
+	if x != nil {
+		return x.Status
+	}
+	return OrderStatus_ORDER_STATUS_UNSPECIFIED
+}
+
+func (x *CreateOrderResponse) GetUpdatedOn() string {
+	if x != nil {
+		return x.UpdatedOn
+	}
+	return ""
+}
+
+// AddEventRequest ignores the user of the event, which is the authenticated
+// subject.
+type AddEventRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       int64                  `protobuf:"varint,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	Event         *Event                 `protobuf:"bytes,2,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddEventRequest) Reset() {
+	*x = AddEventRequest{}
+	mi := &file_orders_v1_orders_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddEventRequest) ProtoMessage() {}
+
+func (x *AddEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orders_v1_orders_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddEventRequest.ProtoReflect.Descriptor instead.
+func (*AddEventRequest) Descriptor() ([]byte, []int) {
+	return file_orders_v1_orders_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *AddEventRequest) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+func (x *AddEventRequest) GetEvent() *Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+type AddEventResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	OrderId        int64                  `protobuf:"varint,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	PreviousStatus OrderStatus            `protobuf:"varint,2,opt,name=previous_status,json=previousStatus,proto3,enum=orders.v1.OrderStatus" json:"previous_status,omitempty"`
+	NewStatus      OrderStatus            `protobuf:"varint,3,opt,name=new_status,json=newStatus,proto3,enum=orders.v1.OrderStatus" json:"new_status,omitempty"`
+	UpdatedOn      string                 `protobuf:"bytes,4,opt,name=updated_on,json=updatedOn,proto3" json:"updated_on,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *AddEventResponse) Reset() {
+	*x = AddEventResponse{}
+	mi := &file_orders_v1_orders_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddEventResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddEventResponse) ProtoMessage() {}
+
+func (x *AddEventResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_orders_v1_orders_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddEventResponse.ProtoReflect.Descriptor instead.
+func (*AddEventResponse) Descriptor() ([]byte, []int) {
+	return file_orders_v1_orders_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *AddEventResponse) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+func (x *AddEventResponse) GetPreviousStatus() OrderStatus {
+	if x != nil {
+		return x.PreviousStatus
+	}
+	return OrderStatus_ORDER_STATUS_UNSPECIFIED
+}
+
+func (x *AddEventResponse) GetNewStatus() OrderStatus {
+	if x != nil {
+		return x.NewStatus
+	}
+	return OrderStatus_ORDER_STATUS_UNSPECIFIED
+}
+
+func (x *AddEventResponse) GetUpdatedOn() string {
+	if x != nil {
+		return x.UpdatedOn
+	}
+	return ""
+}
+
+type GetOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       int64                  `protobuf:"varint,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetOrderRequest) Reset() {
+	*x = GetOrderRequest{}
+	mi := &file_orders_v1_orders_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetOrderRequest) ProtoMessage() {}
+
+func (x *GetOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orders_v1_orders_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetOrderRequest.ProtoReflect.Descriptor instead.
+func (*GetOrderRequest) Descriptor() ([]byte, []int) {
+	return file_orders_v1_orders_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetOrderRequest) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+type SearchOrdersRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	OrderId        int64                  `protobuf:"varint,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	DocumentNumber string                 `protobuf:"bytes,2,opt,name=document_number,json=documentNumber,proto3" json:"document_number,omitempty"`
+	Status         OrderStatus            `protobuf:"varint,3,opt,name=status,proto3,enum=orders.v1.OrderStatus" json:"status,omitempty"`
+	Channel        Channel                `protobuf:"varint,4,opt,name=channel,proto3,enum=orders.v1.Channel" json:"channel,omitempty"`
+	CreatedOnFrom  string                 `protobuf:"bytes,5,opt,name=created_on_from,json=createdOnFrom,proto3" json:"created_on_from,omitempty"`
+	CreatedOnTo    string                 `protobuf:"bytes,6,opt,name=created_on_to,json=createdOnTo,proto3" json:"created_on_to,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SearchOrdersRequest) Reset() {
+	*x = SearchOrdersRequest{}
+	mi := &file_orders_v1_orders_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchOrdersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchOrdersRequest) ProtoMessage() {}
+
+func (x *SearchOrdersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orders_v1_orders_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchOrdersRequest.ProtoReflect.Descriptor instead.
+func (*SearchOrdersRequest) Descriptor() ([]byte, []int) {
+	return file_orders_v1_orders_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SearchOrdersRequest) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+func (x *SearchOrdersRequest) GetDocumentNumber() string {
+	if x != nil {
+		return x.DocumentNumber
+	}
+	return ""
+}
+
+func (x *SearchOrdersRequest) GetStatus() OrderStatus {
+	if x != nil {
+		return x.Status
+	}
+	return OrderStatus_ORDER_STATUS_UNSPECIFIED
+}
+
+func (x *SearchOrdersRequest) GetChannel() Channel {
+	if x != nil {
+		return x.Channel
+	}
+	return Channel_CHANNEL_UNSPECIFIED
+}
+
+func (x *SearchOrdersRequest) GetCreatedOnFrom() string {
+	if x != nil {
+		return x.CreatedOnFrom
+	}
+	return ""
+}
+
+func (x *SearchOrdersRequest) GetCreatedOnTo() string {
+	if x != nil {
+		return x.CreatedOnTo
+	}
+	return ""
+}
+
+type WatchOrderRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OrderId       int64                  `protobuf:"varint,1,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchOrderRequest) Reset() {
+	*x = WatchOrderRequest{}
+	mi := &file_orders_v1_orders_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchOrderRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchOrderRequest) ProtoMessage() {}
+
+func (x *WatchOrderRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_orders_v1_orders_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchOrderRequest.ProtoReflect.Descriptor instead.
+func (*WatchOrderRequest) Descriptor() ([]byte, []int) {
+	return file_orders_v1_orders_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *WatchOrderRequest) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+var File_orders_v1_orders_proto protoreflect.FileDescriptor
+
+const file_orders_v1_orders_proto_rawDesc = "" +
+	"\n" +
+	"\x16orders/v1/orders.proto\x12\torders.v1\"\x82\x01\n" +
+	"\x05Buyer\x12\x1d\n" +
+	"\n" +
+	"first_name\x18\x01 \x01(\tR\tfirstName\x12\x1b\n" +
+	"\tlast_name\x18\x02 \x01(\tR\blastName\x12'\n" +
+	"\x0fdocument_number\x18\x03 \x01(\tR\x0edocumentNumber\x12\x14\n" +
+	"\x05phone\x18\x04 \x01(\tR\x05phone\"\xb0\x01\n" +
+	"\aProduct\x12\x10\n" +
+	"\x03sku\x18\x01 \x01(\tR\x03sku\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x14\n" +
+	"\x05price\x18\x04 \x01(\x01R\x05price\x12\x1a\n" +
+	"\bquantity\x18\x05 \x01(\x03R\bquantity\x12+\n" +
+	"\x11returned_quantity\x18\x06 \x01(\x03R\x10returnedQuantity\"<\n" +
+	"\fReturnedItem\x12\x10\n" +
+	"\x03sku\x18\x01 \x01(\tR\x03sku\x12\x1a\n" +
+	"\bquantity\x18\x02 \x01(\x03R\bquantity\"j\n" +
+	"\vEventReason\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x18\n" +
+	"\acomment\x18\x02 \x01(\tR\acomment\x12-\n" +
+	"\x05items\x18\x03 \x03(\v2\x17.orders.v1.ReturnedItemR\x05items\"G\n" +
+	"\vFieldChange\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\"\xcb\x01\n" +
+	"\x05Event\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12(\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x14.orders.v1.EventTypeR\x04type\x12\x12\n" +
+	"\x04date\x18\x03 \x01(\tR\x04date\x12\x12\n" +
+	"\x04user\x18\x04 \x01(\tR\x04user\x12.\n" +
+	"\x06reason\x18\x05 \x01(\v2\x16.orders.v1.EventReasonR\x06reason\x120\n" +
+	"\achanges\x18\x06 \x03(\v2\x16.orders.v1.FieldChangeR\achanges\"\xa5\x03\n" +
+	"\x05Order\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\x03R\aorderId\x122\n" +
+	"\x15external_reference_id\x18\x02 \x01(\tR\x13externalReferenceId\x12,\n" +
+	"\achannel\x18\x03 \x01(\x0e2\x12.orders.v1.ChannelR\achannel\x12#\n" +
+	"\rpurchase_date\x18\x04 \x01(\tR\fpurchaseDate\x12\x1f\n" +
+	"\vtotal_value\x18\x05 \x01(\x01R\n" +
+	"totalValue\x12'\n" +
+	"\x0frefunded_amount\x18\x06 \x01(\x01R\x0erefundedAmount\x12&\n" +
+	"\x05buyer\x18\a \x01(\v2\x10.orders.v1.BuyerR\x05buyer\x12.\n" +
+	"\bproducts\x18\b \x03(\v2\x12.orders.v1.ProductR\bproducts\x12.\n" +
+	"\x06status\x18\t \x01(\x0e2\x16.orders.v1.OrderStatusR\x06status\x12(\n" +
+	"\x06events\x18\n" +
+	" \x03(\v2\x10.orders.v1.EventR\x06events\"<\n" +
+	"\x12CreateOrderRequest\x12&\n" +
+	"\x05order\x18\x01 \x01(\v2\x10.orders.v1.OrderR\x05order\"\x7f\n" +
+	"\x13CreateOrderResponse\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\x03R\aorderId\x12.\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x16.orders.v1.OrderStatusR\x06status\x12\x1d\n" +
+	"\n" +
+	"updated_on\x18\x03 \x01(\tR\tupdatedOn\"T\n" +
+	"\x0fAddEventRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\x03R\aorderId\x12&\n" +
+	"\x05event\x18\x02 \x01(\v2\x10.orders.v1.EventR\x05event\"\xc4\x01\n" +
+	"\x10AddEventResponse\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\x03R\aorderId\x12?\n" +
+	"\x0fprevious_status\x18\x02 \x01(\x0e2\x16.orders.v1.OrderStatusR\x0epreviousStatus\x125\n" +
+	"\n" +
+	"new_status\x18\x03 \x01(\x0e2\x16.orders.v1.OrderStatusR\tnewStatus\x12\x1d\n" +
+	"\n" +
+	"updated_on\x18\x04 \x01(\tR\tupdatedOn\",\n" +
+	"\x0fGetOrderRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\x03R\aorderId\"\x83\x02\n" +
+	"\x13SearchOrdersRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\x03R\aorderId\x12'\n" +
+	"\x0fdocument_number\x18\x02 \x01(\tR\x0edocumentNumber\x12.\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x16.orders.v1.OrderStatusR\x06status\x12,\n" +
+	"\achannel\x18\x04 \x01(\x0e2\x12.orders.v1.ChannelR\achannel\x12&\n" +
+	"\x0fcreated_on_from\x18\x05 \x01(\tR\rcreatedOnFrom\x12\"\n" +
+	"\rcreated_on_to\x18\x06 \x01(\tR\vcreatedOnTo\".\n" +
+	"\x11WatchOrderRequest\x12\x19\n" +
+	"\border_id\x18\x01 \x01(\x03R\aorderId*\xde\x01\n" +
+	"\vOrderStatus\x12\x1c\n" +
+	"\x18ORDER_STATUS_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14ORDER_STATUS_CREATED\x10\x01\x12!\n" +
+	"\x1dORDER_STATUS_PAYMENT_RECEIVED\x10\x02\x12\x19\n" +
+	"\x15ORDER_STATUS_CANCELED\x10\x03\x12\x19\n" +
+	"\x15ORDER_STATUS_INVOICED\x10\x04\x12\x19\n" +
+	"\x15ORDER_STATUS_RETURNED\x10\x05\x12#\n" +
+	"\x1fORDER_STATUS_PARTIALLY_RETURNED\x10\x06*\xab\x01\n" +
+	"\tEventType\x12\x1a\n" +
+	"\x16EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x1f\n" +
+	"\x1bEVENT_TYPE_PAYMENT_RECEIVED\x10\x01\x12\x17\n" +
+	"\x13EVENT_TYPE_CANCELED\x10\x02\x12\x17\n" +
+	"\x13EVENT_TYPE_INVOICED\x10\x03\x12\x17\n" +
+	"\x13EVENT_TYPE_RETURNED\x10\x04\x12\x16\n" +
+	"\x12EVENT_TYPE_AMENDED\x10\x05*|\n" +
+	"\aChannel\x12\x17\n" +
+	"\x13CHANNEL_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11CHANNEL_ECOMMERCE\x10\x01\x12\x17\n" +
+	"\x13CHANNEL_CALL_CENTER\x10\x02\x12\x11\n" +
+	"\rCHANNEL_STORE\x10\x03\x12\x15\n" +
+	"\x11CHANNEL_AFFILIATE\x10\x042\xe0\x02\n" +
+	"\rOrdersService\x12L\n" +
+	"\vCreateOrder\x12\x1d.orders.v1.CreateOrderRequest\x1a\x1e.orders.v1.CreateOrderResponse\x12C\n" +
+	"\bAddEvent\x12\x1a.orders.v1.AddEventRequest\x1a\x1b.orders.v1.AddEventResponse\x128\n" +
+	"\bGetOrder\x12\x1a.orders.v1.GetOrderRequest\x1a\x10.orders.v1.Order\x12B\n" +
+	"\fSearchOrders\x12\x1e.orders.v1.SearchOrdersRequest\x1a\x10.orders.v1.Order0\x01\x12>\n" +
+	"\n" +
+	"WatchOrder\x12\x1c.orders.v1.WatchOrderRequest\x1a\x10.orders.v1.Order0\x01B0Z.challenge_pyegros/app/proto/orders/v1;ordersv1b\x06proto3"
+
+var (
+	file_orders_v1_orders_proto_rawDescOnce sync.Once
+	file_orders_v1_orders_proto_rawDescData []byte
+)
+
+func file_orders_v1_orders_proto_rawDescGZIP() []byte {
+	file_orders_v1_orders_proto_rawDescOnce.Do(func() {
+		file_orders_v1_orders_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_orders_v1_orders_proto_rawDesc), len(file_orders_v1_orders_proto_rawDesc)))
+	})
+	return file_orders_v1_orders_proto_rawDescData
+}
+
+var file_orders_v1_orders_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_orders_v1_orders_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_orders_v1_orders_proto_goTypes = []any{
+	(OrderStatus)(0),            // 0: orders.v1.OrderStatus
+	(EventType)(0),              // 1: orders.v1.EventType
+	(Channel)(0),                // 2: orders.v1.Channel
+	(*Buyer)(nil),               // 3: orders.v1.Buyer
+	(*Product)(nil),             // 4: orders.v1.Product
+	(*ReturnedItem)(nil),        // 5: orders.v1.ReturnedItem
+	(*EventReason)(nil),         // 6: orders.v1.EventReason
+	(*FieldChange)(nil),         // 7: orders.v1.FieldChange
+	(*Event)(nil),               // 8: orders.v1.Event
+	(*Order)(nil),               // 9: orders.v1.Order
+	(*CreateOrderRequest)(nil),  // 10: orders.v1.CreateOrderRequest
+	(*CreateOrderResponse)(nil), // 11: orders.v1.CreateOrderResponse
+	(*AddEventRequest)(nil),     // 12: orders.v1.AddEventRequest
+	(*AddEventResponse)(nil),    // 13: orders.v1.AddEventResponse
+	(*GetOrderRequest)(nil),     // 14: orders.v1.GetOrderRequest
+	(*SearchOrdersRequest)(nil), // 15: orders.v1.SearchOrdersRequest
+	(*WatchOrderRequest)(nil),   // 16: orders.v1.WatchOrderRequest
+}
+var file_orders_v1_orders_proto_depIdxs = []int32{
+	5,  // 0: orders.v1.EventReason.items:type_name -> orders.v1.ReturnedItem
+	1,  // 1: orders.v1.Event.type:type_name -> orders.v1.EventType
+	6,  // 2: orders.v1.Event.reason:type_name -> orders.v1.EventReason
+	7,  // 3: orders.v1.Event.changes:type_name -> orders.v1.FieldChange
+	2,  // 4: orders.v1.Order.channel:type_name -> orders.v1.Channel
+	3,  // 5: orders.v1.Order.buyer:type_name -> orders.v1.Buyer
+	4,  // 6: orders.v1.Order.products:type_name -> orders.v1.Product
+	0,  // 7: orders.v1.Order.status:type_name -> orders.v1.OrderStatus
+	8,  // 8: orders.v1.Order.events:type_name -> orders.v1.Event
+	9,  // 9: orders.v1.CreateOrderRequest.order:type_name -> orders.v1.Order
+	0,  // 10: orders.v1.CreateOrderResponse.status:type_name -> orders.v1.OrderStatus
+	8,  // 11: orders.v1.AddEventRequest.event:type_name -> orders.v1.Event
+	0,  // 12: orders.v1.AddEventResponse.previous_status:type_name -> orders.v1.OrderStatus
+	0,  // 13: orders.v1.AddEventResponse.new_status:type_name -> orders.v1.OrderStatus
+	0,  // 14: orders.v1.SearchOrdersRequest.status:type_name -> orders.v1.OrderStatus
+	2,  // 15: orders.v1.SearchOrdersRequest.channel:type_name -> orders.v1.Channel
+	10, // 16: orders.v1.OrdersService.CreateOrder:input_type -> orders.v1.CreateOrderRequest
+	12, // 17: orders.v1.OrdersService.AddEvent:input_type -> orders.v1.AddEventRequest
+	14, // 18: orders.v1.OrdersService.GetOrder:input_type -> orders.v1.GetOrderRequest
+	15, // 19: orders.v1.OrdersService.SearchOrders:input_type -> orders.v1.SearchOrdersRequest
+	16, // 20: orders.v1.OrdersService.WatchOrder:input_type -> orders.v1.WatchOrderRequest
+	11, // 21: orders.v1.OrdersService.CreateOrder:output_type -> orders.v1.CreateOrderResponse
+	13, // 22: orders.v1.OrdersService.AddEvent:output_type -> orders.v1.AddEventResponse
+	9,  // 23: orders.v1.OrdersService.GetOrder:output_type -> orders.v1.Order
+	9,  // 24: orders.v1.OrdersService.SearchOrders:output_type -> orders.v1.Order
+	9,  // 25: orders.v1.OrdersService.WatchOrder:output_type -> orders.v1.Order
+	21, // [21:26] is the sub-list for method output_type
+	16, // [16:21] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_orders_v1_orders_proto_init() }
+func file_orders_v1_orders_proto_init() {
+	if File_orders_v1_orders_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_orders_v1_orders_proto_rawDesc), len(file_orders_v1_orders_proto_rawDesc)),
+			NumEnums:      3,
+			NumMessages:   14,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_orders_v1_orders_proto_goTypes,
+		DependencyIndexes: file_orders_v1_orders_proto_depIdxs,
+		EnumInfos:         file_orders_v1_orders_proto_enumTypes,
+		MessageInfos:      file_orders_v1_orders_proto_msgTypes,
+	}.Build()
+	File_orders_v1_orders_proto = out.File
+	file_orders_v1_orders_proto_goTypes = nil
+	file_orders_v1_orders_proto_depIdxs = nil
+}
@@ -0,0 +1,87 @@
+package apikeys
+
+import (
+	"challenge_pyegros/app/models"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryRepository keeps the API keys in memory with the semantics of
+// Repository, for running the API without Mongo. The keys are lost when the
+// process stops and must be issued again.
+type MemoryRepository struct {
+	mu          sync.RWMutex
+	keys        map[string]models.APIKey
+	generateKey func() (string, error)
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		keys:        map[string]models.APIKey{},
+		generateKey: defaultGenerateKey,
+	}
+}
+
+func (r *MemoryRepository) IssueAPIKey(request models.APIKeyRequest) (*models.ResponseAPIKey, error) {
+	apiKey, key, err := newAPIKey(request, r.generateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys[apiKey.ID] = apiKey
+	return &models.ResponseAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+// RotateAPIKey and RevokeAPIKey fail with mongo.ErrNoDocuments for an
+// unknown key, as Repository does.
+func (r *MemoryRepository) RotateAPIKey(id string) (*models.ResponseAPIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	apiKey, ok := r.keys[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	key, err := rotateKey(&apiKey, r.generateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	r.keys[id] = apiKey
+	return &models.ResponseAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+func (r *MemoryRepository) RevokeAPIKey(id string) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	apiKey, ok := r.keys[id]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	if apiKey.RevokedOn == "" {
+		apiKey.RevokedOn = time.Now().UTC().Format(time.RFC3339)
+		r.keys[id] = apiKey
+	}
+	return &apiKey, nil
+}
+
+func (r *MemoryRepository) Authenticate(key string) (*models.Principal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	hash := hashKey(key)
+	for _, apiKey := range r.keys {
+		if apiKey.Hash == hash {
+			return toPrincipal(apiKey)
+		}
+	}
+	return nil, ErrInvalidAPIKey
+}
@@ -0,0 +1,232 @@
+package apikeys
+
+import (
+	"challenge_pyegros/app/models"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	keyPrefix    = "fk_"
+	prefixLength = 10
+)
+
+var (
+	ErrChannelNotFound = errors.New("Channel not found")
+	ErrInvalidScope    = errors.New("Invalid scope")
+	ErrMissingScopes   = errors.New("At least one scope is required")
+	ErrInvalidAPIKey   = errors.New("Invalid API key")
+	ErrAPIKeyRevoked   = errors.New("API key is revoked")
+	ErrGeneratingKey   = errors.New("Error generating API key")
+)
+
+// Scopes are the roles an API key can be granted.
+var Scopes = []string{models.RoleChannelClient, models.RoleBackoffice, models.RoleAuditor}
+
+type Repository struct {
+	db          *mongo.Client
+	generateKey func() (string, error)
+}
+
+func NewRepository(client *mongo.Client) *Repository {
+	return &Repository{
+		db:          client,
+		generateKey: defaultGenerateKey,
+	}
+}
+
+func (r *Repository) IssueAPIKey(request models.APIKeyRequest) (*models.ResponseAPIKey, error) {
+	apiKey, key, err := newAPIKey(request, r.generateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := r.db.Database("orders").Collection("api_keys")
+	_, err = collection.InsertOne(context.TODO(), apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ResponseAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+func (r *Repository) RotateAPIKey(id string) (*models.ResponseAPIKey, error) {
+	collection := r.db.Database("orders").Collection("api_keys")
+
+	filter := bson.M{"_id": id}
+
+	var apiKey models.APIKey
+	err := collection.FindOne(context.TODO(), filter).Decode(&apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := rotateKey(&apiKey, r.generateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{"$set": bson.M{"hash": apiKey.Hash, "prefix": apiKey.Prefix, "rotatedOn": apiKey.RotatedOn}}
+	_, err = collection.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ResponseAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+func (r *Repository) RevokeAPIKey(id string) (*models.APIKey, error) {
+	collection := r.db.Database("orders").Collection("api_keys")
+
+	filter := bson.M{"_id": id}
+
+	var apiKey models.APIKey
+	err := collection.FindOne(context.TODO(), filter).Decode(&apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if apiKey.RevokedOn != "" {
+		return &apiKey, nil
+	}
+
+	apiKey.RevokedOn = time.Now().UTC().Format(time.RFC3339)
+
+	update := bson.M{"$set": bson.M{"revokedOn": apiKey.RevokedOn}}
+	_, err = collection.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return nil, err
+	}
+
+	return &apiKey, nil
+}
+
+func (r *Repository) Authenticate(key string) (*models.Principal, error) {
+	collection := r.db.Database("orders").Collection("api_keys")
+
+	filter := bson.M{"hash": hashKey(key)}
+
+	var apiKey models.APIKey
+	err := collection.FindOne(context.TODO(), filter).Decode(&apiKey)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrInvalidAPIKey
+	} else if err != nil {
+		return nil, err
+	}
+
+	return toPrincipal(apiKey)
+}
+
+// newAPIKey validates the request and creates the key, only its hash and
+// prefix are stored.
+func newAPIKey(request models.APIKeyRequest, generateKey func() (string, error)) (models.APIKey, string, error) {
+	if _, ok := models.ExternalReferenceIDs[request.Channel]; !ok {
+		return models.APIKey{}, "", ErrChannelNotFound
+	}
+
+	err := validateScopes(request.Scopes)
+	if err != nil {
+		return models.APIKey{}, "", err
+	}
+
+	id, err := newID()
+	if err != nil {
+		return models.APIKey{}, "", ErrGeneratingKey
+	}
+	key, err := generateKey()
+	if err != nil {
+		return models.APIKey{}, "", ErrGeneratingKey
+	}
+
+	apiKey := models.APIKey{
+		ID:        id,
+		Hash:      hashKey(key),
+		Prefix:    key[:prefixLength],
+		Channel:   request.Channel,
+		Scopes:    request.Scopes,
+		CreatedOn: time.Now().UTC().Format(time.RFC3339),
+	}
+	return apiKey, key, nil
+}
+
+// rotateKey replaces the key of a key that is not revoked.
+func rotateKey(apiKey *models.APIKey, generateKey func() (string, error)) (string, error) {
+	if apiKey.RevokedOn != "" {
+		return "", ErrAPIKeyRevoked
+	}
+
+	key, err := generateKey()
+	if err != nil {
+		return "", ErrGeneratingKey
+	}
+
+	apiKey.Hash = hashKey(key)
+	apiKey.Prefix = key[:prefixLength]
+	apiKey.RotatedOn = time.Now().UTC().Format(time.RFC3339)
+	return key, nil
+}
+
+func toPrincipal(apiKey models.APIKey) (*models.Principal, error) {
+	if apiKey.RevokedOn != "" {
+		return nil, ErrAPIKeyRevoked
+	}
+
+	principal := &models.Principal{
+		Subject: "apikey:" + apiKey.ID,
+		Roles:   apiKey.Scopes,
+		Channel: apiKey.Channel,
+	}
+
+	return principal, nil
+}
+
+func validateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return ErrMissingScopes
+	}
+
+	for _, scope := range scopes {
+		valid := false
+		for _, allowed := range Scopes {
+			if scope == allowed {
+				valid = true
+			}
+		}
+		if !valid {
+			return ErrInvalidScope
+		}
+	}
+
+	return nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func newID() (string, error) {
+	random := make([]byte, 8)
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(random), nil
+}
+
+func defaultGenerateKey() (string, error) {
+	random := make([]byte, 32)
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", err
+	}
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(random), nil
+}
@@ -0,0 +1,208 @@
+package apikeys
+
+import (
+	"challenge_pyegros/app/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const rawKey = "fk_0123456789abcdefghijklmnopqrstuvwxyzABCDEFG"
+
+var (
+	request = models.APIKeyRequest{
+		Channel: "Store",
+		Scopes:  []string{models.RoleChannelClient},
+	}
+)
+
+func apiKeyDocument(revokedOn string) bson.D {
+	return bson.D{
+		{Key: "_id", Value: "a1b2c3d4e5f60718"},
+		{Key: "hash", Value: hashKey(rawKey)},
+		{Key: "prefix", Value: rawKey[:prefixLength]},
+		{Key: "channel", Value: request.Channel},
+		{Key: "scopes", Value: request.Scopes},
+		{Key: "createdOn", Value: "2024-05-01T14:00:00Z"},
+		{Key: "revokedOn", Value: revokedOn},
+	}
+}
+
+func TestIssueAPIKeySuccess(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("success", func(mt *mtest.T) {
+		apiKeysRepo := NewRepository(mt.Client)
+		apiKeysRepo.generateKey = func() (string, error) {
+			return rawKey, nil
+		}
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		response, err := apiKeysRepo.IssueAPIKey(request)
+		assert.Nil(t, err)
+		assert.Equal(t, rawKey, response.Key)
+		assert.Equal(t, hashKey(rawKey), response.Hash)
+		assert.Equal(t, "fk_0123456", response.Prefix)
+		assert.Equal(t, models.ChannelStore, response.Channel)
+		assert.NotEmpty(t, response.ID)
+	})
+}
+
+func TestIssueAPIKeyInvalidRequest(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("invalid request", func(mt *mtest.T) {
+		apiKeysRepo := NewRepository(mt.Client)
+
+		response, err := apiKeysRepo.IssueAPIKey(models.APIKeyRequest{Channel: "Unknown", Scopes: request.Scopes})
+		assert.Nil(t, response)
+		assert.Equal(t, ErrChannelNotFound, err)
+
+		response, err = apiKeysRepo.IssueAPIKey(models.APIKeyRequest{Channel: "Store"})
+		assert.Nil(t, response)
+		assert.Equal(t, ErrMissingScopes, err)
+
+		response, err = apiKeysRepo.IssueAPIKey(models.APIKeyRequest{Channel: "Store", Scopes: []string{models.RoleAdmin}})
+		assert.Nil(t, response)
+		assert.Equal(t, ErrInvalidScope, err)
+	})
+}
+
+func TestRotateAPIKeySuccess(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("success", func(mt *mtest.T) {
+		apiKeysRepo := NewRepository(mt.Client)
+		apiKeysRepo.generateKey = func() (string, error) {
+			return "fk_rotated", nil
+		}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "orders.api_keys", mtest.FirstBatch, apiKeyDocument("")),
+			mtest.CreateSuccessResponse(),
+		)
+
+		response, err := apiKeysRepo.RotateAPIKey("a1b2c3d4e5f60718")
+		assert.Nil(t, err)
+		assert.Equal(t, "fk_rotated", response.Key)
+		assert.Equal(t, hashKey("fk_rotated"), response.Hash)
+		assert.NotEmpty(t, response.RotatedOn)
+	})
+}
+
+func TestRotateAPIKeyRevoked(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("revoked", func(mt *mtest.T) {
+		apiKeysRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "orders.api_keys", mtest.FirstBatch, apiKeyDocument("2024-05-02T14:00:00Z")))
+
+		response, err := apiKeysRepo.RotateAPIKey("a1b2c3d4e5f60718")
+		assert.Nil(t, response)
+		assert.Equal(t, ErrAPIKeyRevoked, err)
+	})
+}
+
+func TestRevokeAPIKeySuccess(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("success", func(mt *mtest.T) {
+		apiKeysRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "orders.api_keys", mtest.FirstBatch, apiKeyDocument("")),
+			mtest.CreateSuccessResponse(),
+		)
+
+		response, err := apiKeysRepo.RevokeAPIKey("a1b2c3d4e5f60718")
+		assert.Nil(t, err)
+		assert.NotEmpty(t, response.RevokedOn)
+	})
+}
+
+func TestRevokeAPIKeyNotFound(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("not found", func(mt *mtest.T) {
+		apiKeysRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "orders.api_keys", mtest.FirstBatch))
+
+		response, err := apiKeysRepo.RevokeAPIKey("unknown")
+		assert.Nil(t, response)
+		assert.Equal(t, mongo.ErrNoDocuments, err)
+	})
+}
+
+func TestAuthenticateSuccess(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("success", func(mt *mtest.T) {
+		apiKeysRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "orders.api_keys", mtest.FirstBatch, apiKeyDocument("")))
+
+		principal, err := apiKeysRepo.Authenticate(rawKey)
+		assert.Nil(t, err)
+		assert.Equal(t, &models.Principal{
+			Subject: "apikey:a1b2c3d4e5f60718",
+			Roles:   []string{models.RoleChannelClient},
+			Channel: "Store",
+		}, principal)
+	})
+}
+
+func TestAuthenticateInvalidKey(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("invalid key", func(mt *mtest.T) {
+		apiKeysRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "orders.api_keys", mtest.FirstBatch))
+
+		principal, err := apiKeysRepo.Authenticate("fk_unknown")
+		assert.Nil(t, principal)
+		assert.Equal(t, ErrInvalidAPIKey, err)
+	})
+}
+
+func TestAuthenticateRevokedKey(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("revoked key", func(mt *mtest.T) {
+		apiKeysRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "orders.api_keys", mtest.FirstBatch, apiKeyDocument("2024-05-02T14:00:00Z")))
+
+		principal, err := apiKeysRepo.Authenticate(rawKey)
+		assert.Nil(t, principal)
+		assert.Equal(t, ErrAPIKeyRevoked, err)
+	})
+}
+
+func TestMemoryRepository(t *testing.T) {
+	apiKeysRepo := NewMemoryRepository()
+	apiKeysRepo.generateKey = func() (string, error) {
+		return rawKey, nil
+	}
+
+	_, err := apiKeysRepo.IssueAPIKey(models.APIKeyRequest{Channel: "Unknown", Scopes: request.Scopes})
+	assert.Equal(t, ErrChannelNotFound, err)
+
+	issued, err := apiKeysRepo.IssueAPIKey(request)
+	assert.Nil(t, err)
+	assert.Equal(t, rawKey, issued.Key)
+
+	principal, err := apiKeysRepo.Authenticate(rawKey)
+	assert.Nil(t, err)
+	assert.Equal(t, &models.Principal{Subject: "apikey:" + issued.ID, Roles: request.Scopes, Channel: "Store"}, principal)
+
+	rotatedKey := "fk_rotatedabcdefghijklmnopqrstuvwxyzABCDEFGHIJ"
+	apiKeysRepo.generateKey = func() (string, error) {
+		return rotatedKey, nil
+	}
+	rotated, err := apiKeysRepo.RotateAPIKey(issued.ID)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, rotated.RotatedOn)
+	_, err = apiKeysRepo.Authenticate(rawKey)
+	assert.Equal(t, ErrInvalidAPIKey, err)
+
+	revoked, err := apiKeysRepo.RevokeAPIKey(issued.ID)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, revoked.RevokedOn)
+	_, err = apiKeysRepo.Authenticate(rotatedKey)
+	assert.Equal(t, ErrAPIKeyRevoked, err)
+	_, err = apiKeysRepo.RotateAPIKey(issued.ID)
+	assert.Equal(t, ErrAPIKeyRevoked, err)
+
+	_, err = apiKeysRepo.RevokeAPIKey("unknown")
+	assert.Equal(t, mongo.ErrNoDocuments, err)
+}
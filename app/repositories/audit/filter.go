@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"challenge_pyegros/app/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func ApplyActorFilter(filters *models.AuditFilters, query []bson.M) []bson.M {
+	if len(filters.Actor) > 0 {
+		query = append(query, bson.M{"actor": filters.Actor})
+	}
+	return query
+}
+
+func ApplyActionFilter(filters *models.AuditFilters, query []bson.M) []bson.M {
+	if len(filters.Action) > 0 {
+		query = append(query, bson.M{"action": filters.Action})
+	}
+	return query
+}
+
+func ApplyOrderIdFilter(filters *models.AuditFilters, query []bson.M) []bson.M {
+	if filters.OrderId > 0 {
+		query = append(query, bson.M{"orderID": filters.OrderId})
+	}
+	return query
+}
+
+func ApplyTimestampFilter(filters *models.AuditFilters, query []bson.M) []bson.M {
+	timestamp := bson.M{}
+	if len(filters.From) > 0 {
+		timestamp["$gte"] = filters.From
+	}
+	if len(filters.To) > 0 {
+		timestamp["$lte"] = filters.To
+	}
+	if len(timestamp) > 0 {
+		query = append(query, bson.M{"timestamp": timestamp})
+	}
+	return query
+}
+
+func ApplyFilters(filters models.AuditFilters) []bson.M {
+	var query []bson.M
+	query = ApplyActorFilter(&filters, query)
+	query = ApplyActionFilter(&filters, query)
+	query = ApplyOrderIdFilter(&filters, query)
+	query = ApplyTimestampFilter(&filters, query)
+	return query
+}
@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"challenge_pyegros/app/models"
+	"sort"
+	"sync"
+)
+
+// MemoryRepository keeps the audit trail in memory with the semantics of
+// Repository, for running the API without Mongo. Its entries are lost when
+// the process stops.
+type MemoryRepository struct {
+	mu      sync.RWMutex
+	entries []models.AuditEntry
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		entries: []models.AuditEntry{},
+	}
+}
+
+func (r *MemoryRepository) Record(entry models.AuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+// GetAuditEntries returns the entries matching the filters sorted by
+// timestamp. The timestamps are RFC 3339 in UTC, so they are compared as
+// strings as Mongo does.
+func (r *MemoryRepository) GetAuditEntries(filters models.AuditFilters) ([]models.AuditEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := []models.AuditEntry{}
+	for _, entry := range r.entries {
+		if matches(entry, filters) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Timestamp < entries[j].Timestamp
+	})
+
+	if filters.Page > 0 && filters.PageSize > 0 {
+		start := (filters.Page - 1) * filters.PageSize
+		if start >= int64(len(entries)) {
+			return []models.AuditEntry{}, nil
+		}
+		end := min(start+filters.PageSize, int64(len(entries)))
+		entries = entries[start:end]
+	}
+	return entries, nil
+}
+
+// matches is the query of ApplyFilters for one entry.
+func matches(entry models.AuditEntry, filters models.AuditFilters) bool {
+	switch {
+	case len(filters.Actor) > 0 && entry.Actor != filters.Actor,
+		len(filters.Action) > 0 && entry.Action != filters.Action,
+		filters.OrderId > 0 && entry.OrderID != filters.OrderId,
+		len(filters.From) > 0 && entry.Timestamp < filters.From,
+		len(filters.To) > 0 && entry.Timestamp > filters.To:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"challenge_pyegros/app/models"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Repository stores the audit trail. The audit_log collection is append-only:
+// entries are inserted and read but never updated or deleted.
+type Repository struct {
+	db *mongo.Client
+}
+
+func NewRepository(client *mongo.Client) *Repository {
+	return &Repository{
+		db: client,
+	}
+}
+
+func (r *Repository) Record(entry models.AuditEntry) error {
+	collection := r.db.Database("orders").Collection("audit_log")
+
+	_, err := collection.InsertOne(context.TODO(), entry)
+	return err
+}
+
+func (r *Repository) GetAuditEntries(filters models.AuditFilters) ([]models.AuditEntry, error) {
+	collection := r.db.Database("orders").Collection("audit_log")
+
+	query := ApplyFilters(filters)
+
+	filtersQuery := bson.M{}
+	if len(query) > 0 {
+		filtersQuery = bson.M{"$and": query}
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "timestamp", Value: 1}})
+	if filters.Page > 0 && filters.PageSize > 0 {
+		findOptions.SetSkip((filters.Page - 1) * filters.PageSize).SetLimit(filters.PageSize)
+	}
+
+	cursor, err := collection.Find(context.TODO(), filtersQuery, findOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries = []models.AuditEntry{}
+	if err = cursor.All(context.TODO(), &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,141 @@
+package audit
+
+import (
+	"challenge_pyegros/app/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+var (
+	entry = models.AuditEntry{
+		Actor:          "adminUser123",
+		Action:         models.AuditActionAddEvent,
+		OrderID:        1,
+		PreviousStatus: "Created",
+		NewStatus:      "PaymentReceived",
+		RequestID:      "host/abc-000001",
+		SourceIP:       "10.0.0.1",
+		Timestamp:      "2024-05-01T15:00:00Z",
+	}
+)
+
+func TestRecordSuccess(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("success", func(mt *mtest.T) {
+		auditRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+		err := auditRepo.Record(entry)
+		assert.Nil(t, err)
+	})
+}
+
+func TestRecordFailsInsertOne(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("fails insert one", func(mt *mtest.T) {
+		auditRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Code:    11000,
+			Message: "duplicate key error",
+			Index:   0,
+		}))
+
+		err := auditRepo.Record(entry)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestGetAuditEntriesSuccess(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("success", func(mt *mtest.T) {
+		auditRepo := NewRepository(mt.Client)
+		firstResponse := mtest.CreateCursorResponse(1, "orders.audit_log", mtest.FirstBatch, bson.D{
+			{Key: "actor", Value: entry.Actor},
+			{Key: "action", Value: entry.Action},
+			{Key: "orderID", Value: entry.OrderID},
+			{Key: "previousStatus", Value: entry.PreviousStatus},
+			{Key: "newStatus", Value: entry.NewStatus},
+			{Key: "requestID", Value: entry.RequestID},
+			{Key: "sourceIP", Value: entry.SourceIP},
+			{Key: "timestamp", Value: entry.Timestamp},
+		})
+		endOfCursor := mtest.CreateCursorResponse(0, "orders.audit_log", mtest.NextBatch)
+		mt.AddMockResponses(firstResponse, endOfCursor)
+
+		entries, err := auditRepo.GetAuditEntries(models.AuditFilters{OrderId: 1, Page: 1, PageSize: 20})
+		assert.Nil(t, err)
+		assert.Equal(t, []models.AuditEntry{entry}, entries)
+	})
+}
+
+func TestGetAuditEntriesFailsFind(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("fails find", func(mt *mtest.T) {
+		auditRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code:    66,
+			Message: "some error",
+			Name:    "SomeError",
+			Labels:  []string{},
+		}))
+
+		entries, err := auditRepo.GetAuditEntries(models.AuditFilters{})
+		assert.Nil(t, entries)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestApplyFilters(t *testing.T) {
+	query := ApplyFilters(models.AuditFilters{})
+	assert.Empty(t, query)
+
+	query = ApplyFilters(models.AuditFilters{
+		Actor:   "adminUser123",
+		Action:  models.AuditActionCreateOrder,
+		OrderId: 1,
+		From:    "2024-05-01T00:00:00Z",
+	})
+	assert.Equal(t, []bson.M{
+		{"actor": "adminUser123"},
+		{"action": models.AuditActionCreateOrder},
+		{"orderID": int64(1)},
+		{"timestamp": bson.M{"$gte": "2024-05-01T00:00:00Z"}},
+	}, query)
+}
+
+func TestMemoryRepository(t *testing.T) {
+	auditRepo := NewMemoryRepository()
+
+	created := entry
+	created.Action = models.AuditActionCreateOrder
+	created.Timestamp = "2024-05-01T14:00:00Z"
+	other := entry
+	other.OrderID = 2
+	other.Timestamp = "2024-05-02T15:00:00Z"
+	for _, recorded := range []models.AuditEntry{entry, other, created} {
+		assert.Nil(t, auditRepo.Record(recorded))
+	}
+
+	entries, err := auditRepo.GetAuditEntries(models.AuditFilters{})
+	assert.Nil(t, err)
+	assert.Equal(t, []models.AuditEntry{created, entry, other}, entries)
+
+	entries, err = auditRepo.GetAuditEntries(models.AuditFilters{Action: models.AuditActionAddEvent, OrderId: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, []models.AuditEntry{entry}, entries)
+
+	entries, err = auditRepo.GetAuditEntries(models.AuditFilters{From: "2024-05-01T15:00:00Z", To: "2024-05-01T23:59:59Z"})
+	assert.Nil(t, err)
+	assert.Equal(t, []models.AuditEntry{entry}, entries)
+
+	entries, err = auditRepo.GetAuditEntries(models.AuditFilters{Page: 2, PageSize: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []models.AuditEntry{other}, entries)
+
+	entries, err = auditRepo.GetAuditEntries(models.AuditFilters{Page: 3, PageSize: 2})
+	assert.Nil(t, err)
+	assert.Equal(t, []models.AuditEntry{}, entries)
+}
@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"challenge_pyegros/app/database"
+	"challenge_pyegros/app/models"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// scanCount is the amount of keys requested to Redis in every SCAN iteration.
+const scanCount = 100
+
+var (
+	ErrMissingPattern = errors.New("A pattern is required")
+)
+
+// Repository inspects and purges the keys of the service. Patterns are scoped
+// to database.KeyNamespace and keys are walked with SCAN, so Redis is never
+// blocked as it would be with KEYS.
+type Repository struct {
+	redis *redis.Client
+}
+
+func NewRepository(redis *redis.Client) *Repository {
+	return &Repository{
+		redis: redis,
+	}
+}
+
+func (r *Repository) GetKeys(pattern string, limit int64) (*models.ResponseCacheKeys, error) {
+	if pattern == "" {
+		return nil, ErrMissingPattern
+	}
+	if r.redis == nil {
+		return nil, database.ErrRedisUnavailable
+	}
+
+	ctx := context.Background()
+	response := &models.ResponseCacheKeys{
+		Pattern: database.KeyPattern(pattern),
+		Keys:    []models.CacheKey{},
+	}
+
+	iter := r.redis.Scan(ctx, 0, response.Pattern, scanCount).Iterator()
+	for iter.Next(ctx) && int64(len(response.Keys)) < limit {
+		key := iter.Val()
+
+		pipe := r.redis.Pipeline()
+		keyType := pipe.Type(ctx, key)
+		ttl := pipe.TTL(ctx, key)
+		_, err := pipe.Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		cacheKey := models.CacheKey{
+			Key:  key,
+			Type: keyType.Val(),
+			TTL:  -1,
+		}
+		if ttl.Val() > 0 {
+			cacheKey.TTL = int64(ttl.Val() / time.Second)
+		}
+		response.Keys = append(response.Keys, cacheKey)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (r *Repository) PurgeKeys(pattern string) (*models.ResponsePurge, error) {
+	if pattern == "" {
+		return nil, ErrMissingPattern
+	}
+	if r.redis == nil {
+		return nil, database.ErrRedisUnavailable
+	}
+
+	ctx := context.Background()
+	response := &models.ResponsePurge{
+		Pattern: database.KeyPattern(pattern),
+	}
+
+	var cursor uint64
+	for {
+		keys, next, err := r.redis.Scan(ctx, cursor, response.Pattern, scanCount).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(keys) > 0 {
+			deleted, err := r.redis.Unlink(ctx, keys...).Result()
+			if err != nil {
+				return nil, err
+			}
+			response.Deleted += deleted
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	return response, nil
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"challenge_pyegros/app/database"
+	"challenge_pyegros/app/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func CreateCacheForTesting(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	s := miniredis.RunT(t)
+
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     s.Addr(),
+		Password: "",
+		DB:       0,
+	})
+
+	return rdb, s
+}
+
+func seed(t *testing.T, rdb *redis.Client) {
+	ctx := context.Background()
+	assert.NoError(t, rdb.Set(ctx, database.OrderDetailKey(1), "{}", time.Minute).Err())
+	assert.NoError(t, rdb.Set(ctx, database.OrderDetailKey(2), "{}", 0).Err())
+	assert.NoError(t, rdb.Set(ctx, database.EventIdempotencyKey(1, "event-001"), "{}", time.Hour).Err())
+	assert.NoError(t, rdb.Set(ctx, "other-service:v1:order:1", "{}", 0).Err())
+}
+
+func TestGetKeys(t *testing.T) {
+	rdb, _ := CreateCacheForTesting(t)
+	seed(t, rdb)
+	cacheRepo := NewRepository(rdb)
+
+	response, err := cacheRepo.GetKeys("v2:order:*", 100)
+	assert.NoError(t, err)
+	assert.Equal(t, "orders-api:v2:order:*", response.Pattern)
+	assert.ElementsMatch(t, []models.CacheKey{
+		{Key: "orders-api:v2:order:1", Type: "string", TTL: 60},
+		{Key: "orders-api:v2:order:2", Type: "string", TTL: -1},
+	}, response.Keys)
+
+	response, err = cacheRepo.GetKeys("*", 1)
+	assert.NoError(t, err)
+	assert.Len(t, response.Keys, 1)
+
+	response, err = cacheRepo.GetKeys("", 100)
+	assert.Nil(t, response)
+	assert.Equal(t, ErrMissingPattern, err)
+}
+
+func TestPurgeKeys(t *testing.T) {
+	rdb, s := CreateCacheForTesting(t)
+	seed(t, rdb)
+	cacheRepo := NewRepository(rdb)
+
+	response, err := cacheRepo.PurgeKeys("v2:idempotency:*")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.ResponsePurge{Pattern: "orders-api:v2:idempotency:*", Deleted: 1}, response)
+	assert.False(t, s.Exists(database.EventIdempotencyKey(1, "event-001")))
+
+	response, err = cacheRepo.PurgeKeys("*")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), response.Deleted)
+	assert.True(t, s.Exists("other-service:v1:order:1"))
+
+	response, err = cacheRepo.PurgeKeys("")
+	assert.Nil(t, response)
+	assert.Equal(t, ErrMissingPattern, err)
+}
+
+func TestWithoutRedis(t *testing.T) {
+	cacheRepo := NewRepository(nil)
+
+	keys, err := cacheRepo.GetKeys("v2:order:*", 100)
+	assert.Nil(t, keys)
+	assert.Equal(t, database.ErrRedisUnavailable, err)
+
+	purged, err := cacheRepo.PurgeKeys("v2:order:*")
+	assert.Nil(t, purged)
+	assert.Equal(t, database.ErrRedisUnavailable, err)
+}
@@ -0,0 +1,104 @@
+package orders
+
+import (
+	"challenge_pyegros/app/models"
+	ports "challenge_pyegros/app/ports/orders"
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// UpdateOrdersEvents writes the events applied to every order in a single
+// unordered BulkWrite, one update per order, with the filter of
+// UpdateOrderEvents. The errors keep the position of the updates, nil for the
+// ones written and ports.ErrOrderChanged for the orders written by another
+// request after they were read.
+func (r *Repository) UpdateOrdersEvents(updates []models.OrderEvents) ([]error, error) {
+	collection := r.db.Database(r.database).Collection("orders")
+
+	writes := make([]mongo.WriteModel, len(updates))
+	for i, update := range updates {
+		writes[i] = mongo.NewUpdateOneModel().SetFilter(eventFilter(update.Order, update.Events)).SetUpdate(eventUpdate(update.Order, update.Events))
+	}
+
+	failures := make([]error, len(updates))
+	result, err := collection.BulkWrite(context.TODO(), writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) || len(bulkErr.WriteErrors) == 0 {
+			return nil, err
+		}
+		for _, writeErr := range bulkErr.WriteErrors {
+			failures[writeErr.Index] = writeErr
+		}
+	}
+
+	written := int64(len(updates))
+	for _, failure := range failures {
+		if failure != nil {
+			written--
+		}
+	}
+	if result != nil && result.MatchedCount < written {
+		err = r.findChangedOrders(updates, failures)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return failures, nil
+}
+
+// findChangedOrders sets ports.ErrOrderChanged to the updates that did not
+// match their order. The result of a BulkWrite only has the amount of matched
+// updates, so the orders are read again to find the ones without the first
+// of their events, whose IDs are unique in the order.
+func (r *Repository) findChangedOrders(updates []models.OrderEvents, failures []error) error {
+	orderIDs := []int64{}
+	for i, update := range updates {
+		if failures[i] == nil {
+			orderIDs = append(orderIDs, update.Order.OrderID)
+		}
+	}
+
+	found, err := r.FindOrdersByIDs(orderIDs)
+	if err != nil {
+		return err
+	}
+	written := map[int64]map[string]bool{}
+	for _, order := range found {
+		written[order.OrderID] = map[string]bool{}
+		for _, event := range order.Events {
+			written[order.OrderID][event.Id] = true
+		}
+	}
+
+	for i, update := range updates {
+		if failures[i] == nil && !written[update.Order.OrderID][update.Events[0].Id] {
+			failures[i] = ports.ErrOrderChanged
+		}
+	}
+	return nil
+}
+
+// ObtainIDBlock reserves size consecutive IDs with a single increment of the
+// counter and returns the first one.
+func (r *Repository) ObtainIDBlock(size int64) (int64, error) {
+	filter := bson.M{"_id": "orders"}
+	update := bson.M{"$inc": bson.M{"sequence_value": size}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var counter models.Counter
+	collection := r.db.Database(r.database).Collection("counters")
+	err := collection.FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(&counter)
+	if err != nil {
+		fmt.Println(err.Error())
+		return 0, ErrUpdatingAutoIncrementalId
+	}
+
+	return counter.SequenceValue - size + 1, nil
+}
@@ -0,0 +1,118 @@
+package orders
+
+import (
+	"challenge_pyegros/app/models"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// buyerOrders is the result of buyerPipeline, the page of orders together
+// with the groups used to build the summary.
+type buyerOrders struct {
+	Orders []models.Order `bson:"orders"`
+	Totals []struct {
+		OrderCount        int64   `bson:"orderCount"`
+		TotalSpent        float64 `bson:"totalSpent"`
+		FirstPurchaseDate string  `bson:"firstPurchaseDate"`
+		LastPurchaseDate  string  `bson:"lastPurchaseDate"`
+	} `bson:"totals"`
+	Statuses []buyerGroup `bson:"statuses"`
+	Channels []buyerGroup `bson:"channels"`
+}
+
+type buyerGroup struct {
+	ID    string `bson:"_id"`
+	Count int64  `bson:"count"`
+}
+
+// GetBuyerOrders returns a page of the orders of a buyer, the most recent
+// first, and the summary of all of them in a single aggregation.
+func (r *Repository) GetBuyerOrders(documentNumber string, filters models.BuyerFilters) (*models.ResponseBuyerOrders, error) {
+	collection := r.db.Database(r.database).Collection("orders")
+
+	cursor, err := collection.Aggregate(context.TODO(), buyerPipeline(documentNumber, filters))
+	if err != nil {
+		return nil, err
+	}
+
+	var results []buyerOrders
+	if err = cursor.All(context.TODO(), &results); err != nil {
+		return nil, err
+	}
+	if len(results) == 0 || len(results[0].Totals) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+	result := results[0]
+
+	summary := models.BuyerSummary{
+		TotalSpent:        result.Totals[0].TotalSpent,
+		OrderCount:        result.Totals[0].OrderCount,
+		OrdersByStatus:    map[string]int64{},
+		FirstPurchaseDate: result.Totals[0].FirstPurchaseDate,
+		LastPurchaseDate:  result.Totals[0].LastPurchaseDate,
+	}
+	for _, status := range result.Statuses {
+		summary.OrdersByStatus[status.ID] = status.Count
+	}
+	if len(result.Channels) > 0 {
+		summary.FavouriteChannel = result.Channels[0].ID
+	}
+
+	orders := result.Orders
+	if orders == nil {
+		orders = []models.Order{}
+	}
+
+	response := &models.ResponseBuyerOrders{
+		DocumentNumber: documentNumber,
+		Page:           filters.Page,
+		PageSize:       filters.PageSize,
+		Summary:        summary,
+		Orders:         orders,
+	}
+
+	return response, nil
+}
+
+func buyerPipeline(documentNumber string, filters models.BuyerFilters) mongo.Pipeline {
+	match := bson.M{"buyer.documentNumber": documentNumber}
+	if len(filters.Channel) > 0 {
+		match["channel"] = filters.Channel
+	}
+
+	spent := bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{"$status", models.StatusCanceled}},
+		0,
+		bson.M{"$subtract": bson.A{"$totalValue", bson.M{"$ifNull": bson.A{"$refundedAmount", 0}}}},
+	}}
+
+	return mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$facet", Value: bson.M{
+			"orders": bson.A{
+				bson.M{"$sort": bson.D{{Key: "purchaseDate", Value: -1}, {Key: "id", Value: -1}}},
+				bson.M{"$skip": (filters.Page - 1) * filters.PageSize},
+				bson.M{"$limit": filters.PageSize},
+			},
+			"totals": bson.A{
+				bson.M{"$group": bson.M{
+					"_id":               nil,
+					"orderCount":        bson.M{"$sum": 1},
+					"totalSpent":        bson.M{"$sum": spent},
+					"firstPurchaseDate": bson.M{"$min": "$purchaseDate"},
+					"lastPurchaseDate":  bson.M{"$max": "$purchaseDate"},
+				}},
+			},
+			"statuses": bson.A{
+				bson.M{"$group": bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}},
+			},
+			"channels": bson.A{
+				bson.M{"$group": bson.M{"_id": "$channel", "count": bson.M{"$sum": 1}}},
+				bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+				bson.M{"$limit": 1},
+			},
+		}}},
+	}
+}
@@ -0,0 +1,416 @@
+package orders
+
+import (
+	"challenge_pyegros/app/database"
+	"challenge_pyegros/app/models"
+	ports "challenge_pyegros/app/ports/orders"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testRepositoryContract checks the semantics every implementation of
+// ports.OrdersRepository must share. newRepository returns an empty
+// repository for every subtest.
+func testRepositoryContract(t *testing.T, newRepository func(t *testing.T) ports.OrdersRepository) {
+	t.Run("ObtainID is a sequence", func(t *testing.T) {
+		repo := newRepository(t)
+
+		id, err := repo.ObtainID()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), id)
+
+		id, err = repo.ObtainID()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), id)
+
+		id, err = repo.ObtainIDBlock(3)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(3), id)
+
+		id, err = repo.ObtainID()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(6), id)
+	})
+
+	t.Run("ObtainIDBlock starts the sequence", func(t *testing.T) {
+		repo := newRepository(t)
+
+		id, err := repo.ObtainIDBlock(2)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), id)
+	})
+
+	t.Run("InsertOrder and FindOrderByID", func(t *testing.T) {
+		repo := newRepository(t)
+		localOrder := contractOrder(1, "Created")
+
+		assert.Nil(t, repo.InsertOrder(localOrder))
+
+		found, err := repo.FindOrderByID(1)
+		assert.Nil(t, err)
+		assert.Equal(t, &localOrder, found)
+
+		found.Products[0].Quantity = 10
+		found, err = repo.FindOrderByID(1)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), found.Products[0].Quantity)
+
+		_, err = repo.FindOrderByID(2)
+		assert.Equal(t, mongo.ErrNoDocuments, err)
+
+		err = repo.InsertOrder(localOrder)
+		assert.True(t, mongo.IsDuplicateKeyError(err), "%v", err)
+	})
+
+	t.Run("FindOrdersByIDs skips missing orders", func(t *testing.T) {
+		repo := newRepository(t)
+		assert.Nil(t, repo.InsertOrder(contractOrder(1, "Created")))
+		assert.Nil(t, repo.InsertOrder(contractOrder(2, "Created")))
+
+		found, err := repo.FindOrdersByIDs([]int64{2, 3, 1})
+		assert.Nil(t, err)
+		assert.ElementsMatch(t, []models.Order{contractOrder(1, "Created"), contractOrder(2, "Created")}, found)
+
+		found, err = repo.FindOrdersByIDs([]int64{3})
+		assert.Nil(t, err)
+		assert.Equal(t, []models.Order{}, found)
+	})
+
+	t.Run("UpdateOrderEvents appends the events", func(t *testing.T) {
+		repo := newRepository(t)
+		assert.Nil(t, repo.InsertOrder(contractOrder(1, "Invoiced")))
+
+		returned := models.Event{
+			Id:     "event-002",
+			Type:   "Returned",
+			Date:   "2024-05-03T15:00:00Z",
+			User:   "admin002",
+			Reason: &models.EventReason{Code: "DEFECTIVE", Items: []models.ReturnedItem{{Sku: "P001", Quantity: 1}}},
+		}
+		updated := contractOrder(1, "PartiallyReturned")
+		updated.Products[0].ReturnedQuantity = 1
+		updated.RefundedAmount = 1000
+
+		// The orders are passed with the events applied, as the use case does.
+		updated.Events = []models.Event{event}
+		assert.Nil(t, repo.UpdateOrderEvents(updated, []models.Event{event}))
+		updated.Events = []models.Event{event, returned}
+		assert.Nil(t, repo.UpdateOrderEvents(updated, []models.Event{returned}))
+
+		found, err := repo.FindOrderByID(1)
+		assert.Nil(t, err)
+		assert.Equal(t, &updated, found)
+
+		missing := contractOrder(2, "PaymentReceived")
+		missing.Events = []models.Event{event}
+		assert.Equal(t, ports.ErrOrderChanged, repo.UpdateOrderEvents(missing, []models.Event{event}))
+	})
+
+	t.Run("UpdateOrderEvents rejects an order changed since it was read", func(t *testing.T) {
+		repo := newRepository(t)
+		assert.Nil(t, repo.InsertOrder(contractOrder(1, "Invoiced")))
+
+		// Two requests read the order without events and return its only unit.
+		first := contractOrder(1, "Returned")
+		first.RefundedAmount = 1000
+		first.Events = []models.Event{{Id: "event-002", Type: "Returned", Reason: &models.EventReason{Code: "DEFECTIVE"}}}
+		second := first
+		second.Events = []models.Event{{Id: "event-003", Type: "Returned", Reason: &models.EventReason{Code: "DEFECTIVE"}}}
+
+		assert.Nil(t, repo.UpdateOrderEvents(first, first.Events))
+		assert.Equal(t, ports.ErrOrderChanged, repo.UpdateOrderEvents(second, second.Events))
+
+		failures, err := repo.UpdateOrdersEvents([]models.OrderEvents{{Order: second, Events: second.Events}})
+		assert.Nil(t, err)
+		assert.Equal(t, []error{ports.ErrOrderChanged}, failures)
+
+		found, err := repo.FindOrderByID(1)
+		assert.Nil(t, err)
+		assert.Equal(t, first.Events, found.Events)
+		assert.Equal(t, 1000.0, found.RefundedAmount)
+	})
+
+	t.Run("UpdateOrdersEvents writes every order", func(t *testing.T) {
+		repo := newRepository(t)
+		assert.Nil(t, repo.InsertOrder(contractOrder(1, "Created")))
+		assert.Nil(t, repo.InsertOrder(contractOrder(2, "Created")))
+
+		paid := contractOrder(1, "PaymentReceived")
+		paid.Events = []models.Event{event}
+		canceled := contractOrder(2, "Canceled")
+		canceled.Events = []models.Event{{Id: "event-002", Type: "Canceled", Reason: &models.EventReason{Code: "OUT_OF_STOCK"}}}
+
+		failures, err := repo.UpdateOrdersEvents([]models.OrderEvents{
+			{Order: paid, Events: paid.Events},
+			{Order: canceled, Events: canceled.Events},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []error{nil, nil}, failures)
+
+		found, err := repo.FindOrderByID(1)
+		assert.Nil(t, err)
+		assert.Equal(t, models.StatusPaymentReceived, found.Status)
+		assert.Equal(t, []models.Event{event}, found.Events)
+
+		found, err = repo.FindOrderByID(2)
+		assert.Nil(t, err)
+		assert.Equal(t, models.StatusCanceled, found.Status)
+		assert.Equal(t, "OUT_OF_STOCK", found.Events[0].Reason.Code)
+	})
+
+	t.Run("UpdateAmendedOrder only amends Created orders", func(t *testing.T) {
+		repo := newRepository(t)
+		assert.Nil(t, repo.InsertOrder(contractOrder(1, "Created")))
+		assert.Nil(t, repo.InsertOrder(contractOrder(2, "PaymentReceived")))
+
+		amended := contractOrder(1, "Created")
+		amended.Buyer.Phone = "+541187654321"
+		amended.Buyer.FirstName = "Ignored"
+		amended.Products[0].Quantity = 1
+		amended.TotalValue = 1000
+		amendment := models.Event{
+			Id:      "amendment-1",
+			Type:    "Amended",
+			Date:    "2024-05-01T16:00:00Z",
+			Changes: []models.FieldChange{{Field: "products.P001.quantity", From: "2", To: "1"}},
+		}
+		amended.Events = []models.Event{amendment}
+
+		assert.Nil(t, repo.UpdateAmendedOrder(amended, amendment))
+
+		found, err := repo.FindOrderByID(1)
+		assert.Nil(t, err)
+		assert.Equal(t, "+541187654321", found.Buyer.Phone)
+		assert.Equal(t, "Patricio", found.Buyer.FirstName)
+		assert.Equal(t, int64(1), found.Products[0].Quantity)
+		assert.Equal(t, 1000.0, found.TotalValue)
+		assert.Equal(t, []models.Event{amendment}, found.Events)
+
+		amended.OrderID = 2
+		assert.Equal(t, ports.ErrOrderChanged, repo.UpdateAmendedOrder(amended, amendment))
+
+		found, err = repo.FindOrderByID(2)
+		assert.Nil(t, err)
+		assert.Equal(t, order.TotalValue, found.TotalValue)
+	})
+
+	t.Run("UpdateAmendedOrder fails when the order changed since it was read", func(t *testing.T) {
+		repo := newRepository(t)
+		assert.Nil(t, repo.InsertOrder(contractOrder(1, "Created")))
+
+		// Two requests read the order without events and amend it.
+		first := contractOrder(1, "Created")
+		first.Buyer.Phone = "+541187654321"
+		first.Events = []models.Event{{Id: "amendment-1", Type: "Amended", Changes: []models.FieldChange{{Field: "buyer.phone"}}}}
+		second := contractOrder(1, "Created")
+		second.Products[0].Quantity = 1
+		second.TotalValue = 1000
+		second.Events = []models.Event{{Id: "amendment-1", Type: "Amended", Changes: []models.FieldChange{{Field: "products.P001.quantity"}}}}
+
+		assert.Nil(t, repo.UpdateAmendedOrder(first, first.Events[0]))
+		assert.Equal(t, ports.ErrOrderChanged, repo.UpdateAmendedOrder(second, second.Events[0]))
+
+		found, err := repo.FindOrderByID(1)
+		assert.Nil(t, err)
+		assert.Equal(t, "+541187654321", found.Buyer.Phone)
+		assert.Equal(t, order.Products[0].Quantity, found.Products[0].Quantity)
+		assert.Equal(t, order.TotalValue, found.TotalValue)
+		assert.Equal(t, first.Events, found.Events)
+	})
+
+	t.Run("GetOrderByFilters", func(t *testing.T) {
+		repo := newRepository(t)
+
+		first := contractOrder(1, "PaymentReceived")
+		first.Events = []models.Event{{Id: "event-000", Type: models.EventAmended}, event}
+		second := contractOrder(2, "Created")
+		second.Channel = "Store"
+		second.PurchaseDate = "2024-06-01T14:00:00Z"
+		third := contractOrder(3, "Created")
+		third.Buyer.DocumentNumber = "12345678"
+		for _, localOrder := range []models.Order{first, second, third} {
+			assert.Nil(t, repo.InsertOrder(localOrder))
+		}
+
+		// Only the last event of every order is returned.
+		first.Events = []models.Event{event}
+
+		tests := []struct {
+			name    string
+			filters models.Filters
+			orders  []models.Order
+		}{
+			{name: "no filters", filters: models.Filters{}, orders: []models.Order{first, second, third}},
+			{name: "order id", filters: models.Filters{OrderId: 2}, orders: []models.Order{second}},
+			{name: "document number", filters: models.Filters{DocumentNumber: "12345678"}, orders: []models.Order{third}},
+			{name: "status", filters: models.Filters{Status: "Created"}, orders: []models.Order{second, third}},
+			{name: "channel", filters: models.Filters{Channel: "Store"}, orders: []models.Order{second}},
+			{name: "created on", filters: models.Filters{CreatedOnFrom: "2024-05-31T00:00:00Z", CreatedOnTo: "2024-06-30T00:00:00Z"}, orders: []models.Order{second}},
+			{name: "created on without end", filters: models.Filters{CreatedOnFrom: "2024-05-31T00:00:00Z"}, orders: []models.Order{first, second, third}},
+			{name: "every filter", filters: filters, orders: []models.Order{}},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				orders, err := repo.GetOrderByFilters(test.filters)
+				assert.Nil(t, err)
+				assert.ElementsMatch(t, test.orders, orders)
+			})
+		}
+	})
+
+	t.Run("StreamOrdersByFilters stops on error", func(t *testing.T) {
+		repo := newRepository(t)
+		for id := int64(1); id <= 3; id++ {
+			assert.Nil(t, repo.InsertOrder(contractOrder(id, "Created")))
+		}
+
+		streamed := 0
+		err := repo.StreamOrdersByFilters(models.Filters{Status: "Created"}, func(order models.Order) error {
+			streamed++
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 3, streamed)
+
+		errStop := errors.New("stop")
+		streamed = 0
+		err = repo.StreamOrdersByFilters(models.Filters{}, func(order models.Order) error {
+			streamed++
+			return errStop
+		})
+		assert.Equal(t, errStop, err)
+		assert.Equal(t, 1, streamed)
+	})
+
+	t.Run("GetBuyerOrders", func(t *testing.T) {
+		repo := newRepository(t)
+
+		canceled := contractOrder(1, "Canceled")
+		returned := contractOrder(2, "PartiallyReturned")
+		returned.Channel = "Store"
+		returned.PurchaseDate = "2024-06-01T14:00:00Z"
+		returned.RefundedAmount = 500
+		created := contractOrder(3, "Created")
+		created.PurchaseDate = "2024-04-01T14:00:00Z"
+		other := contractOrder(4, "Created")
+		other.Buyer.DocumentNumber = "12345678"
+		for _, localOrder := range []models.Order{canceled, returned, created, other} {
+			assert.Nil(t, repo.InsertOrder(localOrder))
+		}
+
+		response, err := repo.GetBuyerOrders(order.Buyer.DocumentNumber, models.BuyerFilters{Page: 1, PageSize: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, &models.ResponseBuyerOrders{
+			DocumentNumber: order.Buyer.DocumentNumber,
+			Page:           1,
+			PageSize:       2,
+			Summary: models.BuyerSummary{
+				TotalSpent:        3500,
+				OrderCount:        3,
+				OrdersByStatus:    map[string]int64{"Canceled": 1, "PartiallyReturned": 1, "Created": 1},
+				FirstPurchaseDate: "2024-04-01T14:00:00Z",
+				LastPurchaseDate:  "2024-06-01T14:00:00Z",
+				FavouriteChannel:  "Ecommerce",
+			},
+			Orders: []models.Order{returned, canceled},
+		}, response)
+
+		response, err = repo.GetBuyerOrders(order.Buyer.DocumentNumber, models.BuyerFilters{Page: 2, PageSize: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, []models.Order{created}, response.Orders)
+
+		response, err = repo.GetBuyerOrders(order.Buyer.DocumentNumber, models.BuyerFilters{Channel: "Store", Page: 1, PageSize: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), response.Summary.OrderCount)
+		assert.Equal(t, "Store", response.Summary.FavouriteChannel)
+
+		response, err = repo.GetBuyerOrders("00000000", models.BuyerFilters{Page: 1, PageSize: 2})
+		assert.Nil(t, response)
+		assert.Equal(t, mongo.ErrNoDocuments, err)
+	})
+}
+
+// contractOrder is order with the given ID and status, as it is stored.
+func contractOrder(id int64, status models.OrderStatus) models.Order {
+	localOrder := order
+	localOrder.OrderID = id
+	localOrder.Status = status
+	localOrder.Products = append([]models.Product{}, order.Products...)
+	localOrder.Events = []models.Event{}
+	return localOrder
+}
+
+func TestMemoryRepositoryContract(t *testing.T) {
+	testRepositoryContract(t, func(t *testing.T) ports.OrdersRepository {
+		return NewMemoryRepository()
+	})
+}
+
+// TestRepositoryContract runs the contract against the Mongo of
+// MONGODB_TEST_URI, with a new database for every subtest.
+func TestRepositoryContract(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI is not set")
+	}
+
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(uri))
+	assert.Nil(t, err)
+	defer client.Disconnect(context.Background())
+
+	testRepositoryContract(t, func(t *testing.T) ports.OrdersRepository {
+		name := fmt.Sprintf("orders_contract_test_%d", time.Now().UnixNano())
+		t.Cleanup(func() {
+			client.Database(name).Drop(context.Background())
+		})
+
+		registry := []database.CollectionIndexes{{Database: name, Collection: "orders", Indexes: ordersIndexes()}}
+		_, err := database.EnsureIndexes(client, registry)
+		assert.Nil(t, err)
+
+		return NewRepository(client, WithDatabase(name))
+	})
+}
+
+func TestMemoryRepositoryConcurrency(t *testing.T) {
+	repo := NewMemoryRepository()
+
+	var wg sync.WaitGroup
+	ids := make([]int64, 50)
+	for i := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := repo.ObtainID()
+			assert.Nil(t, err)
+			ids[i] = id
+			assert.Nil(t, repo.InsertOrder(contractOrder(id, "Created")))
+			paid := contractOrder(id, "PaymentReceived")
+			paid.Events = []models.Event{event}
+			assert.Nil(t, repo.UpdateOrderEvents(paid, paid.Events))
+		}()
+	}
+	wg.Wait()
+
+	assert.ElementsMatch(t, ids, func() []int64 {
+		expected := make([]int64, len(ids))
+		for i := range expected {
+			expected[i] = int64(i + 1)
+		}
+		return expected
+	}())
+
+	orders, err := repo.GetOrderByFilters(models.Filters{Status: "PaymentReceived"})
+	assert.Nil(t, err)
+	assert.Len(t, orders, len(ids))
+}
@@ -27,6 +27,13 @@ func ApplyStatusFilter(filters *models.Filters, query []bson.M) []bson.M {
 	return query
 }
 
+func ApplyChannelFilter(filters *models.Filters, query []bson.M) []bson.M {
+	if len(filters.Channel) > 0 {
+		query = append(query, bson.M{"channel": filters.Channel})
+	}
+	return query
+}
+
 func ApplyCreatedOnFilter(filters *models.Filters, query []bson.M) []bson.M {
 	if len(filters.CreatedOnFrom) > 0 && len(filters.CreatedOnTo) > 0 {
 		query = append(query, bson.M{
@@ -44,6 +51,7 @@ func ApplyFilters(filters models.Filters) []bson.M {
 	query = ApplyOrderIdFilter(&filters, query)
 	query = ApplyDocumentNumberFilter(&filters, query)
 	query = ApplyStatusFilter(&filters, query)
+	query = ApplyChannelFilter(&filters, query)
 	query = ApplyCreatedOnFilter(&filters, query)
 	return query
 }
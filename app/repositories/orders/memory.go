@@ -0,0 +1,326 @@
+package orders
+
+import (
+	"challenge_pyegros/app/models"
+	ports "challenge_pyegros/app/ports/orders"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MemoryRepository keeps the orders in memory with the semantics of
+// Repository, for tests and running the API without Mongo. It is safe for
+// concurrent use and its content is lost when the process stops.
+type MemoryRepository struct {
+	mu      sync.RWMutex
+	counter int64
+	ids     []int64
+	orders  map[int64]models.Order
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		orders: map[int64]models.Order{},
+	}
+}
+
+// ObtainID increments the counter of the orders and returns the new value.
+func (r *MemoryRepository) ObtainID() (int64, error) {
+	return r.ObtainIDBlock(1)
+}
+
+// ObtainIDBlock reserves size consecutive IDs and returns the first one.
+func (r *MemoryRepository) ObtainIDBlock(size int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.counter += size
+	return r.counter - size + 1, nil
+}
+
+// InsertOrder stores a copy of the order. An order with the ID of another one
+// fails with the duplicate key error of the id_unique index.
+func (r *MemoryRepository) InsertOrder(order models.Order) error {
+	stored, err := cloneOrder(order)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.orders[order.OrderID]; ok {
+		return mongo.WriteException{WriteErrors: mongo.WriteErrors{{
+			Code:    11000,
+			Message: "E11000 duplicate key error collection: orders.orders index: id_unique",
+		}}}
+	}
+	r.ids = append(r.ids, order.OrderID)
+	r.orders[order.OrderID] = stored
+	return nil
+}
+
+func (r *MemoryRepository) FindOrderByID(orderID int64) (*models.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stored, ok := r.orders[orderID]
+	if !ok {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	order, err := cloneOrder(stored)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// FindOrdersByIDs returns the orders of the IDs that exist, in any order.
+func (r *MemoryRepository) FindOrdersByIDs(orderIDs []int64) ([]models.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var orders = []models.Order{}
+	seen := map[int64]bool{}
+	for _, orderID := range orderIDs {
+		stored, ok := r.orders[orderID]
+		if !ok || seen[orderID] {
+			continue
+		}
+		seen[orderID] = true
+
+		order, err := cloneOrder(stored)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// UpdateOrderEvents stores the status, products and refunded amount of the
+// order after applying the events, and appends the events. It fails with
+// ports.ErrOrderChanged as Repository when the order changed since it was read.
+func (r *MemoryRepository) UpdateOrderEvents(order models.Order, events []models.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.updateOrderEvents(order, events)
+}
+
+// UpdateOrdersEvents writes the events applied to every order. The errors keep
+// the position of the updates, nil for the ones written.
+func (r *MemoryRepository) UpdateOrdersEvents(updates []models.OrderEvents) ([]error, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	failures := make([]error, len(updates))
+	for i, update := range updates {
+		failures[i] = r.updateOrderEvents(update.Order, update.Events)
+	}
+	return failures, nil
+}
+
+func (r *MemoryRepository) updateOrderEvents(order models.Order, events []models.Event) error {
+	stored, ok := r.orders[order.OrderID]
+	if !ok || len(stored.Events) != len(order.Events)-len(events) {
+		return ports.ErrOrderChanged
+	}
+
+	update, err := cloneOrder(models.Order{Products: order.Products, Events: events})
+	if err != nil {
+		return err
+	}
+
+	stored.Status = order.Status
+	stored.Products = update.Products
+	stored.RefundedAmount = order.RefundedAmount
+	stored.Events = append(stored.Events, update.Events...)
+	r.orders[order.OrderID] = stored
+	return nil
+}
+
+// UpdateAmendedOrder stores the amendable fields of the order and appends the
+// Amended event, the last one of order.Events. As UpdateOrderEvents, it fails
+// with ports.ErrOrderChanged when the order was written after it was read or
+// is not in Created status anymore.
+func (r *MemoryRepository) UpdateAmendedOrder(order models.Order, event models.Event) error {
+	update, err := cloneOrder(models.Order{Products: order.Products, Events: []models.Event{event}})
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.orders[order.OrderID]
+	if !ok || stored.Status != models.StatusCreated || len(stored.Events) != len(order.Events)-1 {
+		return ports.ErrOrderChanged
+	}
+
+	stored.Buyer.Phone = order.Buyer.Phone
+	stored.Products = update.Products
+	stored.TotalValue = order.TotalValue
+	stored.Events = append(stored.Events, update.Events...)
+	r.orders[order.OrderID] = stored
+	return nil
+}
+
+func (r *MemoryRepository) GetOrderByFilters(filters models.Filters) ([]models.Order, error) {
+	return r.searchOrders(filters)
+}
+
+// StreamOrdersByFilters calls fn with every order that matches the filters. An
+// error of fn stops the stream and is returned.
+func (r *MemoryRepository) StreamOrdersByFilters(filters models.Filters, fn func(order models.Order) error) error {
+	orders, err := r.searchOrders(filters)
+	if err != nil {
+		return err
+	}
+
+	for _, order := range orders {
+		if err = fn(order); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// searchOrders returns the orders that match the filters in the order they
+// were inserted, with only their last event like the projection of Mongo.
+func (r *MemoryRepository) searchOrders(filters models.Filters) ([]models.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var orders = []models.Order{}
+	for _, id := range r.ids {
+		stored := r.orders[id]
+		if !matchFilters(stored, filters) {
+			continue
+		}
+
+		order, err := cloneOrder(stored)
+		if err != nil {
+			return nil, err
+		}
+		if len(order.Events) > 1 {
+			order.Events = order.Events[len(order.Events)-1:]
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// matchFilters is the in memory version of ApplyFilters.
+func matchFilters(order models.Order, filters models.Filters) bool {
+	if filters.OrderId > 0 && order.OrderID != filters.OrderId {
+		return false
+	}
+	if len(filters.DocumentNumber) > 0 && order.Buyer.DocumentNumber != filters.DocumentNumber {
+		return false
+	}
+	if len(filters.Status) > 0 && order.Status != filters.Status {
+		return false
+	}
+	if len(filters.Channel) > 0 && order.Channel != filters.Channel {
+		return false
+	}
+	if len(filters.CreatedOnFrom) > 0 && len(filters.CreatedOnTo) > 0 {
+		if order.PurchaseDate < filters.CreatedOnFrom || order.PurchaseDate > filters.CreatedOnTo {
+			return false
+		}
+	}
+	return true
+}
+
+// GetBuyerOrders returns a page of the orders of a buyer, the most recent
+// first, and the summary of all of them.
+func (r *MemoryRepository) GetBuyerOrders(documentNumber string, filters models.BuyerFilters) (*models.ResponseBuyerOrders, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var buyerOrders []models.Order
+	for _, id := range r.ids {
+		order := r.orders[id]
+		if order.Buyer.DocumentNumber != documentNumber {
+			continue
+		}
+		if len(filters.Channel) > 0 && order.Channel != filters.Channel {
+			continue
+		}
+		buyerOrders = append(buyerOrders, order)
+	}
+	if len(buyerOrders) == 0 {
+		return nil, mongo.ErrNoDocuments
+	}
+
+	summary := models.BuyerSummary{
+		OrderCount:        int64(len(buyerOrders)),
+		OrdersByStatus:    map[string]int64{},
+		FirstPurchaseDate: buyerOrders[0].PurchaseDate,
+		LastPurchaseDate:  buyerOrders[0].PurchaseDate,
+	}
+	channels := map[string]int64{}
+	for _, order := range buyerOrders {
+		if order.Status != models.StatusCanceled {
+			summary.TotalSpent += order.TotalValue - order.RefundedAmount
+		}
+		if order.PurchaseDate < summary.FirstPurchaseDate {
+			summary.FirstPurchaseDate = order.PurchaseDate
+		}
+		if order.PurchaseDate > summary.LastPurchaseDate {
+			summary.LastPurchaseDate = order.PurchaseDate
+		}
+		summary.OrdersByStatus[string(order.Status)]++
+		channels[string(order.Channel)]++
+	}
+	for channel, count := range channels {
+		favourite := channels[summary.FavouriteChannel]
+		if count > favourite || (count == favourite && channel < summary.FavouriteChannel) {
+			summary.FavouriteChannel = channel
+		}
+	}
+
+	sort.Slice(buyerOrders, func(i, j int) bool {
+		if buyerOrders[i].PurchaseDate != buyerOrders[j].PurchaseDate {
+			return buyerOrders[i].PurchaseDate > buyerOrders[j].PurchaseDate
+		}
+		return buyerOrders[i].OrderID > buyerOrders[j].OrderID
+	})
+
+	var orders = []models.Order{}
+	start := (filters.Page - 1) * filters.PageSize
+	for i := start; i >= 0 && i < start+filters.PageSize && i < int64(len(buyerOrders)); i++ {
+		order, err := cloneOrder(buyerOrders[i])
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	response := &models.ResponseBuyerOrders{
+		DocumentNumber: documentNumber,
+		Page:           filters.Page,
+		PageSize:       filters.PageSize,
+		Summary:        summary,
+		Orders:         orders,
+	}
+
+	return response, nil
+}
+
+// cloneOrder copies the order through BSON, so the stored orders share no
+// memory with the callers and read back as they would from Mongo.
+func cloneOrder(order models.Order) (models.Order, error) {
+	var clone models.Order
+
+	document, err := bson.Marshal(order)
+	if err != nil {
+		return clone, err
+	}
+	err = bson.Unmarshal(document, &clone)
+	return clone, err
+}
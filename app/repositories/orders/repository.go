@@ -1,202 +1,159 @@
 package orders
 
 import (
-	"challenge_pyegros/app/database"
 	"challenge_pyegros/app/models"
+	ports "challenge_pyegros/app/ports/orders"
 	"context"
 	"errors"
-	"fmt"
 
-	"github.com/redis/go-redis/v9"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var (
-	ErrTotalMismatch             = errors.New("Total value does not match sum of products")
-	ErrParsingID                 = errors.New("Error parsing order ID")
-	ErrMismatchExternalReference = errors.New("External ReferenceId does not match with channel")
-	ErrChannelNotFound           = errors.New("Channel not found")
-	ErrCreatingAutoIncrementalId = errors.New("Error creating auto incremental ID")
-	ErrGettingAutoIncrementalId  = errors.New("Error getting auto incremental ID")
 	ErrUpdatingAutoIncrementalId = errors.New("Error updating auto incremental ID")
-	ErrAnotherEventWithSameID    = errors.New("Another event with same ID already exists")
 )
 
+// Repository persists the orders in Mongo. The rules of the orders are
+// applied by the use case before calling it.
 type Repository struct {
 	db       *mongo.Client
-	obtainID func() (int64, error)
-	redis    *redis.Client
+	database string
 }
 
-func NewRepository(client *mongo.Client, redis *redis.Client) *Repository {
-	repo := &Repository{
-		db:    client,
-		redis: redis,
-	}
-	repo.obtainID = repo.defaultObtainID
-	return repo
-}
-
-func (r *Repository) CreateOrder(order models.Order) (*models.ResponseCreate, error) {
-	keyForRedisCache := order.ExternalReferenceID + "-" + order.Channel
-
-	responseCache, err := database.GetOrderDataFromRedis(keyForRedisCache, r.redis)
-	if err == redis.Nil {
-		fmt.Println("Key " + keyForRedisCache + " dont exist in Cache")
-	} else if err != nil {
-		fmt.Println("Error getting value of: " + keyForRedisCache)
-	} else if responseCache != nil {
-		fmt.Println("Get value from cache")
-		return responseCache, nil
-	}
+type Option func(*Repository)
 
-	if !validateTotal(order.Products, order.TotalValue) {
-		return nil, ErrTotalMismatch
-	}
-
-	isValid, err := validateExternalReferenceId(order.ExternalReferenceID, order.Channel)
-	if err != nil || !isValid {
-		return nil, err
+// WithDatabase sets the database of the orders and counters collections,
+// "orders" by default.
+func WithDatabase(name string) Option {
+	return func(r *Repository) {
+		r.database = name
 	}
+}
 
-	var id int64
-	id, err = r.obtainID()
-	if err != nil {
-		return nil, err
+func NewRepository(client *mongo.Client, options ...Option) *Repository {
+	repo := &Repository{
+		db:       client,
+		database: "orders",
 	}
-	order.OrderID = id
-	order.Status = "Created"
-	order.Events = []models.Event{}
-
-	collection := r.db.Database("orders").Collection("orders")
-	_, err = collection.InsertOne(context.TODO(), order)
-	if err != nil {
-		return nil, err
+	for _, option := range options {
+		option(repo)
 	}
+	return repo
+}
 
-	response := &models.ResponseCreate{
-		OrderID:   id,
-		Status:    "Created",
-		UpdatedOn: order.PurchaseDate,
-	}
+func (r *Repository) InsertOrder(order models.Order) error {
+	collection := r.db.Database(r.database).Collection("orders")
 
-	err = database.SetOrderDataFromRedis(keyForRedisCache, response, r.redis)
-	if err != nil {
-		fmt.Println("Error seting value of: " + keyForRedisCache)
-	} else {
-		fmt.Println("Set value of key: " + keyForRedisCache)
-	}
-	return response, nil
+	_, err := collection.InsertOne(context.TODO(), order)
+	return err
 }
 
-func (r *Repository) UpdateEventOrder(orderID int64, event models.Event) (*models.ResponseUpdate, error) {
-	collection := r.db.Database("orders").Collection("orders")
+func (r *Repository) FindOrderByID(orderID int64) (*models.Order, error) {
+	collection := r.db.Database(r.database).Collection("orders")
 
 	filter := bson.M{"id": orderID}
 
-	responseCache, err := database.GetEventDataFromRedis(event.Id, r.redis)
-	if err == redis.Nil {
-		fmt.Println("Key " + event.Id + " dont exist in Cache")
-	} else if err != nil {
-		fmt.Println("Error getting value of: " + event.Id)
-	} else if responseCache != nil {
-		fmt.Println("Get value from cache")
-		return responseCache, nil
-	}
-
 	var order models.Order
-	err = collection.FindOne(context.TODO(), filter).Decode(&order)
-	if err != nil {
-		return nil, err
-	}
-
-	newStatus, err := validateStateTransition(order.Status, event.Type)
+	err := collection.FindOne(context.TODO(), filter).Decode(&order)
 	if err != nil {
 		return nil, err
 	}
 
-	response := &models.ResponseUpdate{
-		OrderID:        order.OrderID,
-		PreviousStatus: order.Status,
-		NewStatus:      newStatus,
-		UpdatedOn:      event.Date,
-	}
-
-	unique, errEvent := checkUniqueEventID(order.Events, event)
-	if errEvent != nil {
-		return nil, errEvent
-	} else {
-		if !unique {
-			return response, nil
-		}
-	}
+	return &order, nil
+}
 
-	update := bson.M{"$set": bson.M{"status": newStatus}, "$push": bson.M{"events": event}}
+// FindOrdersByIDs returns the orders of the IDs that exist, in any order.
+func (r *Repository) FindOrdersByIDs(orderIDs []int64) ([]models.Order, error) {
+	collection := r.db.Database(r.database).Collection("orders")
 
-	_, err = collection.UpdateOne(context.TODO(), filter, update)
+	cursor, err := collection.Find(context.TODO(), bson.M{"id": bson.M{"$in": orderIDs}})
 	if err != nil {
 		return nil, err
 	}
 
-	err = database.SetEventDataFromRedis(event.Id, response, r.redis)
-	if err != nil {
-		fmt.Println("Error seting value of: " + event.Id)
-	} else {
-		fmt.Println("Set value of key: " + event.Id)
+	var orders = []models.Order{}
+	if err = cursor.All(context.TODO(), &orders); err != nil {
+		return nil, err
 	}
-
-	return response, nil
+	return orders, nil
 }
 
-func (r *Repository) GetOrderByID(orderID int64) (*models.ResponseGet, error) {
-	collection := r.db.Database("orders").Collection("orders")
+// UpdateOrderEvents stores the status, products and refunded amount of the
+// order after applying the events, and appends the events. The events of the
+// order must end with the applied ones, when the stored order does not have
+// the events it was read with it fails with ports.ErrOrderChanged.
+func (r *Repository) UpdateOrderEvents(order models.Order, events []models.Event) error {
+	collection := r.db.Database(r.database).Collection("orders")
 
-	filter := bson.M{"id": orderID}
-
-	var order *models.Order
-	err := collection.FindOne(context.TODO(), filter).Decode(&order)
+	result, err := collection.UpdateOne(context.TODO(), eventFilter(order, events), eventUpdate(order, events))
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	translations := translate(order.Channel, order.Status)
-
-	response := &models.ResponseGet{
-		OrderID:             order.OrderID,
-		ExternalReferenceID: order.ExternalReferenceID,
-		Channel:             order.Channel,
-		ChannelTranslate:    translations[0],
-		PurchaseDate:        order.PurchaseDate,
-		TotalValue:          order.TotalValue,
-		Buyer:               order.Buyer,
-		Products:            order.Products,
-		Status:              order.Status,
-		StatusTranslate:     translations[1],
-		Events:              order.Events,
+	if result.MatchedCount == 0 {
+		return ports.ErrOrderChanged
 	}
-
-	return response, nil
+	return nil
 }
 
-func (r *Repository) GetOrderByFilters(filters models.Filters) ([]models.Order, error) {
-	collection := r.db.Database("orders").Collection("orders")
+// eventFilter matches the order while it has as many events as when it was
+// read. Every write of an order appends an event, so the amount of events is
+// the version of the order.
+func eventFilter(order models.Order, events []models.Event) bson.M {
+	read := len(order.Events) - len(events)
+	if read == 0 {
+		return bson.M{"id": order.OrderID, "$or": bson.A{
+			bson.M{"events": bson.M{"$size": 0}},
+			bson.M{"events": nil},
+		}}
+	}
+	return bson.M{"id": order.OrderID, "events": bson.M{"$size": read}}
+}
 
-	query := ApplyFilters(filters)
+// eventUpdate stores the state of the order after applying the events.
+func eventUpdate(order models.Order, events []models.Event) bson.M {
+	return bson.M{
+		"$set": bson.M{
+			"status":         order.Status,
+			"products":       order.Products,
+			"refundedAmount": order.RefundedAmount,
+		},
+		"$push": bson.M{"events": bson.M{"$each": events}},
+	}
+}
 
-	projection := bson.M{
-		"events": bson.M{
-			"$slice": -1,
+// UpdateAmendedOrder stores the amendable fields of the order and appends the
+// Amended event, the last one of order.Events. As UpdateOrderEvents, it fails
+// with ports.ErrOrderChanged when the order was written after it was read or
+// is not in Created status anymore.
+func (r *Repository) UpdateAmendedOrder(order models.Order, event models.Event) error {
+	collection := r.db.Database(r.database).Collection("orders")
+
+	update := bson.M{
+		"$set": bson.M{
+			"buyer.phone": order.Buyer.Phone,
+			"products":    order.Products,
+			"totalValue":  order.TotalValue,
 		},
+		"$push": bson.M{"events": event},
 	}
 
-	filtersQuery := bson.M{}
-	if len(query) > 0 {
-		filtersQuery = bson.M{"$and": query}
+	filter := eventFilter(order, []models.Event{event})
+	filter["status"] = models.StatusCreated
+
+	result, err := collection.UpdateOne(context.TODO(), filter, update)
+	if err != nil {
+		return err
 	}
+	if result.MatchedCount == 0 {
+		return ports.ErrOrderChanged
+	}
+	return nil
+}
 
-	cursor, err := collection.Find(context.TODO(), filtersQuery, options.Find().SetProjection(projection))
+func (r *Repository) GetOrderByFilters(filters models.Filters) ([]models.Order, error) {
+	cursor, err := r.searchOrders(filters)
 	if err != nil {
 		return nil, err
 	}
@@ -208,138 +165,49 @@ func (r *Repository) GetOrderByFilters(filters models.Filters) ([]models.Order,
 	return orders, nil
 }
 
-func validateTotal(products []models.Product, total float64) bool {
-	var totalCalculated float64
-
-	for _, product := range products {
-		totalCalculated = (product.Price * float64(product.Quantity)) + totalCalculated
-	}
-
-	if totalCalculated != total {
-		return false
+// StreamOrdersByFilters calls fn with every order that matches the filters as
+// it is read from the cursor, so the results are never loaded at once. An
+// error of fn stops the stream and is returned.
+func (r *Repository) StreamOrdersByFilters(filters models.Filters, fn func(order models.Order) error) error {
+	cursor, err := r.searchOrders(filters)
+	if err != nil {
+		return err
 	}
+	defer cursor.Close(context.TODO())
 
-	return true
-}
-
-func validateStateTransition(actualStatus string, typeEvent string) (string, error) {
-	err := errors.New("Invalid state transition")
-
-	switch typeEvent {
-	case "PaymentReceived":
-		if actualStatus == "Created" {
-			return "PaymentReceived", nil
-		}
-	case "Canceled":
-		if actualStatus == "Created" {
-			return "Canceled", nil
-		}
-	case "Invoiced":
-		if actualStatus == "PaymentReceived" {
-			return "Invoiced", nil
+	for cursor.Next(context.TODO()) {
+		var order models.Order
+		if err = cursor.Decode(&order); err != nil {
+			return err
 		}
-	case "Returned":
-		if actualStatus == "Invoiced" {
-			return "Returned", nil
+		if err = fn(order); err != nil {
+			return err
 		}
 	}
-
-	return "", err
-}
-
-func checkUniqueEventID(events []models.Event, newEvent models.Event) (bool, error) {
-	for _, event := range events {
-		if event.Id == newEvent.Id {
-			if event.Date == newEvent.Date && event.Type == newEvent.Type {
-				return false, nil
-			} else {
-				return false, ErrAnotherEventWithSameID
-			}
-		}
-	}
-	return true, nil
+	return cursor.Err()
 }
 
-func validateExternalReferenceId(id string, channel string) (bool, error) {
-	values := map[string]string{
-		"Ecommerce":  "abc-123",
-		"CallCenter": "def-456",
-		"Store":      "ghi-789",
-		"Affiliate":  "jkl-012",
-	}
+func (r *Repository) searchOrders(filters models.Filters) (*mongo.Cursor, error) {
+	collection := r.db.Database(r.database).Collection("orders")
 
-	v, ok := values[channel]
-	if ok {
-		if v == id {
-			return true, nil
-		} else {
-			return false, ErrMismatchExternalReference
-		}
-	} else {
-		return false, ErrChannelNotFound
-	}
-}
+	query := ApplyFilters(filters)
 
-func (r *Repository) defaultObtainID() (int64, error) {
-	filter := bson.M{"_id": "orders"}
-	var counter models.Counter
-	collection := r.db.Database("orders").Collection("counters")
-	err := collection.FindOne(context.TODO(), filter).Decode(&counter)
-	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			newCounter := models.Counter{
-				ID:            "orders",
-				SequenceValue: 1,
-			}
-			_, err := collection.InsertOne(context.TODO(), newCounter)
-			if err != nil {
-				return 0, ErrCreatingAutoIncrementalId
-			}
-			return 1, nil
-		} else {
-			fmt.Println(err.Error())
-			return 0, ErrGettingAutoIncrementalId
-		}
-	} else {
-		update := bson.M{"$inc": bson.M{"sequence_value": 1}}
-		_, err := collection.UpdateOne(context.TODO(), filter, update)
-		if err != nil {
-			return 0, ErrUpdatingAutoIncrementalId
-		}
-		return counter.SequenceValue + 1, nil
+	projection := bson.M{
+		"events": bson.M{
+			"$slice": -1,
+		},
 	}
-}
 
-func translate(channel string, status string) []string {
-	var translations = []string{}
-
-	switch channel {
-	case "Ecommerce":
-		translations = append(translations, "Comercio Electrónico")
-	case "CallCenter":
-		translations = append(translations, "Centro de Llamadas")
-	case "Store":
-		translations = append(translations, "Tienda")
-	case "Affiliate":
-		translations = append(translations, "Afiliado")
-	default:
-		translations = append(translations, channel)
+	filtersQuery := bson.M{}
+	if len(query) > 0 {
+		filtersQuery = bson.M{"$and": query}
 	}
 
-	switch status {
-	case "Created":
-		translations = append(translations, "Creado")
-	case "PaymentReceived":
-		translations = append(translations, "Pago Recibido")
-	case "Canceled":
-		translations = append(translations, "Cancelado")
-	case "Invoiced":
-		translations = append(translations, "Facturado")
-	case "Returned":
-		translations = append(translations, "Devuelto")
-	default:
-		translations = append(translations, status)
-	}
+	return collection.Find(context.TODO(), filtersQuery, options.Find().SetProjection(projection))
+}
 
-	return translations
+// ObtainID increments the counter of the orders and returns the new value,
+// with the atomic increment of ObtainIDBlock.
+func (r *Repository) ObtainID() (int64, error) {
+	return r.ObtainIDBlock(1)
 }
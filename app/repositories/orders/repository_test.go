@@ -1,15 +1,19 @@
 package orders
 
 import (
+	"challenge_pyegros/app/database"
 	"challenge_pyegros/app/models"
+	ports "challenge_pyegros/app/ports/orders"
+	"context"
+	"errors"
+	"os"
 	"testing"
 
-	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
-
-	"github.com/alicebob/miniredis/v2"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var (
@@ -36,11 +40,6 @@ var (
 		Events: []models.Event{},
 	}
 
-	counter = models.Counter{
-		ID:            "orders",
-		SequenceValue: 1,
-	}
-
 	event = models.Event{
 		Id:   "event-001",
 		Type: "PaymentReceived",
@@ -55,177 +54,314 @@ var (
 		CreatedOnFrom:  order.PurchaseDate,
 		CreatedOnTo:    order.PurchaseDate,
 	}
-)
 
-func CreateCacheForTesting(t *testing.T) *redis.Client {
-	s := miniredis.RunT(t)
-
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     s.Addr(),
-		Password: "",
-		DB:       0,
+	commandError = mtest.CreateCommandErrorResponse(mtest.CommandError{
+		Code:    66,
+		Message: "some error",
+		Name:    "SomeError",
+		Labels:  []string{},
 	})
+)
 
-	return rdb
+func orderDocument(id int64, status string) bson.D {
+	return bson.D{
+		{Key: "id", Value: id},
+		{Key: "externalReferenceID", Value: order.ExternalReferenceID},
+		{Key: "channel", Value: order.Channel},
+		{Key: "purchaseDate", Value: order.PurchaseDate},
+		{Key: "totalValue", Value: order.TotalValue},
+		{Key: "buyer", Value: order.Buyer},
+		{Key: "products", Value: order.Products},
+		{Key: "status", Value: status},
+		{Key: "events", Value: order.Events},
+	}
 }
-func TestCreateOrderSuccess(t *testing.T) {
-	rdb := CreateCacheForTesting(t)
 
+func TestInsertOrder(t *testing.T) {
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
-
 	mt.Run("success", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
 
-		ordersRepo := NewRepository(mt.Client, rdb)
+		err := ordersRepo.InsertOrder(order)
+		assert.Nil(t, err)
+	})
 
-		mt.AddMockResponses(mtest.CreateSuccessResponse())
+	mt.Run("fails insert one", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
+			Code:    11000,
+			Message: "duplicate key error",
+			Index:   0,
+		}))
 
-		ordersRepo.obtainID = func() (int64, error) {
-			return counter.SequenceValue, nil // Devuelve el valor de counter.SequenceValue
-		}
+		err := ordersRepo.InsertOrder(order)
+		assert.True(t, mongo.IsDuplicateKeyError(err))
+	})
+}
 
-		model, err := ordersRepo.CreateOrder(order)
+func TestFindOrderByID(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("success", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "orders.orders", mtest.FirstBatch, orderDocument(1, "Created")))
 
-		response := &models.ResponseCreate{
-			OrderID:   1,
-			Status:    "Created",
-			UpdatedOn: "2024-05-01T14:00:00Z",
-		}
+		model, err := ordersRepo.FindOrderByID(1)
+		response := order
+		response.OrderID = 1
+		response.Status = "Created"
 
 		assert.Nil(t, err)
-		assert.Equal(t, model, response)
-
+		assert.Equal(t, &response, model)
 	})
-}
-
-func TestCreateOrderTotalMismatch(t *testing.T) {
-	rdb := CreateCacheForTesting(t)
 
-	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("not found", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "orders.orders", mtest.FirstBatch))
 
-	mt.Run("total mismatch", func(mt *mtest.T) {
-		ordersRepo := NewRepository(mt.Client, rdb)
-		localOrder := order
-		localOrder.TotalValue = 3000
-		model, err := ordersRepo.CreateOrder(localOrder)
+		model, err := ordersRepo.FindOrderByID(1)
 		assert.Nil(t, model)
-		assert.Equal(t, err, ErrTotalMismatch)
+		assert.Equal(t, mongo.ErrNoDocuments, err)
 	})
-}
 
-func TestCreateOrderInvalidExternalReferenceID(t *testing.T) {
-	rdb := CreateCacheForTesting(t)
+	mt.Run("fails find one", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(commandError)
 
-	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
-	mt.Run("invalid external reference id", func(mt *mtest.T) {
-		ordersRepo := NewRepository(mt.Client, rdb)
-		localOrder := order
-		localOrder.ExternalReferenceID = "invalid_id"
-		model, err := ordersRepo.CreateOrder(localOrder)
+		model, err := ordersRepo.FindOrderByID(1)
 		assert.Nil(t, model)
-		assert.Equal(t, err, ErrMismatchExternalReference)
+		assert.NotNil(t, err)
 	})
 }
 
-func TestCreateOrderObtainIDError(t *testing.T) {
-	rdb := CreateCacheForTesting(t)
-
+func TestFindOrdersByIDs(t *testing.T) {
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
-	mt.Run("obtain id error", func(mt *mtest.T) {
-		ordersRepo := NewRepository(mt.Client, rdb)
-		ordersRepo.obtainID = func() (int64, error) {
-			return 0, ErrCreatingAutoIncrementalId
-		}
-		model, err := ordersRepo.CreateOrder(order)
-		assert.Nil(t, model)
-		assert.Equal(t, err, ErrCreatingAutoIncrementalId)
+	mt.Run("success", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "orders.orders", mtest.FirstBatch, orderDocument(1, "Created"), orderDocument(3, "Invoiced")))
+
+		found, err := ordersRepo.FindOrdersByIDs([]int64{1, 2, 3})
+		assert.Nil(t, err)
+		assert.Len(t, found, 2)
+		assert.Equal(t, models.StatusInvoiced, found[1].Status)
 	})
-}
 
-func TestCreateOrderFailsInsertOne(t *testing.T) {
-	rdb := CreateCacheForTesting(t)
+	mt.Run("fails find", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(commandError)
+
+		found, err := ordersRepo.FindOrdersByIDs([]int64{1})
+		assert.Nil(t, found)
+		assert.NotNil(t, err)
+	})
+}
 
+func TestUpdateOrderEvents(t *testing.T) {
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
-	mt.Run("fails insert one", func(mt *mtest.T) {
-		ordersRepo := NewRepository(mt.Client, rdb)
-		ordersRepo.obtainID = func() (int64, error) {
-			return counter.SequenceValue, nil
-		}
+	mt.Run("success", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		localOrder := order
+		localOrder.OrderID = 1
+		localOrder.Status = "PaymentReceived"
+		err := ordersRepo.UpdateOrderEvents(localOrder, []models.Event{event})
+		assert.Nil(t, err)
+	})
+
+	mt.Run("fails update one", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
 		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
 			Code:    11000,
 			Message: "duplicate key error",
 			Index:   0,
 		}))
-		model, err := ordersRepo.CreateOrder(order)
-		assert.Nil(t, model)
+
+		err := ordersRepo.UpdateOrderEvents(order, []models.Event{event})
 		assert.NotNil(t, err)
 	})
+
+	mt.Run("order changed", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}))
+
+		localOrder := order
+		localOrder.OrderID = 1
+		localOrder.Events = []models.Event{event}
+		err := ordersRepo.UpdateOrderEvents(localOrder, []models.Event{event})
+		assert.Equal(t, ports.ErrOrderChanged, err)
+	})
+}
+
+func TestEventFilter(t *testing.T) {
+	localOrder := order
+	localOrder.OrderID = 1
+	localOrder.Events = []models.Event{event}
+	assert.Equal(t, bson.M{"id": int64(1), "$or": bson.A{
+		bson.M{"events": bson.M{"$size": 0}},
+		bson.M{"events": nil},
+	}}, eventFilter(localOrder, []models.Event{event}))
+
+	returned := models.Event{Id: "event-002", Type: "Returned"}
+	localOrder.Events = []models.Event{event, returned}
+	assert.Equal(t, bson.M{"id": int64(1), "events": bson.M{"$size": 1}}, eventFilter(localOrder, []models.Event{returned}))
 }
 
-func TestGetOrderByIDSuccess(t *testing.T) {
-	rdb := CreateCacheForTesting(t)
+func TestEventUpdate(t *testing.T) {
+	localOrder := order
+	localOrder.Status = "PartiallyReturned"
+	localOrder.RefundedAmount = 1000
+
+	update := eventUpdate(localOrder, []models.Event{event})
+	assert.Equal(t, bson.M{
+		"$set": bson.M{
+			"status":         models.StatusPartiallyReturned,
+			"products":       order.Products,
+			"refundedAmount": 1000.0,
+		},
+		"$push": bson.M{"events": bson.M{"$each": []models.Event{event}}},
+	}, update)
+}
 
+func TestUpdateAmendedOrder(t *testing.T) {
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
-	mt.Run("success", func(mt *mtest.T) {
-		ordersRepo := NewRepository(mt.Client, rdb)
-		firstResponse := mtest.CreateCursorResponse(1, "orders.orders", mtest.FirstBatch, bson.D{
-			{Key: "id", Value: 1},
-			{Key: "externalReferenceID", Value: order.ExternalReferenceID},
-			{Key: "channel", Value: order.Channel},
-			{Key: "purchaseDate", Value: order.PurchaseDate},
-			{Key: "totalValue", Value: order.TotalValue},
-			{Key: "buyer", Value: order.Buyer},
-			{Key: "products", Value: order.Products},
-			{Key: "status", Value: "Created"},
-			{Key: "events", Value: order.Events},
+	mt.Run("updates the created order", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+		amendment := models.Event{Id: "amendment-1", Type: "Amended"}
+		localOrder := order
+		localOrder.Events = []models.Event{amendment}
+		assert.Nil(t, ordersRepo.UpdateAmendedOrder(localOrder, amendment))
+	})
+
+	mt.Run("order changed", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}))
+
+		amendment := models.Event{Id: "amendment-1", Type: "Amended"}
+		localOrder := order
+		localOrder.Events = []models.Event{amendment}
+		assert.Equal(t, ports.ErrOrderChanged, ordersRepo.UpdateAmendedOrder(localOrder, amendment))
+	})
+}
+
+func TestObtainID(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("creates the counter", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "value", Value: bson.D{{Key: "_id", Value: "orders"}, {Key: "sequence_value", Value: 1}}},
 		})
 
-		mt.AddMockResponses(firstResponse)
-
-		model, err := ordersRepo.GetOrderByID(1)
-		response := &models.ResponseGet{
-			OrderID:             1,
-			ExternalReferenceID: order.ExternalReferenceID,
-			Channel:             order.Channel,
-			ChannelTranslate:    "Comercio Electrónico",
-			PurchaseDate:        order.PurchaseDate,
-			TotalValue:          order.TotalValue,
-			Buyer:               order.Buyer,
-			Products:            order.Products,
-			Status:              "Created",
-			StatusTranslate:     "Creado",
-			Events:              order.Events,
-		}
+		id, err := ordersRepo.ObtainID()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), id)
+	})
+
+	mt.Run("increments the counter", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "value", Value: bson.D{{Key: "_id", Value: "orders"}, {Key: "sequence_value", Value: 5}}},
+		})
 
+		id, err := ordersRepo.ObtainID()
 		assert.Nil(t, err)
-		assert.Equal(t, model, response)
+		assert.Equal(t, int64(5), id)
+	})
+
+	mt.Run("fails find one and update", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(commandError)
+
+		id, err := ordersRepo.ObtainID()
+		assert.Equal(t, int64(0), id)
+		assert.Equal(t, ErrUpdatingAutoIncrementalId, err)
 	})
 }
 
-func TestGetOrderByIDFailsFindOne(t *testing.T) {
-	rdb := CreateCacheForTesting(t)
+func TestObtainIDBlock(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("allocates a block", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(bson.D{
+			{Key: "ok", Value: 1},
+			{Key: "value", Value: bson.D{{Key: "_id", Value: "orders"}, {Key: "sequence_value", Value: 15}}},
+		})
+
+		id, err := ordersRepo.ObtainIDBlock(5)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(11), id)
+	})
+}
+
+func TestUpdateOrdersEvents(t *testing.T) {
+	updates := []models.OrderEvents{
+		{Order: models.Order{OrderID: 1, Status: "PaymentReceived", Events: []models.Event{event}}, Events: []models.Event{event}},
+		{Order: models.Order{OrderID: 2, Status: "PaymentReceived", Events: []models.Event{event}}, Events: []models.Event{event}},
+	}
+	// written is the order 1 as stored after its update, read to find the
+	// updates that did not match.
+	written := mtest.CreateCursorResponse(0, "orders.orders", mtest.FirstBatch, bson.D{
+		{Key: "id", Value: 1},
+		{Key: "status", Value: "PaymentReceived"},
+		{Key: "events", Value: bson.A{bson.D{{Key: "id", Value: event.Id}, {Key: "type", Value: event.Type}}}},
+	})
 
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
-	mt.Run("fails find one", func(mt *mtest.T) {
-		ordersRepo := NewRepository(mt.Client, rdb)
-		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
-			Code:    66,
-			Message: "some error",
-			Name:    "SomeError",
-			Labels:  []string{},
-		}))
+	mt.Run("writes the batch", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 2}, bson.E{Key: "nModified", Value: 2}))
 
-		model, err := ordersRepo.GetOrderByID(1)
-		assert.Nil(t, model)
+		failures, err := ordersRepo.UpdateOrdersEvents(updates)
+		assert.Nil(t, err)
+		assert.Equal(t, []error{nil, nil}, failures)
+	})
+
+	mt.Run("fails write", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 1, Code: 2, Message: "write failed"}), written)
+
+		failures, err := ordersRepo.UpdateOrdersEvents(updates)
+		assert.Nil(t, err)
+		assert.Nil(t, failures[0])
+		assert.NotNil(t, failures[1])
+	})
+
+	mt.Run("order changed", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}), written)
+
+		failures, err := ordersRepo.UpdateOrdersEvents(updates)
+		assert.Nil(t, err)
+		assert.Equal(t, []error{nil, ports.ErrOrderChanged}, failures)
+	})
+
+	mt.Run("fails reading the changed orders", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}), commandError)
+
+		failures, err := ordersRepo.UpdateOrdersEvents(updates)
+		assert.Nil(t, failures)
+		assert.NotNil(t, err)
+	})
+
+	mt.Run("fails command", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(commandError)
+
+		failures, err := ordersRepo.UpdateOrdersEvents(updates)
+		assert.Nil(t, failures)
 		assert.NotNil(t, err)
 	})
 }
 
 func TestGetOrderByFiltersSuccess(t *testing.T) {
-	rdb := CreateCacheForTesting(t)
-
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 	mt.Run("success", func(mt *mtest.T) {
-		ordersRepo := NewRepository(mt.Client, rdb)
+		ordersRepo := NewRepository(mt.Client)
 		firstResponse := mtest.CreateCursorResponse(1, "orders.orders", mtest.FirstBatch, bson.D{
 			{Key: "id", Value: 1},
 			{Key: "externalReferenceID", Value: order.ExternalReferenceID},
@@ -257,17 +393,10 @@ func TestGetOrderByFiltersSuccess(t *testing.T) {
 }
 
 func TestGetOrderByFilterErrorFindOne(t *testing.T) {
-	rdb := CreateCacheForTesting(t)
-
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 	mt.Run("error find one", func(mt *mtest.T) {
-		ordersRepo := NewRepository(mt.Client, rdb)
-		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
-			Code:    66,
-			Message: "some error",
-			Name:    "SomeError",
-			Labels:  []string{},
-		}))
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(commandError)
 		model, err := ordersRepo.GetOrderByFilters(filters)
 		assert.Nil(t, model)
 		assert.NotNil(t, err)
@@ -275,11 +404,9 @@ func TestGetOrderByFilterErrorFindOne(t *testing.T) {
 }
 
 func TestGetOrderByFilterErrorCursorAll(t *testing.T) {
-	rdb := CreateCacheForTesting(t)
-
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 	mt.Run("error cursor all", func(mt *mtest.T) {
-		ordersRepo := NewRepository(mt.Client, rdb)
+		ordersRepo := NewRepository(mt.Client)
 		firstResponse := mtest.CreateCursorResponse(1, "orders.orders", mtest.FirstBatch, bson.D{
 			{Key: "id", Value: 1},
 			{Key: "externalReferenceID", Value: order.ExternalReferenceID},
@@ -303,209 +430,183 @@ func TestGetOrderByFilterErrorCursorAll(t *testing.T) {
 	})
 }
 
-func TestValidateStateTransition(t *testing.T) {
-
-	status, err := validateStateTransition("Created", "PaymentReceived")
-	assert.NoError(t, err)
-	assert.Equal(t, "PaymentReceived", status)
-
-	status, err = validateStateTransition("Created", "Canceled")
-	assert.NoError(t, err)
-	assert.Equal(t, "Canceled", status)
-
-	status, err = validateStateTransition("PaymentReceived", "Invoiced")
-	assert.NoError(t, err)
-	assert.Equal(t, "Invoiced", status)
-
-	status, err = validateStateTransition("Invoiced", "Returned")
-	assert.NoError(t, err)
-	assert.Equal(t, "Returned", status)
-
-	status, err = validateStateTransition("Invalid", "Invalid")
-	assert.Error(t, err)
-}
-
-func TestCheckUniqueEventID(t *testing.T) {
-	events := []models.Event{
-		{Id: "1", Date: "2022-01-01", Type: "Created"},
-	}
-	newEvent := models.Event{Id: "2", Date: "2022-01-02", Type: "PaymentReceived"}
-	unique, err := checkUniqueEventID(events, newEvent)
-	assert.True(t, unique)
-	assert.NoError(t, err)
-
-	duplicateEvent := models.Event{Id: "1", Date: "2022-01-01", Type: "Created"}
-	unique, err = checkUniqueEventID(events, duplicateEvent)
-	assert.False(t, unique)
-	assert.NoError(t, err)
-
-	otherEventWithSameID := models.Event{Id: "1", Date: "2022-01-02", Type: "PaymentReceived"}
-	unique, err = checkUniqueEventID(events, otherEventWithSameID)
-	assert.False(t, unique)
-	assert.Error(t, err)
-}
-
-func TestValidateExternalReferenceIdError(t *testing.T) {
-	ok, err := validateExternalReferenceId("abc-123", "Unknown")
-	assert.False(t, ok)
-	assert.Error(t, err)
-}
-
-func TestTranslate(t *testing.T) {
-	trans := translate("CallCenter", "PaymentReceived")
-	assert.Equal(t, []string{"Centro de Llamadas", "Pago Recibido"}, trans)
-
-	trans = translate("Store", "Canceled")
-	assert.Equal(t, []string{"Tienda", "Cancelado"}, trans)
+func TestStreamOrdersByFilters(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("streams every batch", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(1, "orders.orders", mtest.FirstBatch, bson.D{{Key: "id", Value: 1}}, bson.D{{Key: "id", Value: 2}}),
+			mtest.CreateCursorResponse(0, "orders.orders", mtest.NextBatch, bson.D{{Key: "id", Value: 3}}),
+		)
 
-	trans = translate("Affiliate", "Invoiced")
-	assert.Equal(t, []string{"Afiliado", "Facturado"}, trans)
+		ids := []int64{}
+		err := ordersRepo.StreamOrdersByFilters(filters, func(order models.Order) error {
+			ids = append(ids, order.OrderID)
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{1, 2, 3}, ids)
+	})
 
-	trans = translate("Affiliate", "Returned")
-	assert.Equal(t, []string{"Afiliado", "Devuelto"}, trans)
+	mt.Run("stops on error", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "orders.orders", mtest.FirstBatch, bson.D{{Key: "id", Value: 1}}, bson.D{{Key: "id", Value: 2}}),
+		)
 
-	trans = translate("Invalid", "Invalid")
-	assert.Equal(t, []string{"Invalid", "Invalid"}, trans)
+		errStop := errors.New("stop")
+		calls := 0
+		err := ordersRepo.StreamOrdersByFilters(filters, func(order models.Order) error {
+			calls++
+			return errStop
+		})
+		assert.Equal(t, errStop, err)
+		assert.Equal(t, 1, calls)
+	})
 }
 
-func TestUpdateEventOrderSuccess(t *testing.T) {
-	rdb := CreateCacheForTesting(t)
-
+func TestGetBuyerOrdersSuccess(t *testing.T) {
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 	mt.Run("success", func(mt *mtest.T) {
-		ordersRepo := NewRepository(mt.Client, rdb)
-		firstResponse := mtest.CreateCursorResponse(1, "orders.orders", mtest.FirstBatch, bson.D{
-			{Key: "id", Value: 1},
-			{Key: "externalReferenceID", Value: order.ExternalReferenceID},
-			{Key: "channel", Value: order.Channel},
-			{Key: "purchaseDate", Value: order.PurchaseDate},
-			{Key: "totalValue", Value: order.TotalValue},
-			{Key: "buyer", Value: order.Buyer},
-			{Key: "products", Value: order.Products},
-			{Key: "status", Value: "Created"},
-			{Key: "events", Value: order.Events},
-		})
-
-		mt.AddMockResponses(firstResponse)
-		mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{
-			Code:    11000,
-			Message: "duplicate key error",
-			Index:   0,
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "orders.orders", mtest.FirstBatch, bson.D{
+			{Key: "orders", Value: bson.A{
+				bson.D{{Key: "id", Value: 2}, {Key: "channel", Value: "Store"}, {Key: "purchaseDate", Value: "2024-06-01T10:00:00Z"}},
+				bson.D{{Key: "id", Value: 1}, {Key: "channel", Value: "Ecommerce"}, {Key: "purchaseDate", Value: "2024-05-01T10:00:00Z"}},
+			}},
+			{Key: "totals", Value: bson.A{bson.D{
+				{Key: "orderCount", Value: 3},
+				{Key: "totalSpent", Value: 1500.0},
+				{Key: "firstPurchaseDate", Value: "2024-04-01T10:00:00Z"},
+				{Key: "lastPurchaseDate", Value: "2024-06-01T10:00:00Z"},
+			}}},
+			{Key: "statuses", Value: bson.A{
+				bson.D{{Key: "_id", Value: "Created"}, {Key: "count", Value: 2}},
+				bson.D{{Key: "_id", Value: "Canceled"}, {Key: "count", Value: 1}},
+			}},
+			{Key: "channels", Value: bson.A{bson.D{{Key: "_id", Value: "Ecommerce"}, {Key: "count", Value: 2}}}},
 		}))
 
-		model, err := ordersRepo.UpdateEventOrder(1, event)
-
-		assert.NotNil(t, err)
-		assert.Nil(t, model)
+		response, err := ordersRepo.GetBuyerOrders("87654321", models.BuyerFilters{Page: 1, PageSize: 2})
+		assert.Nil(t, err)
+		assert.Equal(t, models.BuyerSummary{
+			TotalSpent:        1500,
+			OrderCount:        3,
+			OrdersByStatus:    map[string]int64{"Created": 2, "Canceled": 1},
+			FirstPurchaseDate: "2024-04-01T10:00:00Z",
+			LastPurchaseDate:  "2024-06-01T10:00:00Z",
+			FavouriteChannel:  "Ecommerce",
+		}, response.Summary)
+		assert.Len(t, response.Orders, 2)
+		assert.Equal(t, int64(2), response.Orders[0].OrderID)
 	})
 }
 
-func TestUpdateEventOrderFailFindOne(t *testing.T) {
-	rdb := CreateCacheForTesting(t)
-
+func TestGetBuyerOrdersNotFound(t *testing.T) {
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
-	mt.Run("fails find one", func(mt *mtest.T) {
-		ordersRepo := NewRepository(mt.Client, rdb)
-		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
-			Code:    66,
-			Message: "some error",
-			Name:    "SomeError",
-			Labels:  []string{},
+	mt.Run("not found", func(mt *mtest.T) {
+		ordersRepo := NewRepository(mt.Client)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "orders.orders", mtest.FirstBatch, bson.D{
+			{Key: "orders", Value: bson.A{}},
+			{Key: "totals", Value: bson.A{}},
+			{Key: "statuses", Value: bson.A{}},
+			{Key: "channels", Value: bson.A{}},
 		}))
 
-		model, err := ordersRepo.UpdateEventOrder(1, event)
-		assert.Nil(t, model)
-		assert.NotNil(t, err)
+		response, err := ordersRepo.GetBuyerOrders("00000000", models.BuyerFilters{Page: 1, PageSize: 20})
+		assert.Nil(t, response)
+		assert.Equal(t, mongo.ErrNoDocuments, err)
 	})
 }
 
-func TestUpdateEventOrderFailsUniqueEventID(t *testing.T) {
-	rdb := CreateCacheForTesting(t)
-
-	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
-	mt.Run("fails unique event id", func(mt *mtest.T) {
-		ordersRepo := NewRepository(mt.Client, rdb)
-
-		firstResponse := mtest.CreateCursorResponse(1, "orders.orders", mtest.FirstBatch, bson.D{
-			{Key: "id", Value: 1},
-			{Key: "externalReferenceID", Value: order.ExternalReferenceID},
-			{Key: "channel", Value: order.Channel},
-			{Key: "purchaseDate", Value: order.PurchaseDate},
-			{Key: "totalValue", Value: order.TotalValue},
-			{Key: "buyer", Value: order.Buyer},
-			{Key: "products", Value: order.Products},
-			{Key: "status", Value: "Created"},
-			{Key: "events", Value: []models.Event{event}},
-		})
-
-		localEvent := event
-		localEvent.Type = "Invoiced"
+func TestBuyerPipeline(t *testing.T) {
+	pipeline := buyerPipeline("87654321", models.BuyerFilters{Channel: "Store", Page: 3, PageSize: 10})
 
-		mt.AddMockResponses(firstResponse)
-
-		model, err := ordersRepo.UpdateEventOrder(1, localEvent)
-		assert.Nil(t, model)
-		assert.NotNil(t, err)
-	})
+	assert.Equal(t, bson.M{"buyer.documentNumber": "87654321", "channel": models.ChannelStore}, pipeline[0][0].Value)
+	orders := pipeline[1][0].Value.(bson.M)["orders"].(bson.A)
+	assert.Equal(t, bson.M{"$skip": int64(20)}, orders[1])
+	assert.Equal(t, bson.M{"$limit": int64(10)}, orders[2])
 }
 
-func TestUpdateEventOrderFailsRepeatEvent(t *testing.T) {
-	rdb := CreateCacheForTesting(t)
+// searchFilters are the combinations of filters of /orders/search, every one
+// of them must be served by an index.
+var searchFilters = map[string]models.Filters{
+	"order id":        {OrderId: 1},
+	"document number": {DocumentNumber: "87654321"},
+	"status":          {Status: "Created"},
+	"channel":         {Channel: "Store"},
+	"purchase date":   {CreatedOnFrom: "2024-05-01T00:00:00Z", CreatedOnTo: "2024-05-31T23:59:59Z"},
+	"status and date": {Status: "Created", CreatedOnFrom: "2024-05-01T00:00:00Z", CreatedOnTo: "2024-05-31T23:59:59Z"},
+	"channel scoped":  {Channel: "Store", DocumentNumber: "87654321", Status: "Invoiced"},
+	"every filter":    {OrderId: 1, DocumentNumber: "87654321", Status: "Created", Channel: "Store", CreatedOnFrom: "2024-05-01T00:00:00Z", CreatedOnTo: "2024-05-31T23:59:59Z"},
+}
 
-	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
-	mt.Run("fails unique event id", func(mt *mtest.T) {
-		ordersRepo := NewRepository(mt.Client, rdb)
+func ordersIndexes() []database.Index {
+	for _, collection := range database.IndexRegistry {
+		if collection.Collection == "orders" {
+			return collection.Indexes
+		}
+	}
+	return nil
+}
 
-		firstResponse := mtest.CreateCursorResponse(1, "orders.orders", mtest.FirstBatch, bson.D{
-			{Key: "id", Value: 1},
-			{Key: "externalReferenceID", Value: order.ExternalReferenceID},
-			{Key: "channel", Value: order.Channel},
-			{Key: "purchaseDate", Value: order.PurchaseDate},
-			{Key: "totalValue", Value: order.TotalValue},
-			{Key: "buyer", Value: order.Buyer},
-			{Key: "products", Value: order.Products},
-			{Key: "status", Value: "Created"},
-			{Key: "events", Value: []models.Event{event}},
+func TestApplyFiltersHaveIndexPrefix(t *testing.T) {
+	for name, filters := range searchFilters {
+		t.Run(name, func(t *testing.T) {
+			fields := map[string]bool{}
+			for _, condition := range ApplyFilters(filters) {
+				for field := range condition {
+					fields[field] = true
+				}
+			}
+
+			prefixed := false
+			for _, index := range ordersIndexes() {
+				prefixed = prefixed || fields[index.Keys[0].Key]
+			}
+			assert.True(t, prefixed, "no index starts with a field of %v", fields)
 		})
-
-		mt.AddMockResponses(firstResponse)
-
-		model, err := ordersRepo.UpdateEventOrder(1, event)
-		assert.NotNil(t, model)
-		assert.Nil(t, err)
-	})
+	}
 }
 
-func TestUpdateEventOrderFailsEventTransition(t *testing.T) {
-	rdb := CreateCacheForTesting(t)
-
-	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
-	mt.Run("fails unique event id", func(mt *mtest.T) {
-		ordersRepo := NewRepository(mt.Client, rdb)
+// TestApplyFiltersUseIndexes explains the search queries against the Mongo of
+// MONGODB_TEST_URI, with the indexes of the registry.
+func TestApplyFiltersUseIndexes(t *testing.T) {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI is not set")
+	}
 
-		firstResponse := mtest.CreateCursorResponse(1, "orders.orders", mtest.FirstBatch, bson.D{
-			{Key: "id", Value: 1},
-			{Key: "externalReferenceID", Value: order.ExternalReferenceID},
-			{Key: "channel", Value: order.Channel},
-			{Key: "purchaseDate", Value: order.PurchaseDate},
-			{Key: "totalValue", Value: order.TotalValue},
-			{Key: "buyer", Value: order.Buyer},
-			{Key: "products", Value: order.Products},
-			{Key: "status", Value: "Created"},
-			{Key: "events", Value: []models.Event{event}},
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI(uri))
+	assert.Nil(t, err)
+	defer client.Disconnect(context.Background())
+
+	db := client.Database("orders_explain_test")
+	defer db.Drop(context.Background())
+
+	registry := []database.CollectionIndexes{{Database: db.Name(), Collection: "orders", Indexes: ordersIndexes()}}
+	_, err = database.EnsureIndexes(client, registry)
+	assert.Nil(t, err)
+
+	localOrder := order
+	localOrder.OrderID = 1
+	_, err = db.Collection("orders").InsertOne(context.Background(), localOrder)
+	assert.Nil(t, err)
+
+	for name, filters := range searchFilters {
+		t.Run(name, func(t *testing.T) {
+			command := bson.D{
+				{Key: "explain", Value: bson.D{
+					{Key: "find", Value: "orders"},
+					{Key: "filter", Value: bson.M{"$and": ApplyFilters(filters)}},
+				}},
+				{Key: "verbosity", Value: "queryPlanner"},
+			}
+
+			var explain bson.M
+			err := db.RunCommand(context.Background(), command).Decode(&explain)
+			assert.Nil(t, err)
+			assert.True(t, database.ExplainUsesIndex(explain), "%v", explain["queryPlanner"])
 		})
-
-		localEvent := models.Event{
-			Id:   "event-002",
-			Type: "Invoiced",
-			Date: "2025-05-01T15:00:00Z",
-			User: "admin002",
-		}
-
-		mt.AddMockResponses(firstResponse)
-
-		model, err := ordersRepo.UpdateEventOrder(1, localEvent)
-		assert.Nil(t, model)
-		assert.NotNil(t, err)
-	})
+	}
 }
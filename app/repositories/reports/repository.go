@@ -0,0 +1,227 @@
+package reports
+
+import (
+	"challenge_pyegros/app/database"
+	"challenge_pyegros/app/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrInvalidReasonType = errors.New("The type must be Canceled or Returned")
+	ErrInvalidGroupBy    = errors.New("The groupBy must be channel, status, day, week, month or sku")
+)
+
+const DefaultSalesCacheTTL = 5 * time.Minute
+
+type Repository struct {
+	db            *mongo.Client
+	redis         *redis.Client
+	salesCacheTTL time.Duration
+}
+
+type Option func(*Repository)
+
+// WithSalesCacheTTL sets how long a sales report is kept in the cache.
+func WithSalesCacheTTL(ttl time.Duration) Option {
+	return func(r *Repository) {
+		r.salesCacheTTL = ttl
+	}
+}
+
+func NewRepository(client *mongo.Client, redis *redis.Client, options ...Option) *Repository {
+	repo := &Repository{
+		db:            client,
+		redis:         redis,
+		salesCacheTTL: DefaultSalesCacheTTL,
+	}
+	for _, option := range options {
+		option(repo)
+	}
+	return repo
+}
+
+// GetReasonsReport counts the Canceled and Returned events per reason code.
+func (r *Repository) GetReasonsReport(filters models.ReportFilters) ([]models.ReasonReport, error) {
+	collection := r.db.Database("orders").Collection("orders")
+
+	types := []models.EventType{models.EventCanceled, models.EventReturned}
+	if len(filters.Type) > 0 {
+		if filters.Type != models.EventCanceled && filters.Type != models.EventReturned {
+			return nil, ErrInvalidReasonType
+		}
+		types = []models.EventType{filters.Type}
+	}
+
+	cursor, err := collection.Aggregate(context.TODO(), reasonsPipeline(types, filters))
+	if err != nil {
+		return nil, err
+	}
+
+	var report = []models.ReasonReport{}
+	if err = cursor.All(context.TODO(), &report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func reasonsPipeline(types []models.EventType, filters models.ReportFilters) mongo.Pipeline {
+	eventsMatch := bson.M{
+		"events.type":        bson.M{"$in": types},
+		"events.reason.code": bson.M{"$exists": true},
+	}
+
+	date := bson.M{}
+	if len(filters.From) > 0 {
+		date["$gte"] = filters.From
+	}
+	if len(filters.To) > 0 {
+		date["$lte"] = filters.To
+	}
+	if len(date) > 0 {
+		eventsMatch["events.date"] = date
+	}
+
+	return mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"events.type": bson.M{"$in": types}}}},
+		{{Key: "$unwind", Value: "$events"}},
+		{{Key: "$match", Value: eventsMatch}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"type": "$events.type", "code": "$events.reason.code"},
+			"count": bson.M{"$sum": 1},
+			"units": bson.M{"$sum": bson.M{"$sum": "$events.reason.items.quantity"}},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":   0,
+			"type":  "$_id.type",
+			"code":  "$_id.code",
+			"count": 1,
+			"units": 1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "type", Value: 1}, {Key: "count", Value: -1}, {Key: "code", Value: 1}}}},
+	}
+}
+
+// GetSalesReport sums the orders purchased between from and to per group. The
+// reports are cached by group and dates, so the same report is aggregated at
+// most once per salesCacheTTL.
+func (r *Repository) GetSalesReport(filters models.SalesFilters) (*models.ResponseSalesReport, error) {
+	if len(filters.GroupBy) == 0 {
+		filters.GroupBy = models.SalesGroupChannel
+	}
+
+	pipeline, err := salesPipeline(filters)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, err := database.GetSalesReportFromRedis(filters, r.redis)
+	if err == nil {
+		return cached, nil
+	} else if err != redis.Nil {
+		fmt.Println("Error getting value of: " + database.SalesReportKey(filters.GroupBy, filters.From, filters.To))
+	}
+
+	collection := r.db.Database("orders").Collection("orders")
+	cursor, err := collection.Aggregate(context.TODO(), pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups = []models.SalesReport{}
+	if err = cursor.All(context.TODO(), &groups); err != nil {
+		return nil, err
+	}
+
+	response := &models.ResponseSalesReport{
+		GroupBy: filters.GroupBy,
+		From:    filters.From,
+		To:      filters.To,
+		Groups:  groups,
+	}
+
+	err = database.SetSalesReportFromRedis(filters, response, r.salesCacheTTL, r.redis)
+	if err != nil {
+		fmt.Println("Error seting value of: " + database.SalesReportKey(filters.GroupBy, filters.From, filters.To))
+	}
+
+	return response, nil
+}
+
+func salesPipeline(filters models.SalesFilters) (mongo.Pipeline, error) {
+	match := bson.M{}
+	date := bson.M{}
+	if len(filters.From) > 0 {
+		date["$gte"] = filters.From
+	}
+	if len(filters.To) > 0 {
+		date["$lte"] = filters.To
+	}
+	if len(date) > 0 {
+		match["purchaseDate"] = date
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+	}
+
+	group := bson.M{
+		"orderCount": bson.M{"$sum": 1},
+		"units":      bson.M{"$sum": bson.M{"$sum": "$products.quantity"}},
+		"totalValue": bson.M{"$sum": "$totalValue"},
+	}
+	orderCount := interface{}(1)
+
+	switch filters.GroupBy {
+	case models.SalesGroupChannel, models.SalesGroupStatus:
+		group["_id"] = "$" + filters.GroupBy
+	case models.SalesGroupDay, models.SalesGroupWeek, models.SalesGroupMonth:
+		group["_id"] = periodExpression(filters.GroupBy)
+	case models.SalesGroupSku:
+		pipeline = append(pipeline, bson.D{{Key: "$unwind", Value: "$products"}})
+		group = bson.M{
+			"_id":        "$products.sku",
+			"orders":     bson.M{"$addToSet": "$id"},
+			"units":      bson.M{"$sum": "$products.quantity"},
+			"totalValue": bson.M{"$sum": bson.M{"$multiply": bson.A{"$products.price", "$products.quantity"}}},
+		}
+		orderCount = bson.M{"$size": "$orders"}
+	default:
+		return nil, ErrInvalidGroupBy
+	}
+
+	return append(pipeline,
+		bson.D{{Key: "$group", Value: group}},
+		bson.D{{Key: "$project", Value: bson.M{
+			"_id":        0,
+			"group":      "$_id",
+			"orderCount": orderCount,
+			"units":      1,
+			"totalValue": 1,
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "group", Value: 1}}}},
+	), nil
+}
+
+// periodExpression truncates the purchase date to the first day of its day,
+// week or month, in UTC.
+func periodExpression(unit string) bson.M {
+	trunc := bson.M{
+		"date": bson.M{"$dateFromString": bson.M{"dateString": "$purchaseDate"}},
+		"unit": unit,
+	}
+	if unit == models.SalesGroupWeek {
+		trunc["startOfWeek"] = "monday"
+	}
+
+	return bson.M{"$dateToString": bson.M{
+		"format": "%Y-%m-%d",
+		"date":   bson.M{"$dateTrunc": trunc},
+	}}
+}
@@ -0,0 +1,186 @@
+package reports
+
+import (
+	"challenge_pyegros/app/database"
+	"challenge_pyegros/app/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestGetReasonsReportSuccess(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("success", func(mt *mtest.T) {
+		reportsRepo := NewRepository(mt.Client, nil)
+		firstResponse := mtest.CreateCursorResponse(1, "orders.orders", mtest.FirstBatch,
+			bson.D{
+				{Key: "type", Value: "Canceled"},
+				{Key: "code", Value: "OUT_OF_STOCK"},
+				{Key: "count", Value: 3},
+				{Key: "units", Value: 0},
+			},
+			bson.D{
+				{Key: "type", Value: "Returned"},
+				{Key: "code", Value: "DEFECTIVE"},
+				{Key: "count", Value: 2},
+				{Key: "units", Value: 5},
+			},
+		)
+		endOfCursor := mtest.CreateCursorResponse(0, "orders.orders", mtest.NextBatch)
+		mt.AddMockResponses(firstResponse, endOfCursor)
+
+		report, err := reportsRepo.GetReasonsReport(models.ReportFilters{From: "2024-05-01T00:00:00Z"})
+		assert.Nil(t, err)
+		assert.Equal(t, []models.ReasonReport{
+			{Type: "Canceled", Code: "OUT_OF_STOCK", Count: 3, Units: 0},
+			{Type: "Returned", Code: "DEFECTIVE", Count: 2, Units: 5},
+		}, report)
+	})
+}
+
+func TestGetReasonsReportInvalidType(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("invalid type", func(mt *mtest.T) {
+		reportsRepo := NewRepository(mt.Client, nil)
+
+		report, err := reportsRepo.GetReasonsReport(models.ReportFilters{Type: "Invoiced"})
+		assert.Nil(t, report)
+		assert.Equal(t, ErrInvalidReasonType, err)
+	})
+}
+
+func TestGetReasonsReportFailsAggregate(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("fails aggregate", func(mt *mtest.T) {
+		reportsRepo := NewRepository(mt.Client, nil)
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code:    66,
+			Message: "some error",
+			Name:    "SomeError",
+			Labels:  []string{},
+		}))
+
+		report, err := reportsRepo.GetReasonsReport(models.ReportFilters{Type: "Returned"})
+		assert.Nil(t, report)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestReasonsPipeline(t *testing.T) {
+	pipeline := reasonsPipeline([]models.EventType{models.EventReturned}, models.ReportFilters{From: "2024-05-01T00:00:00Z", To: "2024-05-31T23:59:59Z"})
+
+	assert.Equal(t, bson.M{
+		"events.type":        bson.M{"$in": []models.EventType{models.EventReturned}},
+		"events.reason.code": bson.M{"$exists": true},
+		"events.date":        bson.M{"$gte": "2024-05-01T00:00:00Z", "$lte": "2024-05-31T23:59:59Z"},
+	}, pipeline[2][0].Value)
+}
+
+func createCacheForTesting(t *testing.T) *redis.Client {
+	s := miniredis.RunT(t)
+
+	return redis.NewClient(&redis.Options{
+		Addr: s.Addr(),
+	})
+}
+
+func TestGetSalesReportSuccess(t *testing.T) {
+	rdb := createCacheForTesting(t)
+
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("success", func(mt *mtest.T) {
+		reportsRepo := NewRepository(mt.Client, rdb, WithSalesCacheTTL(time.Minute))
+		firstResponse := mtest.CreateCursorResponse(0, "orders.orders", mtest.FirstBatch,
+			bson.D{
+				{Key: "group", Value: "Ecommerce"},
+				{Key: "orderCount", Value: 3},
+				{Key: "units", Value: 7},
+				{Key: "totalValue", Value: 4500.5},
+			},
+			bson.D{
+				{Key: "group", Value: "Store"},
+				{Key: "orderCount", Value: 1},
+				{Key: "units", Value: 1},
+				{Key: "totalValue", Value: 100.0},
+			},
+		)
+		// Only one response is mocked, the second report must come from Redis.
+		mt.AddMockResponses(firstResponse)
+
+		filters := models.SalesFilters{From: "2024-05-01T00:00:00Z", To: "2024-05-07T23:59:59Z"}
+		expected := &models.ResponseSalesReport{
+			GroupBy: models.SalesGroupChannel,
+			From:    filters.From,
+			To:      filters.To,
+			Groups: []models.SalesReport{
+				{Group: "Ecommerce", OrderCount: 3, Units: 7, TotalValue: 4500.5},
+				{Group: "Store", OrderCount: 1, Units: 1, TotalValue: 100},
+			},
+		}
+
+		report, err := reportsRepo.GetSalesReport(filters)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, report)
+
+		report, err = reportsRepo.GetSalesReport(models.SalesFilters{GroupBy: models.SalesGroupChannel, From: filters.From, To: filters.To})
+		assert.Nil(t, err)
+		assert.Equal(t, expected, report)
+
+		key := database.SalesReportKey(models.SalesGroupChannel, filters.From, filters.To)
+		assert.Equal(t, time.Minute, rdb.TTL(context.Background(), key).Val())
+	})
+}
+
+func TestGetSalesReportInvalidGroupBy(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("invalid group by", func(mt *mtest.T) {
+		reportsRepo := NewRepository(mt.Client, createCacheForTesting(t))
+
+		report, err := reportsRepo.GetSalesReport(models.SalesFilters{GroupBy: "year"})
+		assert.Nil(t, report)
+		assert.Equal(t, ErrInvalidGroupBy, err)
+	})
+}
+
+func TestGetSalesReportFailsAggregate(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("fails aggregate", func(mt *mtest.T) {
+		reportsRepo := NewRepository(mt.Client, createCacheForTesting(t))
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{
+			Code:    66,
+			Message: "some error",
+			Name:    "SomeError",
+			Labels:  []string{},
+		}))
+
+		report, err := reportsRepo.GetSalesReport(models.SalesFilters{GroupBy: models.SalesGroupStatus})
+		assert.Nil(t, report)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestSalesPipeline(t *testing.T) {
+	pipeline, err := salesPipeline(models.SalesFilters{GroupBy: models.SalesGroupWeek, From: "2024-05-01T00:00:00Z"})
+	assert.Nil(t, err)
+	assert.Equal(t, bson.M{"purchaseDate": bson.M{"$gte": "2024-05-01T00:00:00Z"}}, pipeline[0][0].Value)
+	assert.Equal(t, bson.M{"$dateToString": bson.M{
+		"format": "%Y-%m-%d",
+		"date": bson.M{"$dateTrunc": bson.M{
+			"date":        bson.M{"$dateFromString": bson.M{"dateString": "$purchaseDate"}},
+			"unit":        "week",
+			"startOfWeek": "monday",
+		}},
+	}}, pipeline[1][0].Value.(bson.M)["_id"])
+
+	pipeline, err = salesPipeline(models.SalesFilters{GroupBy: models.SalesGroupSku})
+	assert.Nil(t, err)
+	assert.Equal(t, "$unwind", pipeline[1][0].Key)
+	assert.Equal(t, "$products.sku", pipeline[2][0].Value.(bson.M)["_id"])
+	assert.Equal(t, bson.M{"$size": "$orders"}, pipeline[3][0].Value.(bson.M)["orderCount"])
+}
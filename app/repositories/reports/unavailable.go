@@ -0,0 +1,24 @@
+package reports
+
+import (
+	"challenge_pyegros/app/models"
+	"errors"
+)
+
+var ErrReportsUnavailable = errors.New("The reports are not available with the orders kept in memory")
+
+// UnavailableRepository replaces Repository when the orders are kept in
+// memory, the reports are aggregations of the orders collection.
+type UnavailableRepository struct{}
+
+func NewUnavailableRepository() *UnavailableRepository {
+	return &UnavailableRepository{}
+}
+
+func (r *UnavailableRepository) GetReasonsReport(filters models.ReportFilters) ([]models.ReasonReport, error) {
+	return nil, ErrReportsUnavailable
+}
+
+func (r *UnavailableRepository) GetSalesReport(filters models.SalesFilters) (*models.ResponseSalesReport, error) {
+	return nil, ErrReportsUnavailable
+}
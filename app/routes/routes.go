@@ -1,21 +1,81 @@
 package routes
 
 import (
+	"challenge_pyegros/app/handlers/apikeys"
+	"challenge_pyegros/app/handlers/audit"
+	"challenge_pyegros/app/handlers/cache"
+	"challenge_pyegros/app/handlers/openapi"
 	"challenge_pyegros/app/handlers/orders"
+	"challenge_pyegros/app/handlers/reports"
+	"challenge_pyegros/app/middlewares"
+	"challenge_pyegros/app/models"
+	"expvar"
+	"net/http"
 
 	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func SetUpRoutes(client *mongo.Client, orderHandler *orders.Handler) *chi.Mux {
+func SetUpRoutes(client *mongo.Client, authenticator *middlewares.JWTAuthenticator, apiKeyAuthenticator *middlewares.APIKeyAuthenticator, rateLimiter *middlewares.RateLimiter, proxies *middlewares.TrustedProxies, orderHandler *orders.Handler, auditHandler *audit.Handler, apiKeysHandler *apikeys.Handler, cacheHandler *cache.Handler, reportsHandler *reports.Handler, openAPIHandler *openapi.Handler) *chi.Mux {
 	r := chi.NewRouter()
+	r.Use(middleware.RequestID)
+	r.Use(proxies.RealIP)
+
+	creators := middlewares.RequireRoles(models.RoleChannelClient)
+	operators := middlewares.RequireRoles(models.RoleBackoffice)
+	amenders := middlewares.RequireRoles(models.RoleChannelClient, models.RoleBackoffice)
+	readers := middlewares.RequireRoles(models.RoleChannelClient, models.RoleBackoffice, models.RoleAuditor)
+	auditors := middlewares.RequireRoles(models.RoleAuditor)
+	analysts := middlewares.RequireRoles(models.RoleBackoffice, models.RoleAuditor)
+	allChannels := middlewares.RequireAllChannels
+	admins := middlewares.RequireRoles(models.RoleAdmin)
+
+	r.Get(openapi.SpecPath, openAPIHandler.GetSpec)
+	r.Get("/docs", openAPIHandler.GetUI)
+	r.Get("/docs/*", openAPIHandler.GetUI)
 
 	r.Route("/api/v1", func(router chi.Router) {
-		router.Post("/orders", orderHandler.CreateOrder)
-		router.Post("/orders/{orderId}/events", orderHandler.UpdateEventOrder)
-		router.Get("/orders/{orderId}", orderHandler.GetOrderByID)
-		router.Get("/orders/search", orderHandler.GetOrderByFilters)
+		// The IP is limited before the credentials are checked, the routes
+		// limit every client after.
+		router.Use(rateLimiter.LimitIP())
+		router.Use(apiKeyAuthenticator.Authenticate)
+		router.Use(authenticator.Authenticate)
+
+		router.With(creators, rateLimiter.Limit("create-order")).Post("/orders", orderHandler.CreateOrder)
+		router.With(creators, rateLimiter.Limit("bulk-orders")).Post("/orders/bulk", orderHandler.CreateOrders)
+		router.With(operators, rateLimiter.Limit("add-event")).Post("/orders/{orderId}/events", orderHandler.UpdateEventOrder)
+		router.With(operators, rateLimiter.Limit("bulk-events")).Post("/events/bulk", orderHandler.UpdateEventOrders)
+		router.With(readers, rateLimiter.Limit("get-events")).Get("/orders/{orderId}/events", orderHandler.GetOrderEvents)
+		router.With(readers, rateLimiter.Limit("get-order")).Get("/orders/{orderId}", orderHandler.GetOrderByID)
+		router.With(amenders, rateLimiter.Limit("amend-order")).Patch("/orders/{orderId}", orderHandler.AmendOrder)
+		router.With(readers, rateLimiter.Limit("search-orders")).Get("/orders/search", orderHandler.GetOrderByFilters)
+		router.With(readers, rateLimiter.Limit("buyer-orders")).Get("/buyers/{documentNumber}/orders", orderHandler.GetBuyerOrders)
+		router.With(auditors, allChannels, rateLimiter.Limit("audit")).Get("/audit", auditHandler.GetAuditEntries)
+		router.With(analysts, allChannels, rateLimiter.Limit("reports")).Get("/reports/reasons", reportsHandler.GetReasonsReport)
+		router.With(analysts, allChannels, rateLimiter.Limit("reports")).Get("/reports/sales", reportsHandler.GetSalesReport)
+		router.With(admins, rateLimiter.Limit("admin")).Post("/admin/api-keys", apiKeysHandler.IssueAPIKey)
+		router.With(admins, rateLimiter.Limit("admin")).Post("/admin/api-keys/{keyId}/rotate", apiKeysHandler.RotateAPIKey)
+		router.With(admins, rateLimiter.Limit("admin")).Delete("/admin/api-keys/{keyId}", apiKeysHandler.RevokeAPIKey)
+		router.With(admins, rateLimiter.Limit("admin")).Get("/admin/metrics", getMetrics)
+		router.With(admins, rateLimiter.Limit("admin")).Get("/admin/cache/keys", cacheHandler.GetCacheKeys)
+		router.With(admins, rateLimiter.Limit("admin")).Delete("/admin/cache/keys", cacheHandler.PurgeCacheKeys)
 	})
 
 	return r
 }
+
+// getMetrics godoc
+// @Summary Metrics of the service
+// @Description Publishes the expvar variables of the service, with the memory statistics of the runtime and the counters of the order cache
+// @Tags admin
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} models.ResponseError
+// @Failure 403 {object} models.ResponseError
+// @Failure 429 {object} models.ResponseError
+// @Security BearerAuth
+// @Router /admin/metrics [get]
+func getMetrics(w http.ResponseWriter, r *http.Request) {
+	expvar.Handler().ServeHTTP(w, r)
+}
@@ -1,14 +1,16 @@
 package main
 
 import (
+	"challenge_pyegros/app/config"
 	"challenge_pyegros/app/database"
-	orderHandler "challenge_pyegros/app/handlers/orders"
-	orderRepository "challenge_pyegros/app/repositories/orders"
-	"challenge_pyegros/app/routes"
-	orderUseCase "challenge_pyegros/app/usecases/orders"
+	"challenge_pyegros/app/migrations"
 	"context"
+	"flag"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
 )
 
 // @title           Orders API
@@ -21,25 +23,121 @@ import (
 
 // @host      localhost:8080
 // @BasePath  /api/v1
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT signed with HS256 or RS256, as "Bearer {token}"
+
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key bound to a channel, issued in /admin/api-keys
 func main() {
-	client, err := database.ConnectMongoDB()
+	checkIndexes := flag.Bool("check-indexes", false, "check the Mongo indexes against the registry and exit with status 1 if they differ")
+	flag.Parse()
+
+	if *checkIndexes {
+		os.Exit(runCheckIndexes())
+	}
+	if flag.Arg(0) == "migrate" {
+		os.Exit(runMigrate(flag.Args()[1:]))
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s, err := newServer(cfg)
 	if err != nil {
-		panic("Failed to connect to MongoDB:")
+		log.Fatal(err)
 	}
 	defer func() {
-		if err = client.Disconnect(context.TODO()); err != nil {
+		if err = s.Close(); err != nil {
 			log.Fatal(err)
 		}
 	}()
 
-	rdb := database.ConnectRedis()
-
-	repoOrders := orderRepository.NewRepository(client, rdb)
-	useCaseOrders := orderUseCase.NewUseCase(repoOrders, rdb)
-	orderHandler := orderHandler.NewHandler(useCaseOrders)
+	listener, err := net.Listen("tcp", cfg.GRPCAddr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go func() {
+		if err := s.grpc.Serve(listener); err != nil {
+			log.Fatal(err)
+		}
+	}()
 
-	r := routes.SetUpRoutes(client, orderHandler)
-	if err := http.ListenAndServe(":8080", r); err != nil {
+	if err := http.ListenAndServe(":8080", s.http); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// runCheckIndexes prints the differences between the indexes of the database
+// and the registry, for CI. It returns the exit status.
+func runCheckIndexes() int {
+	client, err := database.ConnectMongoDB()
+	if err != nil {
+		fmt.Println("Failed to connect to MongoDB: " + err.Error())
+		return 1
+	}
+	defer client.Disconnect(context.TODO())
+
+	problems, err := database.CheckIndexes(client, database.IndexRegistry)
+	if err != nil {
+		fmt.Println("Error checking the indexes: " + err.Error())
+		return 1
+	}
+	for _, problem := range problems {
+		fmt.Println(problem.String())
+	}
+	if len(problems) > 0 {
+		return 1
+	}
+
+	fmt.Println("The indexes match the registry")
+	return 0
+}
+
+// runMigrate runs the pending migrations, or lists them with --dry-run. It
+// returns the exit status.
+func runMigrate(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "list the pending migrations without running them")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	client, err := database.ConnectMongoDB()
+	if err != nil {
+		fmt.Println("Failed to connect to MongoDB: " + err.Error())
+		return 1
+	}
+	defer client.Disconnect(context.TODO())
+
+	migrator, err := migrations.NewMigrator(client, migrations.Registry)
+	if err != nil {
+		fmt.Println("Invalid migrations: " + err.Error())
+		return 1
+	}
+
+	var results []migrations.Result
+	if *dryRun {
+		results, err = migrator.DryRun()
+	} else {
+		results, err = migrator.Up()
+	}
+	for _, result := range results {
+		fmt.Println(result.String())
+	}
+	if err != nil {
+		fmt.Println("Error running the migrations: " + err.Error())
+		return 1
+	}
+
+	if len(results) == 0 {
+		fmt.Println("The database is up to date")
+	}
+	return 0
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"challenge_pyegros/app/config"
+	"challenge_pyegros/app/database"
+	apiKeysHandler "challenge_pyegros/app/handlers/apikeys"
+	auditHandler "challenge_pyegros/app/handlers/audit"
+	cacheHandler "challenge_pyegros/app/handlers/cache"
+	"challenge_pyegros/app/handlers/openapi"
+	orderHandler "challenge_pyegros/app/handlers/orders"
+	reportsHandler "challenge_pyegros/app/handlers/reports"
+	"challenge_pyegros/app/handlers/rpc"
+	"challenge_pyegros/app/i18n"
+	"challenge_pyegros/app/middlewares"
+	apiKeysPorts "challenge_pyegros/app/ports/apikeys"
+	auditPorts "challenge_pyegros/app/ports/audit"
+	ports "challenge_pyegros/app/ports/orders"
+	reportsPorts "challenge_pyegros/app/ports/reports"
+	ordersv1 "challenge_pyegros/app/proto/orders/v1"
+	apiKeysRepository "challenge_pyegros/app/repositories/apikeys"
+	auditRepository "challenge_pyegros/app/repositories/audit"
+	cacheRepository "challenge_pyegros/app/repositories/cache"
+	orderRepository "challenge_pyegros/app/repositories/orders"
+	reportsRepository "challenge_pyegros/app/repositories/reports"
+	"challenge_pyegros/app/routes"
+	apiKeysUseCase "challenge_pyegros/app/usecases/apikeys"
+	auditUseCase "challenge_pyegros/app/usecases/audit"
+	cacheUseCase "challenge_pyegros/app/usecases/cache"
+	orderUseCase "challenge_pyegros/app/usecases/orders"
+	reportsUseCase "challenge_pyegros/app/usecases/reports"
+	"context"
+	"log"
+	"net/http"
+	"os"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc"
+)
+
+// server is the REST and gRPC APIs wired to their backends. Close disconnects
+// them.
+type server struct {
+	http  http.Handler
+	grpc  *grpc.Server
+	mongo *mongo.Client
+}
+
+// newServer connects to the backends of the configuration. With the orders in
+// memory Mongo is not used at all: the API keys and the audit trail are kept
+// in memory too and the reports are unavailable. Redis is optional, without
+// it the caches and the idempotency of the orders are skipped.
+func newServer(cfg *config.Config) (*server, error) {
+	authenticator, err := middlewares.NewJWTAuthenticator(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	locales := i18n.Locales()
+	if cfg.LocalesDir != "" {
+		locales = os.DirFS(cfg.LocalesDir)
+	}
+	catalogue, err := i18n.NewCatalogue(locales, cfg.DefaultLocale)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := openapi.LoadSpec()
+	if err != nil {
+		return nil, err
+	}
+	openAPIHandler, err := openapi.NewHandler(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var client *mongo.Client
+	if cfg.OrdersStore == config.OrdersStoreMongo {
+		client, err = database.ConnectMongoDB()
+		if err != nil {
+			return nil, err
+		}
+
+		problems, err := database.EnsureIndexes(client, database.IndexRegistry)
+		if err != nil {
+			log.Println("Error ensuring the indexes: " + err.Error())
+		}
+		for _, problem := range problems {
+			log.Println("Warning, index " + problem.String())
+		}
+	}
+
+	rdb := database.ConnectRedis(cfg.RedisAddr)
+	rateLimiter := middlewares.NewRateLimiter(rdb, cfg)
+
+	var repoOrders ports.OrdersRepository
+	var repoAPIKeys apiKeysPorts.APIKeysRepository
+	var repoAudit auditPorts.AuditRepository
+	var repoReports reportsPorts.ReportsRepository
+	if client != nil {
+		repoOrders = orderRepository.NewRepository(client)
+		repoAPIKeys = apiKeysRepository.NewRepository(client)
+		repoAudit = auditRepository.NewRepository(client)
+		repoReports = reportsRepository.NewRepository(client, rdb,
+			reportsRepository.WithSalesCacheTTL(cfg.SalesCacheTTL),
+		)
+	} else {
+		log.Println("Warning, the orders, API keys and audit trail are kept in memory and lost on restart")
+		repoOrders = orderRepository.NewMemoryRepository()
+		repoAPIKeys = apiKeysRepository.NewMemoryRepository()
+		repoAudit = auditRepository.NewMemoryRepository()
+		repoReports = reportsRepository.NewUnavailableRepository()
+	}
+
+	useCaseAudit := auditUseCase.NewUseCase(repoAudit)
+	auditHandler := auditHandler.NewHandler(useCaseAudit)
+
+	useCaseAPIKeys := apiKeysUseCase.NewUseCase(repoAPIKeys)
+	apiKeysHandler := apiKeysHandler.NewHandler(useCaseAPIKeys, useCaseAudit)
+	apiKeyAuthenticator := middlewares.NewAPIKeyAuthenticator(useCaseAPIKeys)
+
+	useCaseCache := cacheUseCase.NewUseCase(cacheRepository.NewRepository(rdb))
+	cacheHandler := cacheHandler.NewHandler(useCaseCache, useCaseAudit)
+
+	useCaseReports := reportsUseCase.NewUseCase(repoReports)
+	reportsHandler := reportsHandler.NewHandler(useCaseReports)
+
+	useCaseOrders := orderUseCase.NewUseCase(repoOrders, rdb,
+		orderUseCase.WithOrderCacheTTL(cfg.OrderCacheTTL),
+		orderUseCase.WithReasonCatalogue(cfg.ReasonCatalogue),
+		orderUseCase.WithBulkConcurrency(cfg.BulkConcurrency),
+	)
+	orderHandler := orderHandler.NewHandler(useCaseOrders, useCaseAudit, catalogue)
+
+	grpcAuthenticator := middlewares.NewGRPCAuthenticator(authenticator, useCaseAPIKeys, rpc.Roles)
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(rateLimiter.UnaryLimitIP(), grpcAuthenticator.UnaryInterceptor(), rateLimiter.UnaryLimit(rpc.RateLimitRoutes)),
+		grpc.ChainStreamInterceptor(rateLimiter.StreamLimitIP(), grpcAuthenticator.StreamInterceptor(), rateLimiter.StreamLimit(rpc.RateLimitRoutes)),
+	)
+	ordersv1.RegisterOrdersServiceServer(grpcServer, rpc.NewServer(useCaseOrders, useCaseAudit, rpc.WithMaxWatchers(cfg.GRPCMaxWatchers)))
+
+	r := routes.SetUpRoutes(client, authenticator, apiKeyAuthenticator, rateLimiter, middlewares.NewTrustedProxies(cfg), orderHandler, auditHandler, apiKeysHandler, cacheHandler, reportsHandler, openAPIHandler)
+
+	return &server{
+		http:  r,
+		grpc:  grpcServer,
+		mongo: client,
+	}, nil
+}
+
+func (s *server) Close() error {
+	s.grpc.Stop()
+	if s.mongo == nil {
+		return nil
+	}
+	return s.mongo.Disconnect(context.TODO())
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"challenge_pyegros/app/config"
+	"challenge_pyegros/app/models"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const secret = "server-secret"
+
+// TestServerInMemoryWithoutBackends starts the API with the orders in memory,
+// without Mongo nor Redis, and runs an order through it.
+func TestServerInMemoryWithoutBackends(t *testing.T) {
+	t.Setenv("ORDERS_STORE", config.OrdersStoreMemory)
+	t.Setenv("REDIS_ADDR", "")
+	t.Setenv("JWT_HS256_SECRET", secret)
+
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	s, err := newServer(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, s.Close()) })
+
+	api := httptest.NewServer(s.http)
+	t.Cleanup(api.Close)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   "operator",
+		"roles": []string{models.RoleChannelClient, models.RoleBackoffice, models.RoleAuditor, models.RoleAdmin},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(secret))
+	require.NoError(t, err)
+
+	do := func(method string, path string, body interface{}, out interface{}, headers ...string) int {
+		var reader io.Reader
+		if body != nil {
+			content, err := json.Marshal(body)
+			require.NoError(t, err)
+			reader = bytes.NewReader(content)
+		}
+		req, err := http.NewRequest(method, api.URL+"/api/v1"+path, reader)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		if out != nil {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+		}
+		return resp.StatusCode
+	}
+
+	order := models.Order{
+		ExternalReferenceID: models.ExternalReferenceIDs[models.ChannelEcommerce],
+		Channel:             models.ChannelEcommerce,
+		PurchaseDate:        "2024-05-01T14:00:00Z",
+		TotalValue:          2000,
+		Buyer:               models.Buyer{FirstName: "Patricio", LastName: "Yegros", DocumentNumber: "87654321", Phone: "+541112345678"},
+		Products:            []models.Product{{Sku: "P001", Name: "Producto A", Price: 1000, Quantity: 2}},
+	}
+	var created models.ResponseCreate
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/orders", order, &created))
+	assert.Equal(t, int64(1), created.OrderID)
+
+	var updated models.ResponseUpdate
+	event := models.Event{Id: "event-001", Type: models.EventPaymentReceived, Date: "2024-05-01T15:00:00Z"}
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/orders/1/events", event, &updated))
+	assert.Equal(t, models.StatusPaymentReceived, updated.NewStatus)
+
+	// Without Redis the replay of the event is told apart by the events of
+	// the order, it is neither applied nor audited again.
+	updated = models.ResponseUpdate{}
+	require.Equal(t, http.StatusOK, do(http.MethodPost, "/orders/1/events", event, &updated))
+	assert.Equal(t, models.StatusCreated, updated.PreviousStatus)
+	assert.Equal(t, models.StatusPaymentReceived, updated.NewStatus)
+
+	var got models.ResponseGet
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/orders/1", nil, &got))
+	assert.Equal(t, models.StatusPaymentReceived, got.Status)
+
+	var entries []models.AuditEntry
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/audit", nil, &entries))
+	assert.Len(t, entries, 2)
+
+	var issued models.ResponseAPIKey
+	request := models.APIKeyRequest{Channel: models.ChannelEcommerce, Scopes: []string{models.RoleChannelClient}}
+	require.Equal(t, http.StatusCreated, do(http.MethodPost, "/admin/api-keys", request, &issued))
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/orders/1", nil, nil, "X-API-Key", issued.Key))
+	require.Equal(t, http.StatusOK, do(http.MethodDelete, "/admin/api-keys/"+issued.ID, nil, nil))
+
+	// The admin actions are audited with the key they act on.
+	require.Equal(t, http.StatusOK, do(http.MethodGet, "/audit?actor=operator", nil, &entries))
+	require.Len(t, entries, 4)
+	assert.Equal(t, models.AuditActionIssueAPIKey, entries[2].Action)
+	assert.Equal(t, issued.ID, entries[2].Target)
+	assert.Equal(t, models.AuditActionRevokeAPIKey, entries[3].Action)
+	assert.Equal(t, issued.ID, entries[3].Target)
+
+	// The backends that are missing answer unavailable.
+	assert.Equal(t, http.StatusServiceUnavailable, do(http.MethodGet, "/reports/sales", nil, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, do(http.MethodGet, "/admin/cache/keys?pattern=v2:order:*", nil, nil))
+}
@@ -0,0 +1,32 @@
+package apikeys
+
+import (
+	"challenge_pyegros/app/models"
+	ports "challenge_pyegros/app/ports/apikeys"
+)
+
+type UseCase struct {
+	r ports.APIKeysRepository
+}
+
+func NewUseCase(r ports.APIKeysRepository) *UseCase {
+	return &UseCase{
+		r: r,
+	}
+}
+
+func (u *UseCase) IssueAPIKey(request models.APIKeyRequest) (*models.ResponseAPIKey, error) {
+	return u.r.IssueAPIKey(request)
+}
+
+func (u *UseCase) RotateAPIKey(id string) (*models.ResponseAPIKey, error) {
+	return u.r.RotateAPIKey(id)
+}
+
+func (u *UseCase) RevokeAPIKey(id string) (*models.APIKey, error) {
+	return u.r.RevokeAPIKey(id)
+}
+
+func (u *UseCase) Authenticate(key string) (*models.Principal, error) {
+	return u.r.Authenticate(key)
+}
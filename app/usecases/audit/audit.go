@@ -0,0 +1,24 @@
+package audit
+
+import (
+	"challenge_pyegros/app/models"
+	ports "challenge_pyegros/app/ports/audit"
+)
+
+type UseCase struct {
+	r ports.AuditRepository
+}
+
+func NewUseCase(r ports.AuditRepository) *UseCase {
+	return &UseCase{
+		r: r,
+	}
+}
+
+func (u *UseCase) Record(entry models.AuditEntry) error {
+	return u.r.Record(entry)
+}
+
+func (u *UseCase) GetAuditEntries(filters models.AuditFilters) ([]models.AuditEntry, error) {
+	return u.r.GetAuditEntries(filters)
+}
@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"challenge_pyegros/app/models"
+	ports "challenge_pyegros/app/ports/cache"
+)
+
+type UseCase struct {
+	r ports.CacheRepository
+}
+
+func NewUseCase(r ports.CacheRepository) *UseCase {
+	return &UseCase{
+		r: r,
+	}
+}
+
+func (u *UseCase) GetKeys(pattern string, limit int64) (*models.ResponseCacheKeys, error) {
+	return u.r.GetKeys(pattern, limit)
+}
+
+func (u *UseCase) PurgeKeys(pattern string) (*models.ResponsePurge, error) {
+	return u.r.PurgeKeys(pattern)
+}
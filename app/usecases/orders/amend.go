@@ -0,0 +1,246 @@
+package orders
+
+import (
+	"bytes"
+	"challenge_pyegros/app/models"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrOrderNotAmendable = errors.New("Only orders in Created status can be amended")
+	ErrInvalidPatch      = errors.New("The body must be a JSON Merge Patch object")
+	ErrFieldNotAmendable = errors.New("Only the buyer phone, the products and the total value can be amended")
+	ErrInvalidProducts   = errors.New("The order requires products with a positive quantity")
+)
+
+// amendableFields are the fields of the order a merge patch can change. The
+// buyer can only change its phone.
+var amendableFields = map[string]bool{
+	"buyer":      true,
+	"products":   true,
+	"totalValue": true,
+}
+
+// AmendOrder applies a JSON Merge Patch (RFC 7396) to an order in Created
+// status and records an Amended event with the changes. The update only
+// matches the order as it was read, so an event or another amendment written
+// meanwhile makes it fail with ports.ErrOrderChanged.
+func (u *UseCase) AmendOrder(orderID int64, patch []byte, user string) (*models.ResponseAmend, error) {
+	order, err := u.r.FindOrderByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.Status != models.StatusCreated {
+		return nil, ErrOrderNotAmendable
+	}
+
+	amended, err := applyAmendment(*order, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	if !validateTotal(amended.Products, amended.TotalValue) {
+		return nil, ErrTotalMismatch
+	}
+
+	response := &models.ResponseAmend{
+		OrderID:   order.OrderID,
+		Status:    order.Status,
+		UpdatedOn: time.Now().UTC().Format(time.RFC3339),
+		Changes:   diffOrder(*order, amended),
+	}
+	if len(response.Changes) == 0 {
+		return response, nil
+	}
+
+	event := models.Event{
+		Id:      "amendment-" + strconv.Itoa(countEvents(order.Events, models.EventAmended)+1),
+		Type:    models.EventAmended,
+		Date:    response.UpdatedOn,
+		User:    user,
+		Changes: response.Changes,
+	}
+
+	amended.OrderID = order.OrderID
+	amended.Events = append(order.Events, event)
+	err = u.r.UpdateAmendedOrder(amended, event)
+	if err != nil {
+		return nil, err
+	}
+
+	u.invalidateOrderCache(orderID)
+
+	return response, nil
+}
+
+// applyAmendment checks that the patch only touches amendable fields and
+// returns the order with the patch merged.
+func applyAmendment(order models.Order, patch []byte) (models.Order, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil || fields == nil {
+		return models.Order{}, ErrInvalidPatch
+	}
+
+	for field, value := range fields {
+		if !amendableFields[field] {
+			return models.Order{}, ErrFieldNotAmendable
+		}
+		if field != "buyer" {
+			continue
+		}
+
+		var buyer map[string]json.RawMessage
+		if err := json.Unmarshal(value, &buyer); err != nil || buyer == nil {
+			return models.Order{}, ErrFieldNotAmendable
+		}
+		for buyerField := range buyer {
+			if buyerField != "phone" {
+				return models.Order{}, ErrFieldNotAmendable
+			}
+		}
+	}
+
+	document, err := json.Marshal(order)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	merged, err := mergePatch(document, patch)
+	if err != nil {
+		return models.Order{}, ErrInvalidPatch
+	}
+
+	var amended models.Order
+	if err := json.Unmarshal(merged, &amended); err != nil {
+		return models.Order{}, ErrInvalidPatch
+	}
+
+	if len(amended.Products) == 0 {
+		return models.Order{}, ErrInvalidProducts
+	}
+	for i := range amended.Products {
+		if amended.Products[i].Quantity <= 0 {
+			return models.Order{}, ErrInvalidProducts
+		}
+		amended.Products[i].ReturnedQuantity = 0
+	}
+
+	return amended, nil
+}
+
+// mergePatch applies a JSON Merge Patch to a JSON document: objects are
+// merged recursively, null removes a member and any other value replaces it.
+func mergePatch(document []byte, patch []byte) ([]byte, error) {
+	var target, changes interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(document))
+	decoder.UseNumber()
+	if err := decoder.Decode(&target); err != nil {
+		return nil, err
+	}
+
+	decoder = json.NewDecoder(bytes.NewReader(patch))
+	decoder.UseNumber()
+	if err := decoder.Decode(&changes); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergeValue(target, changes))
+}
+
+func mergeValue(target interface{}, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergeValue(targetObject[key], value)
+	}
+
+	return targetObject
+}
+
+// diffOrder lists the amendable fields that changed, comparing the products
+// by SKU.
+func diffOrder(order models.Order, amended models.Order) []models.FieldChange {
+	changes := []models.FieldChange{}
+
+	if order.Buyer.Phone != amended.Buyer.Phone {
+		changes = append(changes, models.FieldChange{Field: "buyer.phone", From: order.Buyer.Phone, To: amended.Buyer.Phone})
+	}
+	if order.TotalValue != amended.TotalValue {
+		changes = append(changes, models.FieldChange{Field: "totalValue", From: formatPrice(order.TotalValue), To: formatPrice(amended.TotalValue)})
+	}
+
+	previous := map[string]models.Product{}
+	for _, product := range order.Products {
+		previous[product.Sku] = product
+	}
+	current := map[string]models.Product{}
+	for _, product := range amended.Products {
+		current[product.Sku] = product
+	}
+
+	for _, product := range order.Products {
+		if _, ok := current[product.Sku]; !ok {
+			changes = append(changes, diffProduct(product.Sku, product, models.Product{})...)
+		}
+	}
+	for _, product := range amended.Products {
+		changes = append(changes, diffProduct(product.Sku, previous[product.Sku], product)...)
+	}
+
+	return changes
+}
+
+var productFields = []string{"name", "description", "price", "quantity"}
+
+func diffProduct(sku string, from models.Product, to models.Product) []models.FieldChange {
+	changes := []models.FieldChange{}
+
+	fromValues, toValues := productValues(from), productValues(to)
+	for i, name := range productFields {
+		if fromValues[i] != toValues[i] {
+			changes = append(changes, models.FieldChange{Field: "products." + sku + "." + name, From: fromValues[i], To: toValues[i]})
+		}
+	}
+
+	return changes
+}
+
+// productValues renders the fields of productFields, empty for a product that
+// is not in the order.
+func productValues(product models.Product) []string {
+	if product.Sku == "" {
+		return make([]string, len(productFields))
+	}
+	return []string{product.Name, product.Description, formatPrice(product.Price), strconv.FormatInt(product.Quantity, 10)}
+}
+
+func formatPrice(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func countEvents(events []models.Event, eventType models.EventType) int {
+	count := 0
+	for _, event := range events {
+		if event.Type == eventType {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,302 @@
+package orders
+
+import (
+	"challenge_pyegros/app/database"
+	"challenge_pyegros/app/models"
+	ports "challenge_pyegros/app/ports/orders"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	CodeTotalMismatch             = "TOTAL_MISMATCH"
+	CodeMismatchExternalReference = "EXTERNAL_REFERENCE_MISMATCH"
+	CodeChannelNotFound           = "CHANNEL_NOT_FOUND"
+	CodeChannelForbidden          = "CHANNEL_FORBIDDEN"
+	CodeDuplicateKey              = "DUPLICATE_KEY"
+	CodeOrderNotFound             = "ORDER_NOT_FOUND"
+	CodeInvalidStateTransition    = "INVALID_STATE_TRANSITION"
+	CodeOrderChanged              = "ORDER_CHANGED"
+	CodeEventIDConflict           = "EVENT_ID_CONFLICT"
+	CodeMissingReason             = "MISSING_REASON"
+	CodeUnexpectedReason          = "UNEXPECTED_REASON"
+	CodeInvalidReasonCode         = "INVALID_REASON_CODE"
+	CodeMissingReturnedItems      = "MISSING_RETURNED_ITEMS"
+	CodeUnexpectedReturnedItems   = "UNEXPECTED_RETURNED_ITEMS"
+	CodeUnknownSku                = "UNKNOWN_SKU"
+	CodeInvalidReturnQuantity     = "INVALID_RETURN_QUANTITY"
+	CodeInternalError             = "INTERNAL_ERROR"
+)
+
+// CreateOrders imports a batch of orders with the validation and idempotency
+// of CreateOrder. The IDs of the new orders are allocated in a single block
+// and at most bulkConcurrency orders are looked up or inserted at the same
+// time. The results keep the position of every order in the batch.
+func (u *UseCase) CreateOrders(orders []models.Order) ([]models.BulkOrderResult, error) {
+	results := make([]models.BulkOrderResult, len(orders))
+
+	// An order repeated in the batch is a duplicate of its first occurrence.
+	first := map[string]int{}
+	repeated := map[int]int{}
+	pending := []int{}
+	for i, order := range orders {
+		results[i] = models.BulkOrderResult{Index: i}
+
+		err := validateOrder(order)
+		if err != nil {
+			results[i] = bulkError(i, err)
+			continue
+		}
+
+		key := orderIdempotencyKey(order)
+		if index, ok := first[key]; ok {
+			repeated[i] = index
+			continue
+		}
+		first[key] = i
+		pending = append(pending, i)
+	}
+
+	cached := make([]bool, len(orders))
+	u.forEachOrder(pending, func(i int) {
+		response, err := database.GetOrderDataFromRedis(orderIdempotencyKey(orders[i]), u.redis)
+		if err == nil && response != nil {
+			results[i] = models.BulkOrderResult{Index: i, Status: models.BulkStatusDuplicate, OrderID: response.OrderID}
+			cached[i] = true
+		}
+	})
+
+	create := []int{}
+	for _, i := range pending {
+		if !cached[i] {
+			create = append(create, i)
+		}
+	}
+
+	if len(create) > 0 {
+		id, err := u.r.ObtainIDBlock(int64(len(create)))
+		if err != nil {
+			return nil, err
+		}
+
+		ids := map[int]int64{}
+		for _, i := range create {
+			ids[i] = id
+			id++
+		}
+
+		u.forEachOrder(create, func(i int) {
+			order := orders[i]
+			order.OrderID = ids[i]
+			order.Status = models.StatusCreated
+			order.Events = []models.Event{}
+
+			err := u.r.InsertOrder(order)
+			if err != nil {
+				results[i] = bulkError(i, err)
+				return
+			}
+
+			response := &models.ResponseCreate{
+				OrderID:   order.OrderID,
+				Status:    order.Status,
+				UpdatedOn: order.PurchaseDate,
+			}
+			err = database.SetOrderDataFromRedis(orderIdempotencyKey(order), response, u.redis)
+			if err != nil {
+				fmt.Println("Error seting value of: " + orderIdempotencyKey(order))
+			}
+			results[i] = models.BulkOrderResult{Index: i, Status: models.BulkStatusCreated, OrderID: order.OrderID}
+		})
+	}
+
+	for i, index := range repeated {
+		results[i] = results[index]
+		results[i].Index = i
+		if results[i].Status == models.BulkStatusCreated {
+			results[i].Status = models.BulkStatusDuplicate
+		}
+	}
+
+	return results, nil
+}
+
+// UpdateEventOrders applies a batch of events with the logic of
+// UpdateEventOrder. The events of the same order are applied in the order of
+// the batch, and every order with new events is written once, in a single
+// write of the whole batch. The results keep the position of every event in
+// the batch. A channel other than "" restricts the events to the orders of
+// the channel, the events of other orders fail with ErrChannelForbidden.
+func (u *UseCase) UpdateEventOrders(items []models.BulkEventItem, channel models.Channel) ([]models.BulkEventResult, error) {
+	results := make([]models.BulkEventResult, len(items))
+	orderIDs := []int64{}
+	itemsByOrder := map[int64][]int{}
+	for i, item := range items {
+		results[i] = models.BulkEventResult{Index: i, OrderID: item.OrderID, EventID: item.Event.Id}
+		if _, ok := itemsByOrder[item.OrderID]; !ok {
+			orderIDs = append(orderIDs, item.OrderID)
+		}
+		itemsByOrder[item.OrderID] = append(itemsByOrder[item.OrderID], i)
+	}
+
+	found, err := u.r.FindOrdersByIDs(orderIDs)
+	if err != nil {
+		return nil, err
+	}
+	orders := map[int64]*models.Order{}
+	for i := range found {
+		orders[found[i].OrderID] = &found[i]
+	}
+
+	updates := []models.OrderEvents{}
+	applied := map[int64][]int{}
+	responses := make([]*models.ResponseUpdate, len(items))
+	for _, orderID := range orderIDs {
+		order, ok := orders[orderID]
+		events := []models.Event{}
+
+		for _, i := range itemsByOrder[orderID] {
+			if !ok {
+				setEventError(&results[i], mongo.ErrNoDocuments)
+				continue
+			}
+			if channel != "" && order.Channel != channel {
+				setEventError(&results[i], ErrChannelForbidden)
+				continue
+			}
+
+			event := items[i].Event
+			cached, err := database.GetEventDataFromRedis(orderID, event.Id, u.redis)
+			if err == nil && cached != nil {
+				setEventResult(&results[i], models.BulkStatusDuplicate, cached)
+				continue
+			}
+
+			response, isNew, err := u.applyEvent(order, event)
+			if err != nil {
+				setEventError(&results[i], err)
+				continue
+			}
+			if !isNew {
+				setEventResult(&results[i], models.BulkStatusDuplicate, response)
+				continue
+			}
+
+			setEventResult(&results[i], models.BulkStatusApplied, response)
+			responses[i] = response
+			applied[orderID] = append(applied[orderID], i)
+			events = append(events, event)
+		}
+
+		if len(events) > 0 {
+			updates = append(updates, models.OrderEvents{Order: *order, Events: events})
+		}
+	}
+
+	if len(updates) == 0 {
+		return results, nil
+	}
+
+	failures, err := u.r.UpdateOrdersEvents(updates)
+	if err != nil {
+		return nil, err
+	}
+
+	for index, update := range updates {
+		orderID := update.Order.OrderID
+		if failures[index] != nil {
+			for _, i := range applied[orderID] {
+				setEventError(&results[i], failures[index])
+			}
+			continue
+		}
+
+		u.invalidateOrderCache(orderID)
+		for _, i := range applied[orderID] {
+			err := database.SetEventDataFromRedis(orderID, items[i].Event.Id, responses[i], u.redis)
+			if err != nil {
+				fmt.Println("Error seting value of: " + items[i].Event.Id)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+func setEventResult(result *models.BulkEventResult, status string, response *models.ResponseUpdate) {
+	result.Status = status
+	result.PreviousStatus = response.PreviousStatus
+	result.NewStatus = response.NewStatus
+}
+
+func setEventError(result *models.BulkEventResult, err error) {
+	result.Status = models.BulkStatusError
+	result.PreviousStatus = ""
+	result.NewStatus = ""
+	result.Code = errorCode(err)
+	result.Error = err.Error()
+}
+
+// forEachOrder calls fn with every index, running at most bulkConcurrency
+// calls at the same time.
+func (u *UseCase) forEachOrder(indexes []int, fn func(i int)) {
+	var group errgroup.Group
+	group.SetLimit(u.bulkConcurrency)
+	for _, i := range indexes {
+		group.Go(func() error {
+			fn(i)
+			return nil
+		})
+	}
+	group.Wait()
+}
+
+func bulkError(index int, err error) models.BulkOrderResult {
+	return models.BulkOrderResult{
+		Index:  index,
+		Status: models.BulkStatusError,
+		Code:   errorCode(err),
+		Error:  err.Error(),
+	}
+}
+
+func errorCode(err error) string {
+	switch err {
+	case ErrTotalMismatch:
+		return CodeTotalMismatch
+	case ErrMismatchExternalReference:
+		return CodeMismatchExternalReference
+	case ErrChannelNotFound:
+		return CodeChannelNotFound
+	case ErrChannelForbidden:
+		return CodeChannelForbidden
+	case mongo.ErrNoDocuments:
+		return CodeOrderNotFound
+	case ErrInvalidStateTransition:
+		return CodeInvalidStateTransition
+	case ports.ErrOrderChanged:
+		return CodeOrderChanged
+	case ErrAnotherEventWithSameID:
+		return CodeEventIDConflict
+	case ErrMissingReason:
+		return CodeMissingReason
+	case ErrUnexpectedReason:
+		return CodeUnexpectedReason
+	case ErrInvalidReasonCode:
+		return CodeInvalidReasonCode
+	case ErrMissingReturnedItems:
+		return CodeMissingReturnedItems
+	case ErrUnexpectedReturnedItems:
+		return CodeUnexpectedReturnedItems
+	case ErrUnknownSku:
+		return CodeUnknownSku
+	case ErrInvalidReturnQuantity:
+		return CodeInvalidReturnQuantity
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return CodeDuplicateKey
+	}
+	return CodeInternalError
+}
@@ -0,0 +1,42 @@
+package orders
+
+import (
+	"challenge_pyegros/app/database"
+	"expvar"
+	"fmt"
+	"strconv"
+)
+
+var (
+	cacheHits   = expvar.NewInt("order_cache_hits")
+	cacheMisses = expvar.NewInt("order_cache_misses")
+	cacheErrors = expvar.NewInt("order_cache_errors")
+)
+
+func init() {
+	expvar.Publish("order_cache_hit_rate", expvar.Func(func() any {
+		hits := cacheHits.Value()
+		total := hits + cacheMisses.Value()
+		if total == 0 {
+			return 0.0
+		}
+		return float64(hits) / float64(total)
+	}))
+}
+
+// invalidateOrderCache drops the cached order after a change. A read that was
+// already in flight can still store the previous version, the TTL bounds how
+// long it is served.
+func (u *UseCase) invalidateOrderCache(orderID int64) {
+	key := strconv.FormatInt(orderID, 10)
+	u.group.Forget(key)
+	if u.redis == nil {
+		return
+	}
+
+	err := database.DeleteOrderDetailFromRedis(orderID, u.redis)
+	if err != nil {
+		cacheErrors.Add(1)
+		fmt.Println("Error deleting value of: " + key)
+	}
+}
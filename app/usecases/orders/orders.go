@@ -1,36 +1,433 @@
 package orders
 
 import (
+	"challenge_pyegros/app/database"
 	"challenge_pyegros/app/models"
 	ports "challenge_pyegros/app/ports/orders"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 )
 
+var (
+	ErrTotalMismatch             = errors.New("Total value does not match sum of products")
+	ErrMismatchExternalReference = errors.New("External ReferenceId does not match with channel")
+	ErrChannelNotFound           = errors.New("Channel not found")
+	ErrChannelForbidden          = errors.New("The order belongs to another channel")
+	ErrAnotherEventWithSameID    = errors.New("Another event with same ID already exists")
+	ErrInvalidStateTransition    = errors.New("Invalid state transition")
+	ErrMissingReason             = errors.New("The event requires a reason")
+	ErrUnexpectedReason          = errors.New("Only Canceled and Returned events have a reason")
+	ErrInvalidReasonCode         = errors.New("The reason code is not in the catalogue")
+	ErrMissingReturnedItems      = errors.New("The returned products are required")
+	ErrUnexpectedReturnedItems   = errors.New("Only Returned events have returned products")
+	ErrUnknownSku                = errors.New("The returned product is not in the order")
+	ErrInvalidReturnQuantity     = errors.New("The returned quantity must be positive and not exceed the bought quantity")
+)
+
+const (
+	DefaultOrderCacheTTL   = 5 * time.Minute
+	DefaultBulkConcurrency = 8
+)
+
+// UseCase applies the rules of the orders: validation, state transitions,
+// returns, amendments and idempotency. The repository only stores the
+// resulting orders.
 type UseCase struct {
-	r     ports.OrdersUseCase
-	redis *redis.Client
+	r               ports.OrdersRepository
+	redis           *redis.Client
+	orderCacheTTL   time.Duration
+	reasonCatalogue models.ReasonCatalogue
+	bulkConcurrency int
+	group           singleflight.Group
+}
+
+type Option func(*UseCase)
+
+// WithOrderCacheTTL sets how long GetOrderByID keeps an order in the cache.
+func WithOrderCacheTTL(ttl time.Duration) Option {
+	return func(u *UseCase) {
+		u.orderCacheTTL = ttl
+	}
+}
+
+// WithReasonCatalogue sets the reason codes allowed in Canceled and Returned
+// events.
+func WithReasonCatalogue(catalogue models.ReasonCatalogue) Option {
+	return func(u *UseCase) {
+		u.reasonCatalogue = catalogue
+	}
+}
+
+// WithBulkConcurrency sets how many orders of a bulk import are processed at
+// the same time.
+func WithBulkConcurrency(concurrency int) Option {
+	return func(u *UseCase) {
+		u.bulkConcurrency = concurrency
+	}
 }
 
-func NewUseCase(r ports.OrdersUseCase, redis *redis.Client) *UseCase {
-	return &UseCase{
-		r:     r,
-		redis: redis,
+func NewUseCase(r ports.OrdersRepository, redis *redis.Client, options ...Option) *UseCase {
+	useCase := &UseCase{
+		r:               r,
+		redis:           redis,
+		orderCacheTTL:   DefaultOrderCacheTTL,
+		reasonCatalogue: models.DefaultReasonCatalogue,
+		bulkConcurrency: DefaultBulkConcurrency,
 	}
+	for _, option := range options {
+		option(useCase)
+	}
+	return useCase
 }
 
 func (u *UseCase) CreateOrder(order models.Order) (*models.ResponseCreate, error) {
-	return u.r.CreateOrder(order)
+	keyForRedisCache := orderIdempotencyKey(order)
+
+	responseCache, err := database.GetOrderDataFromRedis(keyForRedisCache, u.redis)
+	if err == redis.Nil {
+		fmt.Println("Key " + keyForRedisCache + " dont exist in Cache")
+	} else if err != nil {
+		fmt.Println("Error getting value of: " + keyForRedisCache)
+	} else if responseCache != nil {
+		fmt.Println("Get value from cache")
+		responseCache.Duplicate = true
+		return responseCache, nil
+	}
+
+	err = validateOrder(order)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int64
+	id, err = u.r.ObtainID()
+	if err != nil {
+		return nil, err
+	}
+	order.OrderID = id
+	order.Status = models.StatusCreated
+	order.Events = []models.Event{}
+
+	err = u.r.InsertOrder(order)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.ResponseCreate{
+		OrderID:   id,
+		Status:    models.StatusCreated,
+		UpdatedOn: order.PurchaseDate,
+	}
+
+	err = database.SetOrderDataFromRedis(keyForRedisCache, response, u.redis)
+	if err != nil {
+		fmt.Println("Error seting value of: " + keyForRedisCache)
+	} else {
+		fmt.Println("Set value of key: " + keyForRedisCache)
+	}
+	return response, nil
 }
 
 func (u *UseCase) UpdateEventOrder(orderID int64, event models.Event) (*models.ResponseUpdate, error) {
-	return u.r.UpdateEventOrder(orderID, event)
+	responseCache, err := database.GetEventDataFromRedis(orderID, event.Id, u.redis)
+	if err == redis.Nil {
+		fmt.Println("Key " + event.Id + " dont exist in Cache")
+	} else if err != nil {
+		fmt.Println("Error getting value of: " + event.Id)
+	} else if responseCache != nil {
+		fmt.Println("Get value from cache")
+		responseCache.Duplicate = true
+		return responseCache, nil
+	}
+
+	order, err := u.r.FindOrderByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	response, applied, err := u.applyEvent(order, event)
+	if err != nil {
+		return nil, err
+	}
+	if !applied {
+		response.Duplicate = true
+		return response, nil
+	}
+
+	err = u.r.UpdateOrderEvents(*order, []models.Event{event})
+	if err != nil {
+		return nil, err
+	}
+
+	u.invalidateOrderCache(orderID)
+
+	err = database.SetEventDataFromRedis(orderID, event.Id, response, u.redis)
+	if err != nil {
+		fmt.Println("Error seting value of: " + event.Id)
+	} else {
+		fmt.Println("Set value of key: " + event.Id)
+	}
+
+	return response, nil
 }
 
+// applyEvent validates the event against the order and applies it to the
+// order in memory. An event with the same ID, date and type of one already
+// in the order is not applied again: it is checked first, as the order moved
+// on since, and the response it had is returned.
+func (u *UseCase) applyEvent(order *models.Order, event models.Event) (*models.ResponseUpdate, bool, error) {
+	unique, err := checkUniqueEventID(order.Events, event)
+	if err != nil {
+		return nil, false, err
+	}
+	if !unique {
+		return replayedResponse(*order, event), false, nil
+	}
+
+	newStatus, err := validateStateTransition(order.Status, event.Type)
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = validateReason(event, u.reasonCatalogue)
+	if err != nil {
+		return nil, false, err
+	}
+
+	products, refundedAmount := order.Products, order.RefundedAmount
+	if event.Type == models.EventReturned {
+		var refund float64
+		var fullyReturned bool
+		products, refund, fullyReturned, err = applyReturn(order.Products, event.Reason.Items)
+		if err != nil {
+			return nil, false, err
+		}
+		if !fullyReturned {
+			newStatus = models.StatusPartiallyReturned
+		}
+		refundedAmount += refund
+	}
+
+	response := &models.ResponseUpdate{
+		OrderID:        order.OrderID,
+		PreviousStatus: order.Status,
+		NewStatus:      newStatus,
+		UpdatedOn:      event.Date,
+	}
+
+	order.Status = newStatus
+	order.Products = products
+	order.RefundedAmount = refundedAmount
+	order.Events = append(order.Events, event)
+
+	return response, true, nil
+}
+
+// GetOrderByID reads through the order cache. Concurrent misses of the same
+// order share a single read of the repository. Without Redis, or when it
+// fails, the order is read from the repository and not cached.
 func (u *UseCase) GetOrderByID(orderID int64) (*models.ResponseGet, error) {
-	return u.r.GetOrderByID(orderID)
+	key := strconv.FormatInt(orderID, 10)
+
+	cache := u.redis != nil
+	if cache {
+		responseCache, err := database.GetOrderDetailFromRedis(orderID, u.redis)
+		if err == nil {
+			cacheHits.Add(1)
+			return responseCache, nil
+		} else if err != redis.Nil {
+			cacheErrors.Add(1)
+			fmt.Println("Error getting value of: " + key)
+			cache = false
+		}
+	}
+	cacheMisses.Add(1)
+
+	response, err, _ := u.group.Do(key, func() (interface{}, error) {
+		order, err := u.r.FindOrderByID(orderID)
+		if err != nil {
+			return nil, err
+		}
+		response := orderResponse(*order)
+		if !cache {
+			return response, nil
+		}
+
+		err = database.SetOrderDetailFromRedis(orderID, response, u.orderCacheTTL, u.redis)
+		if err != nil {
+			cacheErrors.Add(1)
+			fmt.Println("Error seting value of: " + key)
+		}
+		return response, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	shared := *response.(*models.ResponseGet)
+	return &shared, nil
+}
+
+func orderResponse(order models.Order) *models.ResponseGet {
+	return &models.ResponseGet{
+		OrderID:             order.OrderID,
+		ExternalReferenceID: order.ExternalReferenceID,
+		Channel:             order.Channel,
+		PurchaseDate:        order.PurchaseDate,
+		TotalValue:          order.TotalValue,
+		RefundedAmount:      order.RefundedAmount,
+		Buyer:               order.Buyer,
+		Products:            order.Products,
+		Status:              order.Status,
+		Events:              order.Events,
+	}
 }
 
 func (u *UseCase) GetOrderByFilters(filters models.Filters) ([]models.Order, error) {
 	return u.r.GetOrderByFilters(filters)
 }
+
+func (u *UseCase) StreamOrdersByFilters(filters models.Filters, fn func(order models.Order) error) error {
+	return u.r.StreamOrdersByFilters(filters, fn)
+}
+
+func (u *UseCase) GetBuyerOrders(documentNumber string, filters models.BuyerFilters) (*models.ResponseBuyerOrders, error) {
+	return u.r.GetBuyerOrders(documentNumber, filters)
+}
+
+func (u *UseCase) GetOrderEvents(orderID int64, filters models.EventFilters) (*models.ResponseEvents, error) {
+	order, err := u.r.FindOrderByID(orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := ApplyEventFilters(buildTimeline(order.Events, order.Products), filters)
+	sortTimeline(timeline)
+
+	response := &models.ResponseEvents{
+		OrderID:  order.OrderID,
+		Page:     filters.Page,
+		PageSize: filters.PageSize,
+		Total:    int64(len(timeline)),
+		Events:   paginateTimeline(timeline, filters.Page, filters.PageSize),
+	}
+
+	return response, nil
+}
+
+// orderIdempotencyKey identifies an order by its external reference, so the
+// same order posted twice is only created once.
+func orderIdempotencyKey(order models.Order) string {
+	return order.ExternalReferenceID + "-" + string(order.Channel)
+}
+
+func validateOrder(order models.Order) error {
+	if !validateTotal(order.Products, order.TotalValue) {
+		return ErrTotalMismatch
+	}
+
+	isValid, err := validateExternalReferenceId(order.ExternalReferenceID, order.Channel)
+	if err != nil || !isValid {
+		return err
+	}
+	return nil
+}
+
+func validateTotal(products []models.Product, total float64) bool {
+	var totalCalculated float64
+
+	for _, product := range products {
+		totalCalculated = (product.Price * float64(product.Quantity)) + totalCalculated
+	}
+
+	if totalCalculated != total {
+		return false
+	}
+
+	return true
+}
+
+func validateStateTransition(actualStatus models.OrderStatus, typeEvent models.EventType) (models.OrderStatus, error) {
+	err := ErrInvalidStateTransition
+
+	switch typeEvent {
+	case models.EventPaymentReceived:
+		if actualStatus == models.StatusCreated {
+			return models.StatusPaymentReceived, nil
+		}
+	case models.EventCanceled:
+		if actualStatus == models.StatusCreated {
+			return models.StatusCanceled, nil
+		}
+	case models.EventInvoiced:
+		if actualStatus == models.StatusPaymentReceived {
+			return models.StatusInvoiced, nil
+		}
+	case models.EventReturned:
+		if actualStatus == models.StatusInvoiced || actualStatus == models.StatusPartiallyReturned {
+			return models.StatusReturned, nil
+		}
+	}
+
+	return "", err
+}
+
+func validateReason(event models.Event, catalogue models.ReasonCatalogue) error {
+	if event.Type != models.EventCanceled && event.Type != models.EventReturned {
+		if event.Reason != nil {
+			return ErrUnexpectedReason
+		}
+		return nil
+	}
+
+	if event.Reason == nil {
+		return ErrMissingReason
+	}
+	if !catalogue.HasCode(event.Type, event.Reason.Code) {
+		return ErrInvalidReasonCode
+	}
+
+	if event.Type == models.EventCanceled {
+		if len(event.Reason.Items) > 0 {
+			return ErrUnexpectedReturnedItems
+		}
+		return nil
+	}
+
+	if len(event.Reason.Items) == 0 {
+		return ErrMissingReturnedItems
+	}
+
+	return nil
+}
+
+func checkUniqueEventID(events []models.Event, newEvent models.Event) (bool, error) {
+	for _, event := range events {
+		if event.Id == newEvent.Id {
+			if event.Date == newEvent.Date && event.Type == newEvent.Type {
+				return false, nil
+			} else {
+				return false, ErrAnotherEventWithSameID
+			}
+		}
+	}
+	return true, nil
+}
+
+func validateExternalReferenceId(id string, channel models.Channel) (bool, error) {
+	v, ok := models.ExternalReferenceIDs[channel]
+	if ok {
+		if v == id {
+			return true, nil
+		} else {
+			return false, ErrMismatchExternalReference
+		}
+	} else {
+		return false, ErrChannelNotFound
+	}
+}
@@ -0,0 +1,851 @@
+package orders
+
+import (
+	"challenge_pyegros/app/database"
+	"challenge_pyegros/app/models"
+	ports "challenge_pyegros/app/ports/orders"
+	"challenge_pyegros/app/ports/orders/mocks"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/mock/gomock"
+)
+
+var (
+	order = models.Order{
+		ExternalReferenceID: "abc-123",
+		Channel:             "Ecommerce",
+		PurchaseDate:        "2024-05-01T14:00:00Z",
+		TotalValue:          2000,
+		Buyer: models.Buyer{
+			FirstName:      "Patricio",
+			LastName:       "Yegros",
+			DocumentNumber: "87654321",
+			Phone:          "+541112345678",
+		},
+		Products: []models.Product{
+			{
+				Sku:         "P001",
+				Name:        "Producto A",
+				Description: "Descripción",
+				Price:       1000,
+				Quantity:    2,
+			},
+		},
+		Events: []models.Event{},
+	}
+
+	event = models.Event{
+		Id:   "event-001",
+		Type: "PaymentReceived",
+		Date: "2024-05-01T15:00:00Z",
+		User: "adminUser123",
+	}
+
+	errRepository = errors.New("repository error")
+)
+
+func CreateCacheForTesting(t *testing.T) *redis.Client {
+	s := miniredis.RunT(t)
+
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     s.Addr(),
+		Password: "",
+		DB:       0,
+	})
+
+	return rdb
+}
+
+func newTestUseCase(t *testing.T, options ...Option) (*UseCase, *mocks.MockOrdersRepository, *redis.Client) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockOrdersRepository(ctrl)
+	rdb := CreateCacheForTesting(t)
+	return NewUseCase(repo, rdb, options...), repo, rdb
+}
+
+// storedOrder is order as it is read from the repository.
+func storedOrder(id int64, status models.OrderStatus, events ...models.Event) *models.Order {
+	stored := order
+	stored.OrderID = id
+	stored.Status = status
+	stored.Products = append([]models.Product{}, order.Products...)
+	stored.Events = append([]models.Event{}, events...)
+	return &stored
+}
+
+func TestCreateOrderSuccess(t *testing.T) {
+	useCase, repo, _ := newTestUseCase(t)
+
+	repo.EXPECT().ObtainID().Return(int64(1), nil)
+	repo.EXPECT().InsertOrder(gomock.Any()).DoAndReturn(func(inserted models.Order) error {
+		assert.Equal(t, int64(1), inserted.OrderID)
+		assert.Equal(t, models.StatusCreated, inserted.Status)
+		assert.Equal(t, []models.Event{}, inserted.Events)
+		return nil
+	})
+
+	response := &models.ResponseCreate{
+		OrderID:   1,
+		Status:    "Created",
+		UpdatedOn: "2024-05-01T14:00:00Z",
+	}
+
+	model, err := useCase.CreateOrder(order)
+	assert.Nil(t, err)
+	assert.Equal(t, response, model)
+
+	// The same order again is answered from the idempotency cache.
+	model, err = useCase.CreateOrder(order)
+	assert.Nil(t, err)
+	response.Duplicate = true
+	assert.Equal(t, response, model)
+}
+
+func TestCreateOrderFails(t *testing.T) {
+	tests := []struct {
+		name  string
+		order func() models.Order
+		err   error
+	}{
+		{name: "total mismatch", order: func() models.Order { o := order; o.TotalValue = 3000; return o }, err: ErrTotalMismatch},
+		{name: "invalid external reference id", order: func() models.Order { o := order; o.ExternalReferenceID = "invalid_id"; return o }, err: ErrMismatchExternalReference},
+		{name: "unknown channel", order: func() models.Order { o := order; o.Channel = "Unknown"; return o }, err: ErrChannelNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useCase, _, _ := newTestUseCase(t)
+
+			model, err := useCase.CreateOrder(test.order())
+			assert.Nil(t, model)
+			assert.Equal(t, test.err, err)
+		})
+	}
+}
+
+func TestCreateOrderObtainIDError(t *testing.T) {
+	useCase, repo, _ := newTestUseCase(t)
+	repo.EXPECT().ObtainID().Return(int64(0), errRepository)
+
+	model, err := useCase.CreateOrder(order)
+	assert.Nil(t, model)
+	assert.Equal(t, errRepository, err)
+}
+
+func TestCreateOrderFailsInsert(t *testing.T) {
+	useCase, repo, rdb := newTestUseCase(t)
+	repo.EXPECT().ObtainID().Return(int64(1), nil)
+	repo.EXPECT().InsertOrder(gomock.Any()).Return(errRepository)
+
+	model, err := useCase.CreateOrder(order)
+	assert.Nil(t, model)
+	assert.Equal(t, errRepository, err)
+
+	_, err = database.GetOrderDataFromRedis(orderIdempotencyKey(order), rdb)
+	assert.Equal(t, redis.Nil, err)
+}
+
+func TestGetOrderByIDReadsThroughCache(t *testing.T) {
+	useCase, repo, rdb := newTestUseCase(t, WithOrderCacheTTL(time.Minute))
+
+	// The repository is read once, the second read must come from Redis.
+	repo.EXPECT().FindOrderByID(int64(1)).Return(storedOrder(1, "Created"), nil)
+
+	hits := cacheHits.Value()
+
+	model, err := useCase.GetOrderByID(1)
+	assert.Nil(t, err)
+	assert.Equal(t, &models.ResponseGet{
+		OrderID:             1,
+		ExternalReferenceID: order.ExternalReferenceID,
+		Channel:             order.Channel,
+		PurchaseDate:        order.PurchaseDate,
+		TotalValue:          order.TotalValue,
+		Buyer:               order.Buyer,
+		Products:            order.Products,
+		Status:              "Created",
+		Events:              []models.Event{},
+	}, model)
+
+	cached, err := useCase.GetOrderByID(1)
+	assert.Nil(t, err)
+	assert.Equal(t, model, cached)
+	assert.Equal(t, hits+1, cacheHits.Value())
+	assert.Equal(t, time.Minute, rdb.TTL(context.Background(), database.OrderDetailKey(1)).Val())
+
+	useCase.invalidateOrderCache(1)
+	repo.EXPECT().FindOrderByID(int64(1)).Return(nil, mongo.ErrNoDocuments)
+
+	model, err = useCase.GetOrderByID(1)
+	assert.Nil(t, model)
+	assert.Equal(t, mongo.ErrNoDocuments, err)
+}
+
+func TestGetOrderByIDWithoutRedis(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockOrdersRepository(ctrl)
+	useCase := NewUseCase(repo, nil)
+
+	// Every read goes to the repository, as there is no cache.
+	repo.EXPECT().FindOrderByID(int64(1)).Return(storedOrder(1, "Created"), nil).Times(2)
+
+	for range 2 {
+		model, err := useCase.GetOrderByID(1)
+		assert.Nil(t, err)
+		assert.Equal(t, models.StatusCreated, model.Status)
+	}
+
+	useCase.invalidateOrderCache(1)
+}
+
+func TestGetOrderByIDWithRedisDown(t *testing.T) {
+	s := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	s.Close()
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockOrdersRepository(ctrl)
+	useCase := NewUseCase(repo, rdb)
+
+	failures := cacheErrors.Value()
+	repo.EXPECT().FindOrderByID(int64(1)).Return(storedOrder(1, "Created"), nil)
+
+	model, err := useCase.GetOrderByID(1)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusCreated, model.Status)
+	// The failed read is the only error, the order is not written back.
+	assert.Equal(t, failures+1, cacheErrors.Value())
+}
+
+func TestUpdateEventOrderSuccess(t *testing.T) {
+	useCase, repo, rdb := newTestUseCase(t)
+
+	repo.EXPECT().FindOrderByID(int64(1)).Return(storedOrder(1, "Created"), nil)
+	repo.EXPECT().UpdateOrderEvents(gomock.Any(), []models.Event{event}).DoAndReturn(func(updated models.Order, events []models.Event) error {
+		assert.Equal(t, models.StatusPaymentReceived, updated.Status)
+		assert.Equal(t, []models.Event{event}, updated.Events)
+		return nil
+	})
+
+	response := &models.ResponseUpdate{
+		OrderID:        1,
+		PreviousStatus: "Created",
+		NewStatus:      "PaymentReceived",
+		UpdatedOn:      event.Date,
+	}
+
+	model, err := useCase.UpdateEventOrder(1, event)
+	assert.Nil(t, err)
+	assert.Equal(t, response, model)
+
+	cached, err := database.GetEventDataFromRedis(1, event.Id, rdb)
+	assert.Nil(t, err)
+	assert.Equal(t, response, cached)
+
+	// The same event again is answered from the idempotency cache.
+	model, err = useCase.UpdateEventOrder(1, event)
+	assert.Nil(t, err)
+	response.Duplicate = true
+	assert.Equal(t, response, model)
+}
+
+func TestUpdateEventOrderRepeatEvent(t *testing.T) {
+	invoiced := models.Event{Id: "event-002", Type: "Invoiced", Date: "2024-05-02T10:00:00Z"}
+	partialReturn := models.Event{
+		Id:     "event-003",
+		Type:   "Returned",
+		Date:   "2024-05-03T10:00:00Z",
+		Reason: &models.EventReason{Code: "DEFECTIVE", Items: []models.ReturnedItem{{Sku: "P001", Quantity: 1}}},
+	}
+	returned := storedOrder(1, "PartiallyReturned", event, invoiced, partialReturn)
+	returned.Products[0].ReturnedQuantity = 1
+
+	tests := []struct {
+		name     string
+		event    models.Event
+		response *models.ResponseUpdate
+	}{
+		{
+			name:     "payment of an order returned since",
+			event:    event,
+			response: &models.ResponseUpdate{OrderID: 1, PreviousStatus: "Created", NewStatus: "PaymentReceived", UpdatedOn: event.Date, Duplicate: true},
+		},
+		{
+			name:     "partial return",
+			event:    partialReturn,
+			response: &models.ResponseUpdate{OrderID: 1, PreviousStatus: "Invoiced", NewStatus: "PartiallyReturned", UpdatedOn: partialReturn.Date, Duplicate: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// Without Redis the replays are only told apart by the events of
+			// the order, nothing is written.
+			ctrl := gomock.NewController(t)
+			repo := mocks.NewMockOrdersRepository(ctrl)
+			useCase := NewUseCase(repo, nil)
+			repo.EXPECT().FindOrderByID(int64(1)).Return(returned, nil)
+
+			model, err := useCase.UpdateEventOrder(1, test.event)
+			assert.Nil(t, err)
+			assert.Equal(t, test.response, model)
+		})
+	}
+}
+
+func TestUpdateEventOrderFails(t *testing.T) {
+	returned := func(items ...models.ReturnedItem) models.Event {
+		return models.Event{
+			Id:     "event-002",
+			Type:   "Returned",
+			Date:   "2024-05-01T15:00:00Z",
+			User:   "admin002",
+			Reason: &models.EventReason{Code: "DEFECTIVE", Items: items},
+		}
+	}
+	sameIDOtherType := event
+	sameIDOtherType.Date = "2024-05-02T15:00:00Z"
+
+	overReturned := storedOrder(1, "PartiallyReturned")
+	overReturned.Products[0].ReturnedQuantity = 1
+
+	tests := []struct {
+		name  string
+		order *models.Order
+		find  error
+		event models.Event
+		err   error
+	}{
+		{name: "not found", find: mongo.ErrNoDocuments, event: event, err: mongo.ErrNoDocuments},
+		{name: "event transition", order: storedOrder(1, "Created"), event: models.Event{Id: "event-002", Type: "Invoiced", Date: "2025-05-01T15:00:00Z"}, err: ErrInvalidStateTransition},
+		{name: "another event with same id", order: storedOrder(1, "Created", sameIDOtherType), event: event, err: ErrAnotherEventWithSameID},
+		{name: "over return", order: overReturned, event: returned(models.ReturnedItem{Sku: "P001", Quantity: 2}), err: ErrInvalidReturnQuantity},
+		{name: "unknown sku", order: storedOrder(1, "Invoiced"), event: returned(models.ReturnedItem{Sku: "P009", Quantity: 1}), err: ErrUnknownSku},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useCase, repo, _ := newTestUseCase(t)
+			repo.EXPECT().FindOrderByID(int64(1)).Return(test.order, test.find)
+
+			model, err := useCase.UpdateEventOrder(1, test.event)
+			assert.Nil(t, model)
+			assert.Equal(t, test.err, err)
+		})
+	}
+}
+
+func TestUpdateEventOrderFailsReason(t *testing.T) {
+	useCase, repo, _ := newTestUseCase(t, WithReasonCatalogue(models.ReasonCatalogue{
+		"Canceled": {{Code: "NO_STOCK"}},
+	}))
+	repo.EXPECT().FindOrderByID(int64(1)).Return(storedOrder(1, "Created"), nil)
+
+	localEvent := models.Event{
+		Id:     "event-002",
+		Type:   "Canceled",
+		Date:   "2024-05-01T15:00:00Z",
+		User:   "admin002",
+		Reason: &models.EventReason{Code: "OUT_OF_STOCK"},
+	}
+
+	model, err := useCase.UpdateEventOrder(1, localEvent)
+	assert.Nil(t, model)
+	assert.Equal(t, ErrInvalidReasonCode, err)
+}
+
+func TestUpdateEventOrderPartialReturn(t *testing.T) {
+	useCase, repo, _ := newTestUseCase(t)
+
+	invoiced := storedOrder(1, "Invoiced")
+	invoiced.Products = []models.Product{{Sku: "P001", Price: 10, Quantity: 2}}
+	repo.EXPECT().FindOrderByID(int64(1)).Return(invoiced, nil)
+	repo.EXPECT().UpdateOrderEvents(gomock.Any(), gomock.Any()).DoAndReturn(func(updated models.Order, events []models.Event) error {
+		assert.Equal(t, models.StatusPartiallyReturned, updated.Status)
+		assert.Equal(t, int64(1), updated.Products[0].ReturnedQuantity)
+		assert.Equal(t, 10.0, updated.RefundedAmount)
+		return nil
+	})
+
+	localEvent := models.Event{
+		Id:     "event-002",
+		Type:   "Returned",
+		Date:   "2024-05-01T15:00:00Z",
+		User:   "admin002",
+		Reason: &models.EventReason{Code: "DEFECTIVE", Items: []models.ReturnedItem{{Sku: "P001", Quantity: 1}}},
+	}
+
+	model, err := useCase.UpdateEventOrder(1, localEvent)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusPartiallyReturned, model.NewStatus)
+}
+
+func TestUpdateEventOrderFailsUpdate(t *testing.T) {
+	useCase, repo, rdb := newTestUseCase(t)
+	repo.EXPECT().FindOrderByID(int64(1)).Return(storedOrder(1, "Created"), nil)
+	repo.EXPECT().UpdateOrderEvents(gomock.Any(), gomock.Any()).Return(errRepository)
+
+	model, err := useCase.UpdateEventOrder(1, event)
+	assert.Nil(t, model)
+	assert.Equal(t, errRepository, err)
+
+	_, err = database.GetEventDataFromRedis(1, event.Id, rdb)
+	assert.Equal(t, redis.Nil, err)
+}
+
+func TestGetOrderEvents(t *testing.T) {
+	useCase, repo, _ := newTestUseCase(t)
+
+	invoiced := models.Event{
+		Id:   "event-002",
+		Type: "Invoiced",
+		Date: "2024-05-02T10:00:00Z",
+		User: "adminUser123",
+	}
+	repo.EXPECT().FindOrderByID(int64(1)).Return(storedOrder(1, "Invoiced", event, invoiced), nil)
+	repo.EXPECT().FindOrderByID(int64(2)).Return(nil, mongo.ErrNoDocuments)
+
+	model, err := useCase.GetOrderEvents(1, models.EventFilters{Page: 1, PageSize: 20})
+	assert.Nil(t, err)
+	assert.Equal(t, &models.ResponseEvents{
+		OrderID:  1,
+		Page:     1,
+		PageSize: 20,
+		Total:    2,
+		Events: []models.TimelineEvent{
+			{Event: event, Status: "PaymentReceived"},
+			{Event: invoiced, Status: "Invoiced"},
+		},
+	}, model)
+
+	model, err = useCase.GetOrderEvents(2, models.EventFilters{Page: 1, PageSize: 20})
+	assert.Nil(t, model)
+	assert.Equal(t, mongo.ErrNoDocuments, err)
+}
+
+func TestValidateStateTransition(t *testing.T) {
+
+	status, err := validateStateTransition("Created", "PaymentReceived")
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusPaymentReceived, status)
+
+	status, err = validateStateTransition("Created", "Canceled")
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusCanceled, status)
+
+	status, err = validateStateTransition("PaymentReceived", "Invoiced")
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusInvoiced, status)
+
+	status, err = validateStateTransition("Invoiced", "Returned")
+	assert.NoError(t, err)
+	assert.Equal(t, models.StatusReturned, status)
+
+	status, err = validateStateTransition("Invalid", "Invalid")
+	assert.Error(t, err)
+}
+
+func TestCheckUniqueEventID(t *testing.T) {
+	events := []models.Event{
+		{Id: "1", Date: "2022-01-01", Type: "Created"},
+	}
+	newEvent := models.Event{Id: "2", Date: "2022-01-02", Type: "PaymentReceived"}
+	unique, err := checkUniqueEventID(events, newEvent)
+	assert.True(t, unique)
+	assert.NoError(t, err)
+
+	duplicateEvent := models.Event{Id: "1", Date: "2022-01-01", Type: "Created"}
+	unique, err = checkUniqueEventID(events, duplicateEvent)
+	assert.False(t, unique)
+	assert.NoError(t, err)
+
+	otherEventWithSameID := models.Event{Id: "1", Date: "2022-01-02", Type: "PaymentReceived"}
+	unique, err = checkUniqueEventID(events, otherEventWithSameID)
+	assert.False(t, unique)
+	assert.Error(t, err)
+}
+
+func TestValidateExternalReferenceIdError(t *testing.T) {
+	ok, err := validateExternalReferenceId("abc-123", "Unknown")
+	assert.False(t, ok)
+	assert.Error(t, err)
+}
+
+func TestBuildTimeline(t *testing.T) {
+	events := []models.Event{
+		{Id: "1", Type: "PaymentReceived", Date: "2024-05-01T15:00:00Z", User: "a"},
+		{Id: "2", Type: "Invoiced", Date: "2024-05-02T15:00:00Z", User: "b"},
+		{Id: "3", Type: "Returned", Date: "2024-05-03T15:00:00Z", User: "a"},
+	}
+
+	timeline := buildTimeline(events, nil)
+	assert.Equal(t, models.StatusPaymentReceived, timeline[0].Status)
+	assert.Equal(t, models.StatusInvoiced, timeline[1].Status)
+	assert.Equal(t, models.StatusReturned, timeline[2].Status)
+
+	filtered := ApplyEventFilters(timeline, models.EventFilters{User: "a"})
+	assert.Len(t, filtered, 2)
+
+	filtered = ApplyEventFilters(timeline, models.EventFilters{Type: "Invoiced"})
+	assert.Len(t, filtered, 1)
+	assert.Equal(t, "2", filtered[0].Id)
+
+	filtered = ApplyEventFilters(timeline, models.EventFilters{
+		DateFrom: "2024-05-02T00:00:00Z",
+		DateTo:   "2024-05-02T23:59:59Z",
+	})
+	assert.Len(t, filtered, 1)
+	assert.Equal(t, "2", filtered[0].Id)
+}
+
+func TestPaginateTimeline(t *testing.T) {
+	timeline := buildTimeline([]models.Event{
+		{Id: "1", Type: "PaymentReceived"},
+		{Id: "2", Type: "Invoiced"},
+		{Id: "3", Type: "Returned"},
+	}, nil)
+
+	page := paginateTimeline(timeline, 1, 2)
+	assert.Len(t, page, 2)
+
+	page = paginateTimeline(timeline, 2, 2)
+	assert.Len(t, page, 1)
+	assert.Equal(t, "3", page[0].Id)
+
+	page = paginateTimeline(timeline, 3, 2)
+	assert.Empty(t, page)
+}
+
+func TestValidateReason(t *testing.T) {
+	tests := []struct {
+		name  string
+		event models.Event
+		err   error
+	}{
+		{name: "event without reason", event: models.Event{Type: "PaymentReceived"}},
+		{name: "unexpected reason", event: models.Event{Type: "Invoiced", Reason: &models.EventReason{Code: "DEFECTIVE"}}, err: ErrUnexpectedReason},
+		{name: "missing reason", event: models.Event{Type: "Canceled"}, err: ErrMissingReason},
+		{name: "cancellation", event: models.Event{Type: "Canceled", Reason: &models.EventReason{Code: "OUT_OF_STOCK", Comment: "No stock left"}}},
+		{name: "code of other type", event: models.Event{Type: "Canceled", Reason: &models.EventReason{Code: "DEFECTIVE"}}, err: ErrInvalidReasonCode},
+		{name: "cancellation with items", event: models.Event{Type: "Canceled", Reason: &models.EventReason{Code: "OUT_OF_STOCK", Items: []models.ReturnedItem{{Sku: "P001", Quantity: 1}}}}, err: ErrUnexpectedReturnedItems},
+		{name: "return", event: models.Event{Type: "Returned", Reason: &models.EventReason{Code: "DEFECTIVE", Items: []models.ReturnedItem{{Sku: "P001", Quantity: 2}, {Sku: "P002", Quantity: 1}}}}},
+		{name: "return without items", event: models.Event{Type: "Returned", Reason: &models.EventReason{Code: "DEFECTIVE"}}, err: ErrMissingReturnedItems},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateReason(test.event, models.DefaultReasonCatalogue)
+			assert.Equal(t, test.err, err)
+		})
+	}
+}
+
+func TestApplyReturn(t *testing.T) {
+	products := []models.Product{
+		{Sku: "P001", Price: 10, Quantity: 2},
+		{Sku: "P002", Price: 5, Quantity: 1, ReturnedQuantity: 1},
+	}
+
+	tests := []struct {
+		name          string
+		items         []models.ReturnedItem
+		refund        float64
+		fullyReturned bool
+		err           error
+	}{
+		{name: "partial return", items: []models.ReturnedItem{{Sku: "P001", Quantity: 1}}, refund: 10},
+		{name: "full return", items: []models.ReturnedItem{{Sku: "P001", Quantity: 2}}, refund: 20, fullyReturned: true},
+		{name: "unknown sku", items: []models.ReturnedItem{{Sku: "P003", Quantity: 1}}, err: ErrUnknownSku},
+		{name: "zero quantity", items: []models.ReturnedItem{{Sku: "P001", Quantity: 0}}, err: ErrInvalidReturnQuantity},
+		{name: "more than bought", items: []models.ReturnedItem{{Sku: "P001", Quantity: 1}, {Sku: "P001", Quantity: 2}}, err: ErrInvalidReturnQuantity},
+		{name: "already returned", items: []models.ReturnedItem{{Sku: "P002", Quantity: 1}}, err: ErrInvalidReturnQuantity},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			updated, refund, fullyReturned, err := applyReturn(products, test.items)
+			assert.Equal(t, test.err, err)
+			assert.Equal(t, test.refund, refund)
+			assert.Equal(t, test.fullyReturned, fullyReturned)
+			if err == nil {
+				assert.Equal(t, int64(0), products[0].ReturnedQuantity)
+				assert.Equal(t, test.items[0].Quantity, updated[0].ReturnedQuantity)
+			}
+		})
+	}
+}
+
+func TestBuildTimelinePartialReturns(t *testing.T) {
+	products := []models.Product{
+		{Sku: "P001", Quantity: 2, ReturnedQuantity: 2},
+		{Sku: "P002", Quantity: 1, ReturnedQuantity: 1},
+	}
+	returned := func(id string, items ...models.ReturnedItem) models.Event {
+		return models.Event{Id: id, Type: "Returned", Reason: &models.EventReason{Code: "DEFECTIVE", Items: items}}
+	}
+
+	timeline := buildTimeline([]models.Event{
+		{Id: "1", Type: "PaymentReceived"},
+		{Id: "2", Type: "Invoiced"},
+		returned("3", models.ReturnedItem{Sku: "P001", Quantity: 1}),
+		returned("4", models.ReturnedItem{Sku: "P001", Quantity: 1}),
+		returned("5", models.ReturnedItem{Sku: "P002", Quantity: 1}),
+	}, products)
+
+	assert.Equal(t, models.StatusPartiallyReturned, timeline[2].Status)
+	assert.Equal(t, models.StatusPartiallyReturned, timeline[3].Status)
+	assert.Equal(t, models.StatusReturned, timeline[4].Status)
+}
+
+func TestAmendOrderSuccess(t *testing.T) {
+	useCase, repo, _ := newTestUseCase(t)
+	repo.EXPECT().FindOrderByID(int64(1)).Return(storedOrder(1, "Created"), nil)
+	repo.EXPECT().UpdateAmendedOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(amended models.Order, event models.Event) error {
+		assert.Equal(t, int64(1), amended.OrderID)
+		assert.Equal(t, "+541187654321", amended.Buyer.Phone)
+		assert.Len(t, amended.Products, 2)
+		assert.Equal(t, []models.Event{event}, amended.Events)
+		assert.Equal(t, "amendment-1", event.Id)
+		assert.Equal(t, models.EventAmended, event.Type)
+		assert.Equal(t, "user-001", event.User)
+		return nil
+	})
+
+	patch := `{"buyer": {"phone": "+541187654321"}, "totalValue": 2500, "products": [
+		{"sku": "P001", "name": "Producto A", "description": "Descripción", "price": 1000, "quantity": 1},
+		{"sku": "P002", "name": "Producto B", "price": 1500, "quantity": 1}
+	]}`
+
+	model, err := useCase.AmendOrder(1, []byte(patch), "user-001")
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusCreated, model.Status)
+	assert.Equal(t, []models.FieldChange{
+		{Field: "buyer.phone", From: "+541112345678", To: "+541187654321"},
+		{Field: "totalValue", From: "2000", To: "2500"},
+		{Field: "products.P001.quantity", From: "2", To: "1"},
+		{Field: "products.P002.name", To: "Producto B"},
+		{Field: "products.P002.price", To: "1500"},
+		{Field: "products.P002.quantity", To: "1"},
+	}, model.Changes)
+}
+
+func TestAmendOrderFails(t *testing.T) {
+	tests := []struct {
+		name   string
+		status models.OrderStatus
+		patch  string
+		err    error
+	}{
+		{name: "not created", status: "PaymentReceived", patch: `{"buyer": {"phone": "1"}}`, err: ErrOrderNotAmendable},
+		{name: "not an object", status: "Created", patch: `[]`, err: ErrInvalidPatch},
+		{name: "channel", status: "Created", patch: `{"channel": "Store"}`, err: ErrFieldNotAmendable},
+		{name: "buyer name", status: "Created", patch: `{"buyer": {"firstName": "Juan"}}`, err: ErrFieldNotAmendable},
+		{name: "no products", status: "Created", patch: `{"products": null, "totalValue": 0}`, err: ErrInvalidProducts},
+		{name: "total mismatch", status: "Created", patch: `{"products": [{"sku": "P001", "price": 1000, "quantity": 3}]}`, err: ErrTotalMismatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			useCase, repo, _ := newTestUseCase(t)
+			repo.EXPECT().FindOrderByID(int64(1)).Return(storedOrder(1, test.status), nil)
+
+			model, err := useCase.AmendOrder(1, []byte(test.patch), "user-001")
+			assert.Nil(t, model)
+			assert.Equal(t, test.err, err)
+		})
+	}
+}
+
+func TestAmendOrderFailsOrderChanged(t *testing.T) {
+	useCase, repo, _ := newTestUseCase(t)
+
+	// Two amendments read the order before any of them is written, only the
+	// first one is stored.
+	repo.EXPECT().FindOrderByID(int64(1)).Return(storedOrder(1, "Created"), nil).Times(2)
+	gomock.InOrder(
+		repo.EXPECT().UpdateAmendedOrder(gomock.Any(), gomock.Any()).Return(nil),
+		repo.EXPECT().UpdateAmendedOrder(gomock.Any(), gomock.Any()).DoAndReturn(func(amended models.Order, event models.Event) error {
+			assert.Equal(t, "amendment-1", event.Id)
+			assert.Equal(t, []models.Event{event}, amended.Events)
+			return ports.ErrOrderChanged
+		}),
+	)
+
+	model, err := useCase.AmendOrder(1, []byte(`{"buyer": {"phone": "+541187654321"}}`), "user-001")
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusCreated, model.Status)
+
+	model, err = useCase.AmendOrder(1, []byte(`{"totalValue": 1000, "products": [{"sku": "P001", "price": 1000, "quantity": 1}]}`), "user-002")
+	assert.Nil(t, model)
+	assert.Equal(t, ports.ErrOrderChanged, err)
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		patch    string
+		result   string
+	}{
+		{name: "replace member", document: `{"a": "b"}`, patch: `{"a": "c"}`, result: `{"a":"c"}`},
+		{name: "add member", document: `{"a": "b"}`, patch: `{"b": "c"}`, result: `{"a":"b","b":"c"}`},
+		{name: "remove member", document: `{"a": "b", "b": "c"}`, patch: `{"a": null}`, result: `{"b":"c"}`},
+		{name: "replace array", document: `{"a": [1, 2]}`, patch: `{"a": [3]}`, result: `{"a":[3]}`},
+		{name: "nested object", document: `{"a": {"b": "c", "d": "e"}}`, patch: `{"a": {"d": null, "f": 1}}`, result: `{"a":{"b":"c","f":1}}`},
+		{name: "replace document", document: `{"a": "b"}`, patch: `["c"]`, result: `["c"]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := mergePatch([]byte(test.document), []byte(test.patch))
+			assert.Nil(t, err)
+			assert.Equal(t, test.result, string(result))
+		})
+	}
+}
+
+func TestCreateOrders(t *testing.T) {
+	useCase, repo, rdb := newTestUseCase(t, WithBulkConcurrency(2))
+
+	repo.EXPECT().ObtainIDBlock(int64(2)).Return(int64(10), nil)
+	repo.EXPECT().InsertOrder(gomock.Any()).Return(nil).Times(2)
+
+	store := order
+	store.Channel = "Store"
+	store.ExternalReferenceID = "ghi-789"
+
+	callCenter := order
+	callCenter.Channel = "CallCenter"
+	callCenter.ExternalReferenceID = "def-456"
+	err := database.SetOrderDataFromRedis(orderIdempotencyKey(callCenter), &models.ResponseCreate{OrderID: 3, Status: "Created"}, rdb)
+	assert.Nil(t, err)
+
+	mismatch := order
+	mismatch.TotalValue = 1
+
+	results, err := useCase.CreateOrders([]models.Order{order, mismatch, store, callCenter, order})
+	assert.Nil(t, err)
+	assert.Equal(t, []models.BulkOrderResult{
+		{Index: 0, Status: models.BulkStatusCreated, OrderID: 10},
+		{Index: 1, Status: models.BulkStatusError, Code: CodeTotalMismatch, Error: ErrTotalMismatch.Error()},
+		{Index: 2, Status: models.BulkStatusCreated, OrderID: 11},
+		{Index: 3, Status: models.BulkStatusDuplicate, OrderID: 3},
+		{Index: 4, Status: models.BulkStatusDuplicate, OrderID: 10},
+	}, results)
+
+	cached, err := database.GetOrderDataFromRedis(orderIdempotencyKey(store), rdb)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), cached.OrderID)
+}
+
+func TestCreateOrdersObtainIDBlockError(t *testing.T) {
+	useCase, repo, _ := newTestUseCase(t)
+	repo.EXPECT().ObtainIDBlock(int64(1)).Return(int64(0), errRepository)
+
+	results, err := useCase.CreateOrders([]models.Order{order})
+	assert.Nil(t, results)
+	assert.Equal(t, errRepository, err)
+}
+
+func TestUpdateEventOrders(t *testing.T) {
+	useCase, repo, rdb := newTestUseCase(t)
+
+	cached := &models.ResponseUpdate{OrderID: 3, PreviousStatus: "Created", NewStatus: "PaymentReceived"}
+	err := database.SetEventDataFromRedis(3, "event-3", cached, rdb)
+	assert.Nil(t, err)
+
+	repo.EXPECT().FindOrdersByIDs([]int64{1, 2, 3}).Return([]models.Order{*storedOrder(1, "Created"), *storedOrder(3, "Created")}, nil)
+	repo.EXPECT().UpdateOrdersEvents(gomock.Any()).DoAndReturn(func(updates []models.OrderEvents) ([]error, error) {
+		assert.Len(t, updates, 1)
+		assert.Equal(t, models.StatusInvoiced, updates[0].Order.Status)
+		assert.Len(t, updates[0].Events, 2)
+		return []error{nil}, nil
+	})
+
+	results, err := useCase.UpdateEventOrders([]models.BulkEventItem{
+		{OrderID: 1, Event: models.Event{Id: "event-1", Type: "PaymentReceived", Date: "2024-05-01T15:00:00Z"}},
+		{OrderID: 2, Event: models.Event{Id: "event-2", Type: "PaymentReceived", Date: "2024-05-01T15:00:00Z"}},
+		{OrderID: 1, Event: models.Event{Id: "event-4", Type: "Invoiced", Date: "2024-05-02T15:00:00Z"}},
+		{OrderID: 3, Event: models.Event{Id: "event-3", Type: "PaymentReceived", Date: "2024-05-01T15:00:00Z"}},
+		{OrderID: 1, Event: models.Event{Id: "event-5", Type: "Canceled", Date: "2024-05-03T15:00:00Z"}},
+	}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []models.BulkEventResult{
+		{Index: 0, Status: models.BulkStatusApplied, OrderID: 1, EventID: "event-1", PreviousStatus: "Created", NewStatus: "PaymentReceived"},
+		{Index: 1, Status: models.BulkStatusError, OrderID: 2, EventID: "event-2", Code: CodeOrderNotFound, Error: "mongo: no documents in result"},
+		{Index: 2, Status: models.BulkStatusApplied, OrderID: 1, EventID: "event-4", PreviousStatus: "PaymentReceived", NewStatus: "Invoiced"},
+		{Index: 3, Status: models.BulkStatusDuplicate, OrderID: 3, EventID: "event-3", PreviousStatus: "Created", NewStatus: "PaymentReceived"},
+		{Index: 4, Status: models.BulkStatusError, OrderID: 1, EventID: "event-5", Code: CodeInvalidStateTransition, Error: ErrInvalidStateTransition.Error()},
+	}, results)
+
+	response, err := database.GetEventDataFromRedis(1, "event-4", rdb)
+	assert.Nil(t, err)
+	assert.Equal(t, models.StatusInvoiced, response.NewStatus)
+}
+
+func TestUpdateEventOrdersOfAnotherChannel(t *testing.T) {
+	useCase, repo, _ := newTestUseCase(t)
+
+	store := *storedOrder(2, "Created")
+	store.Channel = models.ChannelStore
+	repo.EXPECT().FindOrdersByIDs([]int64{1, 2}).Return([]models.Order{*storedOrder(1, "Created"), store}, nil)
+	repo.EXPECT().UpdateOrdersEvents(gomock.Any()).DoAndReturn(func(updates []models.OrderEvents) ([]error, error) {
+		assert.Len(t, updates, 1)
+		assert.Equal(t, int64(2), updates[0].Order.OrderID)
+		return []error{nil}, nil
+	})
+
+	results, err := useCase.UpdateEventOrders([]models.BulkEventItem{
+		{OrderID: 1, Event: models.Event{Id: "event-1", Type: "PaymentReceived", Date: "2024-05-01T15:00:00Z"}},
+		{OrderID: 2, Event: models.Event{Id: "event-2", Type: "PaymentReceived", Date: "2024-05-01T15:00:00Z"}},
+	}, models.ChannelStore)
+	assert.Nil(t, err)
+	assert.Equal(t, []models.BulkEventResult{
+		{Index: 0, Status: models.BulkStatusError, OrderID: 1, EventID: "event-1", Code: CodeChannelForbidden, Error: ErrChannelForbidden.Error()},
+		{Index: 1, Status: models.BulkStatusApplied, OrderID: 2, EventID: "event-2", PreviousStatus: "Created", NewStatus: "PaymentReceived"},
+	}, results)
+}
+
+func TestUpdateEventOrdersFailsWrite(t *testing.T) {
+	useCase, repo, rdb := newTestUseCase(t)
+
+	repo.EXPECT().FindOrdersByIDs([]int64{1, 2}).Return([]models.Order{*storedOrder(1, "Created"), *storedOrder(2, "Created")}, nil)
+	repo.EXPECT().UpdateOrdersEvents(gomock.Any()).Return([]error{nil, errRepository}, nil)
+
+	results, err := useCase.UpdateEventOrders([]models.BulkEventItem{
+		{OrderID: 1, Event: models.Event{Id: "event-1", Type: "PaymentReceived", Date: "2024-05-01T15:00:00Z"}},
+		{OrderID: 2, Event: models.Event{Id: "event-2", Type: "PaymentReceived", Date: "2024-05-01T15:00:00Z"}},
+	}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, models.BulkStatusApplied, results[0].Status)
+	assert.Equal(t, models.BulkStatusError, results[1].Status)
+	assert.Equal(t, CodeInternalError, results[1].Code)
+
+	_, err = database.GetEventDataFromRedis(2, "event-2", rdb)
+	assert.Equal(t, redis.Nil, err)
+}
+
+func TestUpdateEventOrdersOrderChanged(t *testing.T) {
+	useCase, repo, rdb := newTestUseCase(t)
+
+	repo.EXPECT().FindOrdersByIDs([]int64{1}).Return([]models.Order{*storedOrder(1, "Created")}, nil)
+	repo.EXPECT().UpdateOrdersEvents(gomock.Any()).Return([]error{ports.ErrOrderChanged}, nil)
+
+	results, err := useCase.UpdateEventOrders([]models.BulkEventItem{
+		{OrderID: 1, Event: models.Event{Id: "event-1", Type: "PaymentReceived", Date: "2024-05-01T15:00:00Z"}},
+	}, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []models.BulkEventResult{
+		{Index: 0, Status: models.BulkStatusError, OrderID: 1, EventID: "event-1", Code: CodeOrderChanged, Error: ports.ErrOrderChanged.Error()},
+	}, results)
+
+	_, err = database.GetEventDataFromRedis(1, "event-1", rdb)
+	assert.Equal(t, redis.Nil, err)
+}
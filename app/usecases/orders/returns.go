@@ -0,0 +1,54 @@
+package orders
+
+import (
+	"challenge_pyegros/app/models"
+)
+
+// applyReturn adds the returned items to the returned quantity of every line.
+// It returns the updated lines, the amount to refund and whether every unit
+// of the order has been returned. Returning more units than the ones left is
+// rejected.
+func applyReturn(products []models.Product, items []models.ReturnedItem) ([]models.Product, float64, bool, error) {
+	updated := make([]models.Product, len(products))
+	copy(updated, products)
+
+	var refund float64
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return nil, 0, false, ErrInvalidReturnQuantity
+		}
+
+		pending := item.Quantity
+		found := false
+		for i := range updated {
+			if updated[i].Sku != item.Sku {
+				continue
+			}
+			found = true
+
+			returnable := updated[i].Quantity - updated[i].ReturnedQuantity
+			if returnable > pending {
+				returnable = pending
+			}
+			updated[i].ReturnedQuantity += returnable
+			refund += updated[i].Price * float64(returnable)
+			pending -= returnable
+		}
+
+		if !found {
+			return nil, 0, false, ErrUnknownSku
+		}
+		if pending > 0 {
+			return nil, 0, false, ErrInvalidReturnQuantity
+		}
+	}
+
+	fullyReturned := true
+	for _, product := range updated {
+		if product.ReturnedQuantity < product.Quantity {
+			fullyReturned = false
+		}
+	}
+
+	return updated, refund, fullyReturned, nil
+}
@@ -0,0 +1,117 @@
+package orders
+
+import (
+	"challenge_pyegros/app/models"
+	"sort"
+	"time"
+)
+
+// buildTimeline replays the events in the order they were stored, starting
+// from the "Created" status with nothing returned, so every entry carries the
+// resulting status. Returns recorded without items return the whole order.
+func buildTimeline(events []models.Event, products []models.Product) []models.TimelineEvent {
+	timeline := make([]models.TimelineEvent, 0, len(events))
+	status := models.StatusCreated
+
+	returned := make([]models.Product, len(products))
+	for i, product := range products {
+		returned[i] = product
+		returned[i].ReturnedQuantity = 0
+	}
+
+	for _, event := range events {
+		newStatus, err := validateStateTransition(status, event.Type)
+		if err == nil {
+			status = newStatus
+			if event.Type == models.EventReturned && event.Reason != nil {
+				updated, _, fullyReturned, err := applyReturn(returned, event.Reason.Items)
+				if err == nil {
+					returned = updated
+					if !fullyReturned {
+						status = models.StatusPartiallyReturned
+					}
+				}
+			}
+		}
+		timeline = append(timeline, models.TimelineEvent{Event: event, Status: status})
+	}
+
+	return timeline
+}
+
+// replayedResponse is the response of an event already in the order, with
+// the statuses of the order before and after it was applied.
+func replayedResponse(order models.Order, event models.Event) *models.ResponseUpdate {
+	response := &models.ResponseUpdate{
+		OrderID:        order.OrderID,
+		PreviousStatus: models.StatusCreated,
+		UpdatedOn:      event.Date,
+	}
+	for _, entry := range buildTimeline(order.Events, order.Products) {
+		if entry.Id == event.Id {
+			response.NewStatus = entry.Status
+			break
+		}
+		response.PreviousStatus = entry.Status
+	}
+	return response
+}
+
+func ApplyEventFilters(timeline []models.TimelineEvent, filters models.EventFilters) []models.TimelineEvent {
+	from, errFrom := time.Parse(time.RFC3339, filters.DateFrom)
+	to, errTo := time.Parse(time.RFC3339, filters.DateTo)
+
+	var filtered = []models.TimelineEvent{}
+	for _, entry := range timeline {
+		if len(filters.Type) > 0 && entry.Type != filters.Type {
+			continue
+		}
+		if len(filters.User) > 0 && entry.User != filters.User {
+			continue
+		}
+		if errFrom == nil || errTo == nil {
+			date, err := time.Parse(time.RFC3339, entry.Date)
+			if err != nil {
+				continue
+			}
+			if errFrom == nil && date.Before(from) {
+				continue
+			}
+			if errTo == nil && date.After(to) {
+				continue
+			}
+		}
+		filtered = append(filtered, entry)
+	}
+
+	return filtered
+}
+
+func sortTimeline(timeline []models.TimelineEvent) {
+	sort.SliceStable(timeline, func(i, j int) bool {
+		first, errFirst := time.Parse(time.RFC3339, timeline[i].Date)
+		second, errSecond := time.Parse(time.RFC3339, timeline[j].Date)
+		if errFirst != nil || errSecond != nil {
+			return timeline[i].Date < timeline[j].Date
+		}
+		return first.Before(second)
+	})
+}
+
+func paginateTimeline(timeline []models.TimelineEvent, page int64, pageSize int64) []models.TimelineEvent {
+	if page < 1 || pageSize < 1 {
+		return []models.TimelineEvent{}
+	}
+
+	start := (page - 1) * pageSize
+	if start >= int64(len(timeline)) {
+		return []models.TimelineEvent{}
+	}
+
+	end := start + pageSize
+	if end > int64(len(timeline)) {
+		end = int64(len(timeline))
+	}
+
+	return timeline[start:end]
+}
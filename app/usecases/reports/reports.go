@@ -0,0 +1,24 @@
+package reports
+
+import (
+	"challenge_pyegros/app/models"
+	ports "challenge_pyegros/app/ports/reports"
+)
+
+type UseCase struct {
+	r ports.ReportsRepository
+}
+
+func NewUseCase(r ports.ReportsRepository) *UseCase {
+	return &UseCase{
+		r: r,
+	}
+}
+
+func (u *UseCase) GetReasonsReport(filters models.ReportFilters) ([]models.ReasonReport, error) {
+	return u.r.GetReasonsReport(filters)
+}
+
+func (u *UseCase) GetSalesReport(filters models.SalesFilters) (*models.ResponseSalesReport, error) {
+	return u.r.GetSalesReport(filters)
+}
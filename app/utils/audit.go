@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"challenge_pyegros/app/models"
+	ports "challenge_pyegros/app/ports/audit"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+// RecordAudit completes the entry with the request metadata and appends it to
+// the audit trail. A failure is logged but does not fail the request, because
+// the change has already been applied.
+func RecordAudit(audit ports.AuditUseCase, r *http.Request, entry models.AuditEntry) {
+	entry.Actor = GetActor(r)
+	entry.RequestID = middleware.GetReqID(r.Context())
+	entry.SourceIP = GetSourceIP(r)
+	entry.Timestamp = time.Now().UTC().Format(time.RFC3339)
+
+	err := audit.Record(entry)
+	if err != nil {
+		fmt.Println("Error recording audit entry: " + err.Error())
+	}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"challenge_pyegros/app/models"
+	"context"
+	"net"
+	"net/http"
+
+	"google.golang.org/grpc/peer"
+)
+
+type principalKey struct{}
+
+const AnonymousActor = "anonymous"
+
+func WithPrincipal(ctx context.Context, principal *models.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func GetPrincipal(ctx context.Context) (*models.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*models.Principal)
+	return principal, ok && principal != nil
+}
+
+// GetActor returns the subject of the authenticated principal of the request,
+// or AnonymousActor when the request is not authenticated.
+func GetActor(r *http.Request) string {
+	return GetContextActor(r.Context())
+}
+
+// GetContextActor is GetActor for the context of a gRPC call.
+func GetContextActor(ctx context.Context) string {
+	principal, ok := GetPrincipal(ctx)
+	if !ok || principal.Subject == "" {
+		return AnonymousActor
+	}
+	return principal.Subject
+}
+
+func GetSourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// GetPeerIP is GetSourceIP for the context of a gRPC call, empty when the
+// call has no peer.
+func GetPeerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
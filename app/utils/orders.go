@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"challenge_pyegros/app/models"
+	ports "challenge_pyegros/app/ports/orders"
+	orderUseCase "challenge_pyegros/app/usecases/orders"
+	"errors"
+	"net/http"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// The REST handlers and the gRPC service validate the requests of the orders
+// and map the errors of the use case with these functions, so both APIs
+// answer the same.
+
+var (
+	ErrOrderNotFound    = errors.New("The search did not return any results. Incorrect ID.")
+	ErrChannelForbidden = errors.New("The channel of the order does not match the channel of the API key")
+	ErrInvalidFromDate  = errors.New("The From date is not in the correct format")
+	ErrInvalidToDate    = errors.New("The To date is not in the correct format")
+	ErrStatusFilter     = errors.New("The status must be one of " + enumValues(models.OrderStatuses))
+	ErrChannelFilter    = errors.New("The channel must be one of " + enumValues(models.Channels))
+	ErrEventTypeFilter  = errors.New("The type must be one of " + enumValues(models.EventTypes))
+)
+
+// RequestError is an error of a request with the HTTP status of the REST
+// API, the gRPC service translates the status to its code.
+type RequestError struct {
+	Status int
+	Err    error
+}
+
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+// OwnsChannel reports whether the principal can see the orders of the
+// channel. Principals without a channel, e.g. JWTs, see every channel.
+func OwnsChannel(principal *models.Principal, channel models.Channel) bool {
+	return principal == nil || principal.Channel == "" || principal.Channel == channel
+}
+
+// ValidateNewOrder checks the purchase date of an order to create and that a
+// principal bound to a channel creates orders of its channel only.
+func ValidateNewOrder(principal *models.Principal, order models.Order) error {
+	if err := CheckFormatDate(order.PurchaseDate); err != nil {
+		return err
+	}
+	if !OwnsChannel(principal, order.Channel) {
+		return ErrChannelForbidden
+	}
+	return nil
+}
+
+// ValidateFilters checks the status, channel and dates of a search.
+func ValidateFilters(filters models.Filters) error {
+	if filters.Status != "" && !filters.Status.IsValid() {
+		return ErrStatusFilter
+	}
+	if filters.Channel != "" && !filters.Channel.IsValid() {
+		return ErrChannelFilter
+	}
+	if CheckFormatDate(filters.CreatedOnFrom) != nil {
+		return ErrInvalidFromDate
+	}
+	if CheckFormatDate(filters.CreatedOnTo) != nil {
+		return ErrInvalidToDate
+	}
+	return nil
+}
+
+// CreateOrderError maps an error of ValidateNewOrder or CreateOrder.
+func CreateOrderError(err error) *RequestError {
+	switch {
+	case isUnknownEnum(err), isInvalidOrder(err), err == ErrInvalidDate:
+		return &RequestError{Status: http.StatusBadRequest, Err: err}
+	case err == ErrChannelForbidden:
+		return &RequestError{Status: http.StatusForbidden, Err: err}
+	}
+	return &RequestError{Status: http.StatusInternalServerError, Err: err}
+}
+
+// AddEventError maps an error of the event date or of UpdateEventOrder.
+func AddEventError(err error) *RequestError {
+	switch {
+	case err == mongo.ErrNoDocuments:
+		return &RequestError{Status: http.StatusNotFound, Err: ErrOrderNotFound}
+	case isUnknownEnum(err), isInvalidReason(err), err == ErrInvalidDate:
+		return &RequestError{Status: http.StatusBadRequest, Err: err}
+	case err == ports.ErrOrderChanged, err == orderUseCase.ErrInvalidStateTransition, err == orderUseCase.ErrAnotherEventWithSameID:
+		return &RequestError{Status: http.StatusConflict, Err: err}
+	}
+	return &RequestError{Status: http.StatusInternalServerError, Err: err}
+}
+
+// GetOrderError maps an error of GetOrderByID.
+func GetOrderError(err error) *RequestError {
+	if err == mongo.ErrNoDocuments {
+		return &RequestError{Status: http.StatusNotFound, Err: ErrOrderNotFound}
+	}
+	return &RequestError{Status: http.StatusInternalServerError, Err: err}
+}
+
+// SearchOrdersError maps an error of ValidateFilters or of the search.
+func SearchOrdersError(err error) *RequestError {
+	if err == ErrStatusFilter || err == ErrChannelFilter || err == ErrInvalidFromDate || err == ErrInvalidToDate || isUnknownEnum(err) {
+		return &RequestError{Status: http.StatusBadRequest, Err: err}
+	}
+	return &RequestError{Status: http.StatusInternalServerError, Err: err}
+}
+
+func isUnknownEnum(err error) bool {
+	return errors.Is(err, models.ErrUnknownOrderStatus) || errors.Is(err, models.ErrUnknownEventType) || errors.Is(err, models.ErrUnknownChannel)
+}
+
+func isInvalidOrder(err error) bool {
+	switch err {
+	case orderUseCase.ErrTotalMismatch,
+		orderUseCase.ErrMismatchExternalReference,
+		orderUseCase.ErrChannelNotFound:
+		return true
+	}
+	return false
+}
+
+func isInvalidReason(err error) bool {
+	switch err {
+	case orderUseCase.ErrMissingReason,
+		orderUseCase.ErrUnexpectedReason,
+		orderUseCase.ErrInvalidReasonCode,
+		orderUseCase.ErrMissingReturnedItems,
+		orderUseCase.ErrUnexpectedReturnedItems,
+		orderUseCase.ErrUnknownSku,
+		orderUseCase.ErrInvalidReturnQuantity:
+		return true
+	}
+	return false
+}
+
+// enumValues lists the values of an enum for the error of an unknown value.
+func enumValues[T ~string](values []T) string {
+	names := make([]string, len(values))
+	for i, value := range values {
+		names[i] = string(value)
+	}
+	return strings.Join(names, ", ")
+}
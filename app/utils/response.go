@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// WriteError writes the JSON error body with the status code. It replaces
+// http.Error, which sends the body as text/plain.
+func WriteError(w http.ResponseWriter, body string, code int) {
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	fmt.Fprintln(w, body)
+}
@@ -14,13 +14,15 @@ func GetFilters(r *http.Request) models.Filters {
 	orderIdInt, _ := strconv.Atoi(orderId)
 	documentNumber, _ := getQueryValue(r, "documentNumber")
 	status, _ := getQueryValue(r, "status")
+	channel, _ := getQueryValue(r, "channel")
 	createdOnFrom, _ := getQueryValue(r, "createdOnFrom")
 	createdOnTo, _ := getQueryValue(r, "createdOnTo")
 
 	filters := models.Filters{
 		OrderId:        int64(orderIdInt),
 		DocumentNumber: documentNumber,
-		Status:         status,
+		Status:         models.OrderStatus(status),
+		Channel:        models.Channel(channel),
 		CreatedOnFrom:  createdOnFrom,
 		CreatedOnTo:    createdOnTo,
 	}
@@ -28,6 +30,138 @@ func GetFilters(r *http.Request) models.Filters {
 	return filters
 }
 
+const (
+	DefaultPage     = 1
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+func GetEventFilters(r *http.Request) models.EventFilters {
+	eventType, _ := getQueryValue(r, "type")
+	user, _ := getQueryValue(r, "user")
+	dateFrom, _ := getQueryValue(r, "dateFrom")
+	dateTo, _ := getQueryValue(r, "dateTo")
+	page, _ := getQueryValue(r, "page")
+	pageInt, err := strconv.Atoi(page)
+	if err != nil || pageInt < 1 {
+		pageInt = DefaultPage
+	}
+	pageSize, _ := getQueryValue(r, "pageSize")
+	pageSizeInt, err := strconv.Atoi(pageSize)
+	if err != nil || pageSizeInt < 1 {
+		pageSizeInt = DefaultPageSize
+	}
+	if pageSizeInt > MaxPageSize {
+		pageSizeInt = MaxPageSize
+	}
+
+	filters := models.EventFilters{
+		Type:     models.EventType(eventType),
+		User:     user,
+		DateFrom: dateFrom,
+		DateTo:   dateTo,
+		Page:     int64(pageInt),
+		PageSize: int64(pageSizeInt),
+	}
+
+	return filters
+}
+
+func GetBuyerFilters(r *http.Request) models.BuyerFilters {
+	page, _ := getQueryValue(r, "page")
+	pageInt, err := strconv.Atoi(page)
+	if err != nil || pageInt < 1 {
+		pageInt = DefaultPage
+	}
+	pageSize, _ := getQueryValue(r, "pageSize")
+	pageSizeInt, err := strconv.Atoi(pageSize)
+	if err != nil || pageSizeInt < 1 {
+		pageSizeInt = DefaultPageSize
+	}
+	if pageSizeInt > MaxPageSize {
+		pageSizeInt = MaxPageSize
+	}
+
+	filters := models.BuyerFilters{
+		Page:     int64(pageInt),
+		PageSize: int64(pageSizeInt),
+	}
+
+	return filters
+}
+
+func GetAuditFilters(r *http.Request) models.AuditFilters {
+	actor, _ := getQueryValue(r, "actor")
+	action, _ := getQueryValue(r, "action")
+	orderId, _ := getQueryValue(r, "orderId")
+	orderIdInt, _ := strconv.Atoi(orderId)
+	from, _ := getQueryValue(r, "from")
+	to, _ := getQueryValue(r, "to")
+	page, _ := getQueryValue(r, "page")
+	pageInt, err := strconv.Atoi(page)
+	if err != nil || pageInt < 1 {
+		pageInt = DefaultPage
+	}
+	pageSize, _ := getQueryValue(r, "pageSize")
+	pageSizeInt, err := strconv.Atoi(pageSize)
+	if err != nil || pageSizeInt < 1 {
+		pageSizeInt = DefaultPageSize
+	}
+	if pageSizeInt > MaxPageSize {
+		pageSizeInt = MaxPageSize
+	}
+
+	filters := models.AuditFilters{
+		Actor:    actor,
+		Action:   action,
+		OrderId:  int64(orderIdInt),
+		From:     toUTC(from),
+		To:       toUTC(to),
+		Page:     int64(pageInt),
+		PageSize: int64(pageSizeInt),
+	}
+
+	return filters
+}
+
+func GetReportFilters(r *http.Request) models.ReportFilters {
+	reportType, _ := getQueryValue(r, "type")
+	from, _ := getQueryValue(r, "from")
+	to, _ := getQueryValue(r, "to")
+
+	filters := models.ReportFilters{
+		Type: models.EventType(reportType),
+		From: from,
+		To:   to,
+	}
+
+	return filters
+}
+
+func GetSalesFilters(r *http.Request) models.SalesFilters {
+	groupBy, _ := getQueryValue(r, "groupBy")
+	from, _ := getQueryValue(r, "from")
+	to, _ := getQueryValue(r, "to")
+
+	filters := models.SalesFilters{
+		GroupBy: groupBy,
+		From:    from,
+		To:      to,
+	}
+
+	return filters
+}
+
+// toUTC normalises a RFC3339 date to UTC so it can be compared with the
+// timestamps stored by the audit trail. Invalid dates are returned untouched.
+func toUTC(date string) string {
+	parsed, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return date
+	}
+	return parsed.UTC().Format(time.RFC3339)
+}
+
 func getQueryValue(r *http.Request, key string) (string, error) {
 	if !r.URL.Query().Has(key) {
 		return "", fmt.Errorf("missing query parameter: %s", key)
@@ -36,12 +170,13 @@ func getQueryValue(r *http.Request, key string) (string, error) {
 	return r.URL.Query().Get(key), nil
 }
 
+var ErrInvalidDate = errors.New("The date is not in the correct format")
+
 func CheckFormatDate(date string) error {
-	var err = errors.New("The date is not in the correct format")
 	if date != "" {
 		_, errParse := time.Parse(time.RFC3339, date)
 		if errParse != nil {
-			return err
+			return ErrInvalidDate
 		}
 	}
 	return nil